@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/javimartzs/worker-hub-backend/config"
+	"github.com/javimartzs/worker-hub-backend/logger"
+	"github.com/javimartzs/worker-hub-backend/scheduler"
+	"github.com/javimartzs/worker-hub-backend/services"
+	"go.uber.org/zap"
+)
+
+// scheduleOrDefault - Expresion cron de la configuracion o la de por defecto
+func scheduleOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// registerJobs - Registra las tareas programadas del servidor
+// --------------------------------------------------------------------
+func registerJobs(jobScheduler *scheduler.Scheduler, adminService *services.AdminService, holidayService *services.HolidayService, contractService *services.ContractService, assignmentService *services.AssignmentService) {
+	jobs := []struct {
+		name     string
+		schedule string
+		run      scheduler.JobFunc
+	}{
+		{"vacaciones_disfrutadas", scheduleOrDefault(config.Env.FinishHolidaysSchedule, "15 0 * * *"), holidayService.FinishPastHolidays},
+		{"estado_contratos", scheduleOrDefault(config.Env.ContractStatusSchedule, "5 0 * * *"), contractService.RefreshWorkerStatuses},
+		{"avisos_periodo_prueba", scheduleOrDefault(config.Env.TrialAlertsSchedule, "0 8 * * *"), contractService.SendTrialAlerts},
+		{"traslados_tienda", scheduleOrDefault(config.Env.StoreTransfersSchedule, "10 0 * * *"), assignmentService.ApplyScheduledTransfers},
+		{"purgar_archivados", scheduleOrDefault(config.Env.ArchivePurgeSchedule, "30 3 * * *"), adminService.PurgeArchived},
+	}
+
+	for _, job := range jobs {
+		if err := jobScheduler.Register(job.name, job.schedule, job.run); err != nil {
+			logger.Logger.Fatal("Failed to register scheduled job", zap.String("job", job.name), zap.Error(err))
+		}
+	}
+}
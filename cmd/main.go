@@ -8,6 +8,7 @@ import (
 	"github.com/javimartzs/worker-hub-backend/logger"
 	"github.com/javimartzs/worker-hub-backend/repositories"
 	"github.com/javimartzs/worker-hub-backend/routes"
+	"github.com/javimartzs/worker-hub-backend/scheduler"
 	"github.com/javimartzs/worker-hub-backend/services"
 )
 
@@ -26,19 +27,68 @@ func main() {
 	storeRepo := repositories.NewStoreRepository(db)
 	holidaysRepo := repositories.NewHolidaysRepository(db)
 	timelogRepo := repositories.NewTimelogRepository(db)
+	shiftRepo := repositories.NewShiftRepository(db)
+	shiftSwapRepo := repositories.NewShiftSwapRepository(db)
+	shiftClaimRepo := repositories.NewShiftClaimRepository(db)
+	calendarRepo := repositories.NewCalendarRepository(db)
+	scheduleRepo := repositories.NewScheduleRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	publicHolidayRepo := repositories.NewPublicHolidayRepository(db)
+	coverageRepo := repositories.NewCoverageRepository(db)
+	jobRepo := repositories.NewJobRepository(db)
+	orderRepo := repositories.NewOrderRepository(db)
+	productRepo := repositories.NewProductRepository(db)
+	stockRepo := repositories.NewStockRepository(db)
+	supplierRepo := repositories.NewSupplierRepository(db)
+	purchaseRepo := repositories.NewPurchaseOrderRepository(db)
+	contractRepo := repositories.NewContractRepository(db)
+	assignmentRepo := repositories.NewAssignmentRepository(db)
 
 	// Iniciamos las instancias de los servicios
-	adminService := services.NewAdminService(userRepo, workerRepo, storeRepo, holidaysRepo, timelogRepo, db)
+	adminService := services.NewAdminService(userRepo, workerRepo, storeRepo, holidaysRepo, timelogRepo, calendarRepo, publicHolidayRepo, contractRepo, assignmentRepo, db)
 	authService := services.NewAuthService(userRepo)
+	shiftService := services.NewShiftService(shiftRepo, shiftSwapRepo, shiftClaimRepo, workerRepo, storeRepo, holidaysRepo, calendarRepo, scheduleRepo, notificationRepo, publicHolidayRepo, contractRepo, db)
+	reportService := services.NewReportService(shiftRepo, timelogRepo, workerRepo, storeRepo, publicHolidayRepo, contractRepo)
+	calendarService := services.NewCalendarService(calendarRepo, shiftRepo, holidaysRepo, workerRepo, storeRepo)
+	notificationService := services.NewNotificationService(notificationRepo)
+	publicHolidayService := services.NewPublicHolidayService(publicHolidayRepo, storeRepo, db)
+	orderService := services.NewOrderService(orderRepo, productRepo, stockRepo, supplierRepo, purchaseRepo, storeRepo, userRepo, notificationRepo, db)
+	productService := services.NewProductService(productRepo, supplierRepo, storeRepo, db)
+	stockService := services.NewStockService(stockRepo, productRepo, storeRepo, db)
+	supplierService := services.NewSupplierService(supplierRepo, productRepo)
+	contractService := services.NewContractService(contractRepo, workerRepo, storeRepo, userRepo, notificationRepo, db)
+	assignmentService := services.NewAssignmentService(assignmentRepo, workerRepo, storeRepo, db)
+	holidayService := services.NewHolidayService(holidaysRepo, workerRepo, storeRepo, userRepo, calendarRepo, notificationRepo, publicHolidayRepo, coverageRepo, db)
+
+	// Iniciamos las tareas programadas
+	jobScheduler := scheduler.New(jobRepo)
+	registerJobs(jobScheduler, adminService, holidayService, contractService, assignmentService)
+	if config.Env.SchedulerEnabled != "false" {
+		jobScheduler.Start()
+		defer jobScheduler.Stop()
+	}
 
 	// Iniciamos las instancias de los handlers
 	adminHandler := handlers.NewAdminHandler(adminService)
 	authHandler := handlers.NewAuthHandler(authService)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	holidayHandler := handlers.NewHolidayHandler(holidayService)
+	publicHolidayHandler := handlers.NewPublicHolidayHandler(publicHolidayService)
+	jobHandler := handlers.NewJobHandler(jobScheduler)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	productHandler := handlers.NewProductHandler(productService)
+	stockHandler := handlers.NewStockHandler(stockService)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	contractHandler := handlers.NewContractHandler(contractService)
+	assignmentHandler := handlers.NewAssignmentHandler(assignmentService)
 	// Iniciamos el router de Gin
 	router := gin.Default()
 
 	// Configuramos las rutas
-	routes.SetupRoutes(router, adminHandler, authHandler)
+	routes.SetupRoutes(router, adminHandler, authHandler, shiftHandler, reportHandler, calendarHandler, notificationHandler, holidayHandler, publicHolidayHandler, jobHandler, orderHandler, productHandler, stockHandler, supplierHandler, contractHandler, assignmentHandler)
 
 	// Iniciamos el servidor
 	router.Run(":8080")
@@ -20,6 +20,23 @@ type Config struct {
 	Username  string
 	Password  string
 	StorePass string
+	UploadDir string // Directorio de los ficheros subidos; por defecto uploads
+
+	// Tareas programadas, ver scheduler/scheduler.go
+	SchedulerEnabled       string // false para no lanzar tareas en esta instancia
+	FinishHolidaysSchedule string // Expresion cron; por defecto 15 0 * * *
+	ContractStatusSchedule string // Expresion cron; por defecto 5 0 * * *
+	TrialAlertsSchedule    string // Expresion cron; por defecto 0 8 * * *
+	TrialAlertDays         string // Dias de antelacion del aviso de fin de prueba; por defecto 15
+	StoreTransfersSchedule string // Expresion cron; por defecto 10 0 * * *
+	ArchivePurgeSchedule   string // Expresion cron; por defecto 30 3 * * *
+	ArchiveRetentionDays   string // Dias que se conservan los archivados antes de purgarlos; por defecto 1461
+
+	// Vacaciones por defecto, ver services/holiday_balance.go
+	HolidayDayType        string // naturales o laborables
+	HolidayDays           string // Dias al año; 30 naturales o 22 laborables si esta vacio
+	HolidayCarryOverDays  string // Maximo de dias que pasan al año siguiente
+	HolidayCarryOverUntil string // MM-DD hasta el que se pueden disfrutar los dias arrastrados
 }
 
 func LoadEnv() {
@@ -40,6 +57,21 @@ func LoadEnv() {
 		Username:  os.Getenv("USERNAME"),
 		Password:  os.Getenv("PASSWORD"),
 		StorePass: os.Getenv("STORE_PASS"),
+		UploadDir: os.Getenv("UPLOAD_DIR"),
+
+		SchedulerEnabled:       os.Getenv("SCHEDULER_ENABLED"),
+		FinishHolidaysSchedule: os.Getenv("FINISH_HOLIDAYS_SCHEDULE"),
+		ContractStatusSchedule: os.Getenv("CONTRACT_STATUS_SCHEDULE"),
+		TrialAlertsSchedule:    os.Getenv("TRIAL_ALERTS_SCHEDULE"),
+		TrialAlertDays:         os.Getenv("TRIAL_ALERT_DAYS"),
+		StoreTransfersSchedule: os.Getenv("STORE_TRANSFERS_SCHEDULE"),
+		ArchivePurgeSchedule:   os.Getenv("ARCHIVE_PURGE_SCHEDULE"),
+		ArchiveRetentionDays:   os.Getenv("ARCHIVE_RETENTION_DAYS"),
+
+		HolidayDayType:        os.Getenv("HOLIDAY_DAY_TYPE"),
+		HolidayDays:           os.Getenv("HOLIDAY_DAYS"),
+		HolidayCarryOverDays:  os.Getenv("HOLIDAY_CARRY_OVER_DAYS"),
+		HolidayCarryOverUntil: os.Getenv("HOLIDAY_CARRY_OVER_UNTIL"),
 	}
 
 	logger.Logger.Info("Env file loaded succesfully")
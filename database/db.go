@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/javimartzs/worker-hub-backend/config"
@@ -11,6 +12,7 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Connect go to postgres
@@ -30,17 +32,61 @@ func ConnectDB() *gorm.DB {
 		logger.Logger.Error("Failed to connect PostgresDB", zap.Error(err))
 	}
 
+	migrateLegacyOrders(DB)
+	migrateStorePhones(DB)
+
 	DB.AutoMigrate(
 		&models.User{},
 		&models.Store{},
 		&models.Worker{},
+		&models.Contract{},
+		&models.TrialPeriodRule{},
+		&models.StoreAssignment{},
 		&models.Holiday{},
+		&models.LeaveType{},
 		&models.Timelog{},
+		&models.Supplier{},
+		&models.Product{},
+		&models.StoreProduct{},
 		&models.Order{},
+		&models.OrderLine{},
+		&models.OrderStatusChange{},
+		&models.OrderReceipt{},
+		&models.OrderReceiptLine{},
+		&models.StockLevel{},
+		&models.StockMovement{},
+		&models.StockCount{},
+		&models.StockCountLine{},
+		&models.ReorderParameter{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
 		&models.WorkShift{},
+		&models.ShiftSwap{},
+		&models.CalendarFeed{},
+		&models.CalendarCancellation{},
+		&models.SchedulePeriod{},
+		&models.ScheduleChange{},
+		&models.Notification{},
+		&models.ShiftClaim{},
+		&models.HolidayDecision{},
+		&models.HolidayEntitlement{},
+		&models.PublicHolidayCalendar{},
+		&models.PublicHoliday{},
+		&models.StoreHolidayCalendar{},
+		&models.StoreLeaveRule{},
+		&models.StoreBlackout{},
+		&models.ScheduledJob{},
+		&models.JobRun{},
 	)
 
+	migrateProductSuppliers(DB)
+	migrateOrderStatuses(DB)
+	normalizeIdentities(DB)
+	migrateWorkerStores(DB)
 	createInitialAdmin(DB)
+	migrateHolidayStatuses(DB)
+	createDefaultLeaveTypes(DB)
+	createDefaultTrialRules(DB)
 
 	logger.Logger.Info("Connected to postgres")
 	return DB
@@ -74,3 +120,346 @@ func createInitialAdmin(db *gorm.DB) {
 
 	logger.Logger.Info("Initial admin user created successfully")
 }
+
+// Migrate legacy holiday statuses to the request/approval workflow
+func migrateHolidayStatuses(db *gorm.DB) {
+	// Las vacaciones "Pendientes" las creaba el admin, asi que ya estaban aprobadas
+	result := db.Model(&models.Holiday{}).
+		Where("status = ?", models.HolidayStatusLegacyPending).
+		Update("status", models.HolidayStatusApproved)
+	if result.Error != nil {
+		logger.Logger.Error("Failed to migrate holiday statuses", zap.Error(result.Error))
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		logger.Logger.Info("Legacy holiday statuses migrated", zap.Int64("rows", result.RowsAffected))
+	}
+}
+
+// Create the default leave types, keeping the ones already configured
+func createDefaultLeaveTypes(db *gorm.DB) {
+	leaveTypes := models.DefaultLeaveTypes()
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&leaveTypes)
+	if result.Error != nil {
+		logger.Logger.Error("Failed to create default leave types", zap.Error(result.Error))
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		logger.Logger.Info("Default leave types created", zap.Int64("rows", result.RowsAffected))
+	}
+}
+
+// Create the default trial period rules, keeping the ones already configured
+func createDefaultTrialRules(db *gorm.DB) {
+	rules := models.DefaultTrialPeriodRules()
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rules)
+	if result.Error != nil {
+		logger.Logger.Error("Failed to create default trial period rules", zap.Error(result.Error))
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		logger.Logger.Info("Default trial period rules created", zap.Int64("rows", result.RowsAffected))
+	}
+}
+
+// Move the single product of legacy orders into order lines and number them
+func migrateLegacyOrders(db *gorm.DB) {
+	if !db.Migrator().HasTable("orders") || !db.Migrator().HasColumn("orders", "product") {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if !tx.Migrator().HasTable(&models.OrderLine{}) {
+			if err := tx.Migrator().CreateTable(&models.OrderLine{}); err != nil {
+				return err
+			}
+		}
+		statements := []string{
+			`INSERT INTO order_lines (order_id, product, quantity, comment)
+				SELECT id, product, quantity, '' FROM orders`,
+			`ALTER TABLE orders ADD COLUMN IF NOT EXISTS sequence bigint, ADD COLUMN IF NOT EXISTS number varchar(25)`,
+			// Numeramos los pedidos por tienda y año en orden de fecha
+			`UPDATE orders SET sequence = numbered.seq,
+				number = EXTRACT(YEAR FROM orders.date)::int || '-' || LPAD(numbered.seq::text, 4, '0')
+				FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY store_id, EXTRACT(YEAR FROM date) ORDER BY date, id) AS seq
+					FROM orders) AS numbered
+				WHERE orders.id = numbered.id`,
+			`ALTER TABLE orders DROP COLUMN product, DROP COLUMN quantity`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to migrate legacy orders", zap.Error(err))
+		return
+	}
+	logger.Logger.Info("Legacy orders migrated to order lines")
+}
+
+// Estados de texto libre de los pedidos anteriores al flujo de estados
+var legacyOrderStatuses = map[string]string{
+	"borrador":              models.OrderStatusDraft,
+	"pendiente":             models.OrderStatusSubmitted,
+	"solicitado":            models.OrderStatusSubmitted,
+	"nuevo":                 models.OrderStatusSubmitted,
+	"enviado":               models.OrderStatusSubmitted,
+	"aprobado":              models.OrderStatusApproved,
+	"aceptado":              models.OrderStatusApproved,
+	"confirmado":            models.OrderStatusApproved,
+	"pedido":                models.OrderStatusOrdered,
+	"pedido al proveedor":   models.OrderStatusOrdered,
+	"tramitado":             models.OrderStatusOrdered,
+	"en camino":             models.OrderStatusOrdered,
+	"parcial":               models.OrderStatusPartiallyReceived,
+	"recibido parcialmente": models.OrderStatusPartiallyReceived,
+	"recibido":              models.OrderStatusReceived,
+	"entregado":             models.OrderStatusReceived,
+	"completado":            models.OrderStatusReceived,
+	"finalizado":            models.OrderStatusReceived,
+	"cancelado":             models.OrderStatusCancelled,
+	"anulado":               models.OrderStatusCancelled,
+	"rechazado":             models.OrderStatusCancelled,
+}
+
+// migrateOrderStatuses - Pasa los estados de los pedidos antiguos a los del flujo de estados
+// --------------------------------------------------------------------
+// Sin esto ninguna transicion los acepta. Se reconocen sin tener en cuenta
+// mayusculas ni espacios; los desconocidos pasan a Enviado para que el admin
+// los revise.
+func migrateOrderStatuses(db *gorm.DB) {
+	var statuses []string
+	err := db.Model(&models.Order{}).Where("status NOT IN ?", models.OrderStatuses).
+		Distinct().Pluck("status", &statuses).Error
+	if err != nil {
+		logger.Logger.Error("Failed to read legacy order statuses", zap.Error(err))
+		return
+	}
+	if len(statuses) == 0 {
+		return
+	}
+
+	var migrated int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, status := range statuses {
+			target, ok := legacyOrderStatuses[strings.ToLower(strings.TrimSpace(status))]
+			if !ok {
+				target = models.OrderStatusSubmitted
+				logger.Logger.Warn("Unknown legacy order status, moved to submitted",
+					zap.String("status", status), zap.String("to", target))
+			}
+			result := tx.Model(&models.Order{}).Where("status = ?", status).Update("status", target)
+			if result.Error != nil {
+				return result.Error
+			}
+			migrated += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to migrate legacy order statuses", zap.Error(err))
+		return
+	}
+	logger.Logger.Info("Legacy order statuses migrated", zap.Int64("rows", migrated))
+}
+
+// migrateProductSuppliers - Pasa el proveedor de texto de los productos a la tabla de proveedores
+// --------------------------------------------------------------------
+// Crea un proveedor por cada nombre distinto y enlaza sus productos.
+func migrateProductSuppliers(db *gorm.DB) {
+	if !db.Migrator().HasColumn("products", "supplier") {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`INSERT INTO suppliers (id, name, delivery_days, active, created_at, updated_at)
+				SELECT gen_random_uuid()::text, name, '[]', true, NOW(), NOW()
+				FROM (SELECT DISTINCT TRIM(supplier) AS name FROM products WHERE TRIM(supplier) <> '') AS names
+				ON CONFLICT (name) DO NOTHING`,
+			`UPDATE products SET supplier_id = suppliers.id
+				FROM suppliers WHERE suppliers.name = TRIM(products.supplier)`,
+			`ALTER TABLE products DROP COLUMN supplier`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to migrate product suppliers", zap.Error(err))
+		return
+	}
+	logger.Logger.Info("Product suppliers migrated to suppliers table")
+}
+
+// migrateWorkerStores - Abre la asignacion principal de los trabajadores con tienda y sin historial
+// --------------------------------------------------------------------
+// Empieza en la fecha de alta del trabajador o, si no la tiene, hoy.
+func migrateWorkerStores(db *gorm.DB) {
+	result := db.Exec(`INSERT INTO store_assignments (worker_id, store_id, kind, start_date, comment, created_at)
+		SELECT id, store_id, ?, COALESCE(hire_date, CURRENT_DATE), 'Tienda anterior al historial de asignaciones', NOW()
+		FROM workers
+		WHERE store_id IS NOT NULL AND store_id <> ''
+			AND NOT EXISTS (SELECT 1 FROM store_assignments WHERE store_assignments.worker_id = workers.id)`,
+		models.AssignmentPrimary)
+	if result.Error != nil {
+		logger.Logger.Error("Failed to migrate worker stores", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Logger.Info("Worker stores migrated to store assignments", zap.Int64("rows", result.RowsAffected))
+	}
+}
+
+// migrateStorePhones - Pasa el telefono numerico de las tiendas a texto en formato internacional
+// --------------------------------------------------------------------
+// Los telefonos antiguos eran siempre españoles de 9 cifras; los vacios (0)
+// quedan en blanco para que se corrijan al editar la tienda.
+func migrateStorePhones(db *gorm.DB) {
+	if !db.Migrator().HasColumn("stores", "phone") {
+		return
+	}
+	columns, err := db.Migrator().ColumnTypes("stores")
+	if err != nil {
+		logger.Logger.Error("Failed to read store columns", zap.Error(err))
+		return
+	}
+	for _, column := range columns {
+		if column.Name() != "phone" {
+			continue
+		}
+		switch strings.ToLower(column.DatabaseTypeName()) {
+		case "int2", "int4", "int8", "integer", "bigint", "smallint":
+		default:
+			return
+		}
+	}
+
+	err = db.Exec(`ALTER TABLE stores ALTER COLUMN phone TYPE varchar(25)
+		USING CASE WHEN phone IS NULL OR phone = 0 THEN '' ELSE '+34' || phone::text END`).Error
+	if err != nil {
+		logger.Logger.Error("Failed to migrate store phones", zap.Error(err))
+		return
+	}
+	logger.Logger.Info("Store phones migrated to international format")
+}
+
+// normalizeIdentities - Deja los datos de identidad y contacto guardados en el formato de los formularios
+// --------------------------------------------------------------------
+// DNI/NIE en mayusculas y con sus ceros, emails en minusculas y telefonos
+// con prefijo internacional, tambien en los archivados. Los valores que no
+// validan se dejan como estan y los NIE que al normalizarse coinciden con el
+// de otro trabajador no se tocan; ambos casos se avisan en el log para
+// corregirlos a mano.
+func normalizeIdentities(db *gorm.DB) {
+	var workers []models.Worker
+	if err := db.Unscoped().Select("id", "nie", "email", "nss").Find(&workers).Error; err != nil {
+		logger.Logger.Error("Failed to read workers to normalize", zap.Error(err))
+		return
+	}
+	var stores []models.Store
+	if err := db.Unscoped().Select("id", "phone").Find(&stores).Error; err != nil {
+		logger.Logger.Error("Failed to read stores to normalize", zap.Error(err))
+		return
+	}
+	var suppliers []models.Supplier
+	if err := db.Select("id", "email", "phone").Find(&suppliers).Error; err != nil {
+		logger.Logger.Error("Failed to read suppliers to normalize", zap.Error(err))
+		return
+	}
+
+	// Normaliza un valor; si no valida se conserva y se avisa
+	invalid := 0
+	normalize := func(table, id, field, value string, normalizer func(field, value string) (string, error)) string {
+		if value == "" {
+			return value
+		}
+		normalized, err := normalizer(field, value)
+		if err != nil {
+			invalid++
+			logger.Logger.Warn("Stored value does not validate, left unchanged",
+				zap.String("table", table), zap.String("id", id), zap.String("field", field), zap.Error(err))
+			return value
+		}
+		return normalized
+	}
+
+	owners := make(map[string]string, len(workers))
+	for _, worker := range workers {
+		owners[worker.Nie] = worker.ID
+	}
+
+	updated, collisions := 0, 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, worker := range workers {
+			changes := map[string]interface{}{}
+			if nie := normalize("workers", worker.ID, "nie", worker.Nie, utils.NormalizeDocument); nie != worker.Nie {
+				if owner, taken := owners[nie]; taken && owner != worker.ID {
+					collisions++
+					logger.Logger.Warn("Normalized NIE collides with another worker, left unchanged",
+						zap.String("id", worker.ID), zap.String("other_id", owner), zap.String("nie", nie))
+				} else {
+					changes["nie"] = nie
+					delete(owners, worker.Nie)
+					owners[nie] = worker.ID
+				}
+			}
+			if email := normalize("workers", worker.ID, "email", worker.Email, utils.NormalizeEmail); email != worker.Email {
+				changes["email"] = email
+			}
+			if nss := normalize("workers", worker.ID, "nss", worker.Nss, utils.NormalizeSocialSecurity); nss != worker.Nss {
+				changes["nss"] = nss
+			}
+			if len(changes) > 0 {
+				if err := tx.Unscoped().Model(&models.Worker{}).Where("id = ?", worker.ID).Updates(changes).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+		}
+
+		for _, store := range stores {
+			if phone := normalize("stores", store.ID, "phone", store.Phone, utils.NormalizePhone); phone != store.Phone {
+				if err := tx.Unscoped().Model(&models.Store{}).Where("id = ?", store.ID).Update("phone", phone).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+		}
+
+		for _, supplier := range suppliers {
+			changes := map[string]interface{}{}
+			if email := normalize("suppliers", supplier.ID, "email", supplier.Email, utils.NormalizeEmail); email != supplier.Email {
+				changes["email"] = email
+			}
+			if phone := normalize("suppliers", supplier.ID, "phone", supplier.Phone, utils.NormalizePhone); phone != supplier.Phone {
+				changes["phone"] = phone
+			}
+			if len(changes) > 0 {
+				if err := tx.Model(&models.Supplier{}).Where("id = ?", supplier.ID).Updates(changes).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to normalize identity data", zap.Error(err))
+		return
+	}
+
+	if updated > 0 || invalid > 0 || collisions > 0 {
+		logger.Logger.Info("Identity data normalized",
+			zap.Int("rows", updated), zap.Int("invalid", invalid), zap.Int("nie_collisions", collisions))
+	}
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/javimartzs/worker-hub-backend/logger"
 	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
 	"github.com/javimartzs/worker-hub-backend/services"
+	"github.com/javimartzs/worker-hub-backend/utils"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +21,21 @@ func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
 	return &AdminHandler{adminService: adminService}
 }
 
+// invalidField - Responde 400 con el campo y el codigo si el error es de un dato mal formado
+// --------------------------------------------------------------------
+func invalidField(c *gin.Context, err error) bool {
+	var fieldErr *utils.FieldError
+	if !errors.As(err, &fieldErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": err.Error(),
+		"field": fieldErr.Field,
+		"code":  fieldErr.Code,
+	})
+	return true
+}
+
 // Handler para crear un trabajador y su usuario
 // --------------------------------------------------------------------
 func (h *AdminHandler) CreateWorker(c *gin.Context) {
@@ -34,6 +52,9 @@ func (h *AdminHandler) CreateWorker(c *gin.Context) {
 
 	// Llamamos al servicio para crear el trabajador y su usuario
 	if err := h.adminService.CreateWorker(&worker); err != nil {
+		if invalidField(c, err) {
+			return
+		}
 		logger.Logger.Error("CreateWorker: Worker creation failed", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -47,7 +68,7 @@ func (h *AdminHandler) CreateWorker(c *gin.Context) {
 	})
 }
 
-// Handler para eliminar un trabajador y su usuario asociado
+// Handler para archivar un trabajador y su usuario asociado
 // --------------------------------------------------------------------
 func (h *AdminHandler) DeleteWorker(c *gin.Context) {
 	workerID := c.Param("id")
@@ -65,22 +86,34 @@ func (h *AdminHandler) DeleteWorker(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Trabajador eliminado exitosamente",
+		"message": "Trabajador archivado exitosamente",
 	})
 }
 
-// Handler para obtener todos los trabajadores
+// Handler para obtener los trabajadores paginados
 // --------------------------------------------------------------------
+// Parametros: search, store_id, cargo, status, prueba, sort, order, limit
+// (50 por defecto, 200 maximo) y offset. Devuelve tambien el total.
 func (h *AdminHandler) GetAllWorkers(c *gin.Context) {
-	workers, err := h.adminService.GetAllWorkers()
+	var filter dtos.WorkerQuery
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Parametros de busqueda no validos",
+		})
+		return
+	}
+
+	page, err := h.adminService.SearchWorkers(filter)
 	if err != nil {
+		if invalidField(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "No se pudieron obtener los trabajadores", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"workers": workers})
+	c.JSON(http.StatusOK, page)
 }
 
 // Handler para actualizar datos de un trabajador
@@ -103,6 +136,9 @@ func (h *AdminHandler) UpdateWorker(c *gin.Context) {
 	}
 
 	if err := h.adminService.UpdateWorker(workerID, &worker); err != nil {
+		if invalidField(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Error al actualizar el trabajador",
 			"details": err.Error(),
@@ -131,6 +167,9 @@ func (h *AdminHandler) CreateStore(c *gin.Context) {
 
 	// Llamamos al servicio para crear la tienda
 	if err := h.adminService.CreateStore(&store); err != nil {
+		if invalidField(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -143,7 +182,7 @@ func (h *AdminHandler) CreateStore(c *gin.Context) {
 	})
 }
 
-// Handler para eliminar una tienda y su usuario asociado
+// Handler para archivar una tienda y su usuario asociado
 // --------------------------------------------------------------------
 func (h *AdminHandler) DeleteStore(c *gin.Context) {
 
@@ -155,7 +194,7 @@ func (h *AdminHandler) DeleteStore(c *gin.Context) {
 		return
 	}
 
-	// Llamamos al servicio para eliminar la tienda
+	// Llamamos al servicio para archivar la tienda
 	if err := h.adminService.DeleteStore(storeID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -165,7 +204,7 @@ func (h *AdminHandler) DeleteStore(c *gin.Context) {
 
 	// Devolvemos una respuesta exitosa
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Tienda eliminada exitosamente",
+		"message": "Tienda archivada exitosamente",
 	})
 }
 
@@ -204,6 +243,9 @@ func (h *AdminHandler) UpdateStore(c *gin.Context) {
 	}
 
 	if err := h.adminService.UpdateStore(storeID, &store); err != nil {
+		if invalidField(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Error al actualizar la tienda",
 			"details": err.Error(),
@@ -231,7 +273,8 @@ func (h *AdminHandler) CreateHoliday(c *gin.Context) {
 	}
 
 	// Llamamos al servicio para crear la vacacion
-	if err := h.adminService.CreateHoliday(&holiday); err != nil {
+	override := c.Query("override") == "true"
+	if err := h.adminService.CreateHoliday(&holiday, override); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -280,11 +323,8 @@ func (h *AdminHandler) GetHolidaysWithWorker(c *gin.Context) {
 // Handler para eliminar una vacacion
 // --------------------------------------------------------------------
 func (h *AdminHandler) DeleteHoliday(c *gin.Context) {
-	holidayID := c.Param("id")
-	if holidayID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "ID de la vacacion requerido",
-		})
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
 		return
 	}
 
@@ -300,38 +340,6 @@ func (h *AdminHandler) DeleteHoliday(c *gin.Context) {
 	})
 }
 
-// Handler para actualizar los datos de una vacacion
-// --------------------------------------------------------------------
-func (h *AdminHandler) UpdateHoliday(c *gin.Context) {
-	holidayID := c.Param("id")
-	if holidayID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "ID de la vacacion requerido",
-		})
-		return
-	}
-
-	var holiday models.Holiday
-	if err := c.ShouldBind(&holiday); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request",
-		})
-		return
-	}
-
-	if err := h.adminService.UpdateHoliday(holidayID, &holiday); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Vacacion actualizada correctamente",
-		"holiday": holiday,
-	})
-}
-
 // Handler para crear un nuevo usuario
 // --------------------------------------------------------------------
 func (h *AdminHandler) CreateUser(c *gin.Context) {
@@ -373,7 +381,7 @@ func (h *AdminHandler) GetAllUsers(c *gin.Context) {
 	})
 }
 
-// Handler para eliminar un usuario
+// Handler para archivar un usuario
 // --------------------------------------------------------------------
 func (h *AdminHandler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
@@ -392,7 +400,7 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Usuario eliminado correctamente",
+		"message": "Usuario archivado correctamente",
 	})
 }
 
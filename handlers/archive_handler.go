@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler para obtener los trabajadores archivados
+// --------------------------------------------------------------------
+func (h *AdminHandler) GetArchivedWorkers(c *gin.Context) {
+	workers, err := h.adminService.GetArchivedWorkers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workers": workers,
+	})
+}
+
+// Handler para restaurar un trabajador archivado
+// --------------------------------------------------------------------
+func (h *AdminHandler) RestoreWorker(c *gin.Context) {
+	if err := h.adminService.RestoreWorker(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Trabajador restaurado exitosamente",
+	})
+}
+
+// Handler para obtener las tiendas archivadas
+// --------------------------------------------------------------------
+func (h *AdminHandler) GetArchivedStores(c *gin.Context) {
+	stores, err := h.adminService.GetArchivedStores()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stores": stores,
+	})
+}
+
+// Handler para restaurar una tienda archivada
+// --------------------------------------------------------------------
+func (h *AdminHandler) RestoreStore(c *gin.Context) {
+	if err := h.adminService.RestoreStore(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tienda restaurada exitosamente",
+	})
+}
+
+// Handler para obtener los usuarios archivados
+// --------------------------------------------------------------------
+func (h *AdminHandler) GetArchivedUsers(c *gin.Context) {
+	users, err := h.adminService.GetArchivedUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+	})
+}
+
+// Handler para restaurar un usuario archivado
+// --------------------------------------------------------------------
+func (h *AdminHandler) RestoreUser(c *gin.Context) {
+	if err := h.adminService.RestoreUser(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Usuario restaurado correctamente",
+	})
+}
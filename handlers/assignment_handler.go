@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type AssignmentHandler struct {
+	assignmentService *services.AssignmentService
+}
+
+func NewAssignmentHandler(assignmentService *services.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{assignmentService: assignmentService}
+}
+
+// assignmentID - Lee el ID de asignacion de la ruta
+func assignmentID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de asignacion invalido",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// Handler para obtener el historial de tiendas de un trabajador
+// --------------------------------------------------------------------
+func (h *AssignmentHandler) GetWorkerAssignments(c *gin.Context) {
+	assignments, err := h.assignmentService.GetWorkerAssignments(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// Handler para trasladar a un trabajador a otra tienda
+// --------------------------------------------------------------------
+// Cuerpo: {store_id, start_date, comment}; una fecha futura programa el traslado
+func (h *AssignmentHandler) TransferWorker(c *gin.Context) {
+	var assignment models.StoreAssignment
+	if err := c.ShouldBindJSON(&assignment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.assignmentService.TransferWorker(userID, c.Param("id"), &assignment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Traslado registrado correctamente",
+		"assignment": assignment,
+	})
+}
+
+// Handler para asignar una tienda secundaria a un trabajador
+// --------------------------------------------------------------------
+// Cuerpo: {store_id, start_date, end_date, comment}
+func (h *AssignmentHandler) AddSecondaryStore(c *gin.Context) {
+	var assignment models.StoreAssignment
+	if err := c.ShouldBindJSON(&assignment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.assignmentService.AddSecondaryStore(userID, c.Param("id"), &assignment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Tienda secundaria asignada correctamente",
+		"assignment": assignment,
+	})
+}
+
+// Handler para poner fecha de fin a una asignacion
+// --------------------------------------------------------------------
+// Cuerpo: {end_date}
+func (h *AssignmentHandler) EndAssignment(c *gin.Context) {
+	id, ok := assignmentID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		EndDate string `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.assignmentService.EndAssignment(id, body.EndDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Asignacion finalizada correctamente",
+	})
+}
+
+// Handler para anular una asignacion o un traslado programado
+// --------------------------------------------------------------------
+func (h *AssignmentHandler) CancelAssignment(c *gin.Context) {
+	id, ok := assignmentID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.assignmentService.CancelAssignment(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Asignacion anulada correctamente",
+	})
+}
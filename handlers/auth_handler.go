@@ -45,3 +45,65 @@ func (h *AuthHandler) LoginAdmin(c *gin.Context) {
 	// Devolvemos el token
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// Handler para el login del panel de tiendas
+// --------------------------------------------------------------------
+func (h *AuthHandler) LoginStore(c *gin.Context) {
+
+	// Recogemos los datos del body
+	var request struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	// Decodificamos el body
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	// Llamamos al servicio de autenticación
+	token, err := h.authService.LoginStore(request.Username, request.Password)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Devolvemos el token
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// Handler para el login de la app de trabajadores
+// --------------------------------------------------------------------
+func (h *AuthHandler) LoginWorker(c *gin.Context) {
+
+	// Recogemos los datos del body
+	var request struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	// Decodificamos el body
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	// Llamamos al servicio de autenticación
+	token, err := h.authService.LoginWorker(request.Username, request.Password)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Devolvemos el token
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
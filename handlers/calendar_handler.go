@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+}
+
+func NewCalendarHandler(calendarService *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService}
+}
+
+// feedURL - Construye la URL publica de suscripcion de un calendario
+// --------------------------------------------------------------------
+func feedURL(c *gin.Context, feed *models.CalendarFeed) string {
+	scheme := "https"
+	if c.Request.TLS == nil && c.GetHeader("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/api/calendar/%s.ics", scheme, c.Request.Host, feed.Token)
+}
+
+// Handler publico que sirve el calendario iCalendar de una suscripcion
+// --------------------------------------------------------------------
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	name, content, err := h.calendarService.RenderFeed(token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", name+".ics"))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(content))
+}
+
+// Handler para crear o renovar la suscripcion del usuario autenticado
+// --------------------------------------------------------------------
+func (h *CalendarHandler) CreateOwnFeed(c *gin.Context) {
+	userID, role := currentUser(c)
+	feed, err := h.calendarService.CreateOwnFeed(userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Suscripcion de calendario creada correctamente",
+		"url":     feedURL(c, feed),
+	})
+}
+
+// Handler para revocar la suscripcion del usuario autenticado
+// --------------------------------------------------------------------
+func (h *CalendarHandler) RevokeOwnFeed(c *gin.Context) {
+	userID, role := currentUser(c)
+	if err := h.calendarService.RevokeOwnFeed(userID, role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Suscripcion de calendario revocada",
+	})
+}
+
+// Handler para crear la suscripcion de un trabajador o una tienda (admin)
+// --------------------------------------------------------------------
+func (h *CalendarHandler) CreateFeed(c *gin.Context) {
+	var request struct {
+		WorkerID string `form:"worker_id" json:"worker_id"`
+		StoreID  string `form:"store_id" json:"store_id"`
+	}
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	feed, err := h.calendarService.CreateFeed(request.WorkerID, request.StoreID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Suscripcion de calendario creada correctamente",
+		"feed":    feed,
+		"url":     feedURL(c, feed),
+	})
+}
+
+// Handler para obtener las suscripciones de calendario (admin)
+// --------------------------------------------------------------------
+func (h *CalendarHandler) GetFeeds(c *gin.Context) {
+	feeds, err := h.calendarService.GetFeeds(c.Query("worker_id"), c.Query("store_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las suscripciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feeds": feeds,
+	})
+}
+
+// Handler para revocar una suscripcion de calendario (admin)
+// --------------------------------------------------------------------
+func (h *CalendarHandler) RevokeFeed(c *gin.Context) {
+	feedID := c.Param("id")
+	if feedID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la suscripcion requerido",
+		})
+		return
+	}
+
+	if err := h.calendarService.RevokeFeed(feedID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Suscripcion de calendario revocada",
+	})
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// currentUser - Devuelve el ID y el rol del usuario autenticado
+// --------------------------------------------------------------------
+// Los valores los guarda AuthMiddleware a partir de los claims del token.
+func currentUser(c *gin.Context) (string, string) {
+	id, _ := c.Get("id")
+	role, _ := c.Get("role")
+	userID, _ := id.(string)
+	userRole, _ := role.(string)
+	return userID, userRole
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type ContractHandler struct {
+	contractService *services.ContractService
+}
+
+func NewContractHandler(contractService *services.ContractService) *ContractHandler {
+	return &ContractHandler{contractService: contractService}
+}
+
+// Handler para obtener el historial de contratos de un trabajador
+// --------------------------------------------------------------------
+func (h *ContractHandler) GetWorkerContracts(c *gin.Context) {
+	contracts, err := h.contractService.GetWorkerContracts(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, contracts)
+}
+
+// Handler para que un trabajador consulte sus contratos
+// --------------------------------------------------------------------
+func (h *ContractHandler) GetOwnContracts(c *gin.Context) {
+	userID, _ := currentUser(c)
+	contracts, err := h.contractService.GetOwnContracts(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, contracts)
+}
+
+// Handler para registrar un contrato o una renovacion de un trabajador
+// --------------------------------------------------------------------
+func (h *ContractHandler) CreateContract(c *gin.Context) {
+	var contract models.Contract
+	if err := c.ShouldBindJSON(&contract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.contractService.CreateContract(c.Param("id"), &contract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Contrato registrado correctamente",
+		"contract": contract,
+	})
+}
+
+// Handler para corregir los datos de un contrato
+// --------------------------------------------------------------------
+func (h *ContractHandler) UpdateContract(c *gin.Context) {
+	var contract models.Contract
+	if err := c.ShouldBindJSON(&contract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.contractService.UpdateContract(c.Param("id"), &contract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Contrato actualizado correctamente",
+		"contract": contract,
+	})
+}
+
+// Handler para poner fecha de fin a un contrato
+// --------------------------------------------------------------------
+// Cuerpo: {end_date}
+func (h *ContractHandler) EndContract(c *gin.Context) {
+	var body struct {
+		EndDate string `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.contractService.EndContract(c.Param("id"), body.EndDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Contrato finalizado correctamente",
+	})
+}
+
+// Handler para registrar el resultado del periodo de prueba
+// --------------------------------------------------------------------
+// Cuerpo: {outcome, date, notes}
+func (h *ContractHandler) RecordTrialOutcome(c *gin.Context) {
+	var request dtos.TrialOutcomeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.contractService.RecordTrialOutcome(userID, role, c.Param("id"), &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Resultado del periodo de prueba registrado correctamente",
+	})
+}
+
+// Handler para obtener los periodos de prueba que terminan pronto
+// --------------------------------------------------------------------
+// Parametros: days (opcional, por defecto la antelacion del aviso)
+func (h *ContractHandler) GetUpcomingTrials(c *gin.Context) {
+	days := 0
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "days debe ser un numero mayor que cero",
+			})
+			return
+		}
+		days = parsed
+	}
+
+	userID, role := currentUser(c)
+	trials, err := h.contractService.GetUpcomingTrials(userID, role, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, trials)
+}
+
+// Handler para obtener las reglas del periodo de prueba
+// --------------------------------------------------------------------
+func (h *ContractHandler) GetTrialRules(c *gin.Context) {
+	rules, err := h.contractService.GetTrialRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// Handler para crear o sustituir una regla del periodo de prueba
+// --------------------------------------------------------------------
+func (h *ContractHandler) SaveTrialRule(c *gin.Context) {
+	var rule models.TrialPeriodRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.contractService.SaveTrialRule(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regla del periodo de prueba guardada correctamente",
+		"rule":    rule,
+	})
+}
+
+// Handler para eliminar una regla del periodo de prueba
+// --------------------------------------------------------------------
+func (h *ContractHandler) DeleteTrialRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de regla invalido",
+		})
+		return
+	}
+
+	if err := h.contractService.DeleteTrialRule(ruleID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regla del periodo de prueba eliminada correctamente",
+	})
+}
@@ -0,0 +1,601 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/logger"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+	"go.uber.org/zap"
+)
+
+type HolidayHandler struct {
+	holidayService *services.HolidayService
+}
+
+func NewHolidayHandler(holidayService *services.HolidayService) *HolidayHandler {
+	return &HolidayHandler{holidayService: holidayService}
+}
+
+// coverageConflict - Responde 409 con los conflictos si el error es de cobertura
+// --------------------------------------------------------------------
+func coverageConflict(c *gin.Context, err error) bool {
+	var coverageErr *services.CoverageError
+	if !errors.As(err, &coverageErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":     err.Error(),
+		"conflicts": coverageErr.Conflicts,
+	})
+	return true
+}
+
+// Handler para que un trabajador solicite vacaciones
+// --------------------------------------------------------------------
+func (h *HolidayHandler) RequestHoliday(c *gin.Context) {
+
+	// Parseamos el cuerpo de la solicitud
+	var holiday models.Holiday
+	if err := c.ShouldBind(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	// Llamamos al servicio para crear la solicitud
+	userID, _ := currentUser(c)
+	if err := h.holidayService.RequestHoliday(userID, &holiday); err != nil {
+		logger.Logger.Error("RequestHoliday: Holiday request failed", zap.Error(err))
+		if coverageConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Devolvemos una respuesta exitosa
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vacaciones solicitadas correctamente",
+		"holiday": holiday,
+	})
+}
+
+// Handler para obtener las vacaciones del trabajador
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetWorkerHolidays(c *gin.Context) {
+	userID, _ := currentUser(c)
+	holidays, err := h.holidayService.GetWorkerHolidays(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las vacaciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, holidays)
+}
+
+// parseHolidayID - Lee el ID numerico de unas vacaciones de la ruta
+// --------------------------------------------------------------------
+func parseHolidayID(c *gin.Context) (int, bool) {
+	holidayID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la vacacion requerido",
+		})
+		return 0, false
+	}
+	return holidayID, true
+}
+
+// Handler para que un trabajador cancele sus vacaciones
+// --------------------------------------------------------------------
+func (h *HolidayHandler) CancelHoliday(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.holidayService.CancelHoliday(userID, holidayID, bindNote(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vacaciones canceladas",
+	})
+}
+
+// Handler para obtener las solicitudes de vacaciones (encargado o admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetHolidayRequests(c *gin.Context) {
+	userID, role := currentUser(c)
+	holidays, err := h.holidayService.GetHolidayRequests(userID, role, c.Query("store_id"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las vacaciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, holidays)
+}
+
+// Handler para obtener el historial de decisiones de unas vacaciones
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetHolidayDecisions(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	userID, role := currentUser(c)
+	decisions, err := h.holidayService.GetHolidayDecisions(userID, role, holidayID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo obtener el historial", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, decisions)
+}
+
+// Handler para aprobar unas vacaciones (encargado o admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) ApproveHoliday(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	userID, role := currentUser(c)
+	override := role == "admin" && c.Query("override") == "true"
+	if err := h.holidayService.ApproveHoliday(userID, role, holidayID, bindNote(c), override); err != nil {
+		if coverageConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vacaciones aprobadas",
+	})
+}
+
+// Handler para que el admin edite unas vacaciones
+// --------------------------------------------------------------------
+func (h *HolidayHandler) UpdateHoliday(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	var holiday models.Holiday
+	if err := c.ShouldBind(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	override := c.Query("override") == "true"
+	if err := h.holidayService.UpdateHoliday(userID, holidayID, &holiday, override); err != nil {
+		if coverageConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vacacion actualizada correctamente",
+		"holiday": holiday,
+	})
+}
+
+// Handler para rechazar unas vacaciones (encargado o admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) RejectHoliday(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.holidayService.RejectHoliday(userID, role, holidayID, bindNote(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Vacaciones rechazadas",
+	})
+}
+
+// parseBalanceYear - Lee el año del saldo, por defecto el actual
+// --------------------------------------------------------------------
+func parseBalanceYear(c *gin.Context) (int, error) {
+	value := c.Query("year")
+	if value == "" {
+		return time.Now().Year(), nil
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 2000 || year > 2100 {
+		return 0, errors.New("el año no es valido")
+	}
+	return year, nil
+}
+
+// Handler para obtener el saldo de vacaciones de un trabajador (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetHolidayBalance(c *gin.Context) {
+	workerID := c.Param("id")
+	year, err := parseBalanceYear(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	balance, err := h.holidayService.GetHolidayBalance(workerID, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo calcular el saldo de vacaciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// Handler para obtener el saldo de vacaciones del trabajador autenticado
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetOwnHolidayBalance(c *gin.Context) {
+	year, err := parseBalanceYear(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	balance, err := h.holidayService.GetOwnHolidayBalance(userID, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo calcular el saldo de vacaciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// Handler para fijar el derecho a vacaciones de un trabajador en un año (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) SetHolidayEntitlement(c *gin.Context) {
+	var entitlement models.HolidayEntitlement
+	if err := c.ShouldBind(&entitlement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.holidayService.SetHolidayEntitlement(c.Param("id"), &entitlement); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Derecho a vacaciones guardado correctamente",
+		"entitlement": entitlement,
+	})
+}
+
+// Handler para obtener los tipos de ausencia
+// --------------------------------------------------------------------
+// Los trabajadores solo ven los tipos activos.
+func (h *HolidayHandler) GetLeaveTypes(c *gin.Context) {
+	_, role := currentUser(c)
+	leaveTypes, err := h.holidayService.GetLeaveTypes(role != "admin")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener los tipos de ausencia", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, leaveTypes)
+}
+
+// Handler para crear un tipo de ausencia (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) CreateLeaveType(c *gin.Context) {
+	var leaveType models.LeaveType
+	if err := c.ShouldBind(&leaveType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.holidayService.CreateLeaveType(&leaveType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Tipo de ausencia creado correctamente",
+		"leave_type": leaveType,
+	})
+}
+
+// Handler para actualizar un tipo de ausencia (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) UpdateLeaveType(c *gin.Context) {
+	var leaveType models.LeaveType
+	if err := c.ShouldBind(&leaveType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.holidayService.UpdateLeaveType(c.Param("code"), &leaveType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Tipo de ausencia actualizado correctamente",
+		"leave_type": leaveType,
+	})
+}
+
+// Handler para adjuntar el justificante de una ausencia (campo file)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) AttachDocument(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	path, err := saveUpload(c, "file", "justificantes", documentExtensions, 5<<20)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	previous, err := h.holidayService.AttachDocument(userID, role, holidayID, path)
+	if err != nil {
+		removeUpload(path)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	removeUpload(previous)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Justificante adjuntado correctamente",
+	})
+}
+
+// Handler para descargar el justificante de una ausencia
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetDocument(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	userID, role := currentUser(c)
+	path, err := h.holidayService.GetDocument(userID, role, holidayID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// Handler para comprobar unas vacaciones contra las reglas de cobertura sin solicitarlas
+// --------------------------------------------------------------------
+func (h *HolidayHandler) CheckHoliday(c *gin.Context) {
+	var holiday models.Holiday
+	if err := c.ShouldBind(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	conflicts, err := h.holidayService.CheckHoliday(userID, role, &holiday)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"allowed":   len(conflicts) == 0,
+		"conflicts": conflicts,
+	})
+}
+
+// Handler para obtener la linea de tiempo de ausencias de una tienda (encargado o admin)
+// --------------------------------------------------------------------
+// Parametros: from, to (YYYY-MM-DD) y store_id (solo admin)
+func (h *HolidayHandler) GetLeaveTimeline(c *gin.Context) {
+	userID, role := currentUser(c)
+	timeline, err := h.holidayService.GetLeaveTimeline(userID, role,
+		c.Query("store_id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
+
+// Handler para obtener la linea de tiempo de la tienda alrededor de una solicitud
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetHolidayTimeline(c *gin.Context) {
+	holidayID, ok := parseHolidayID(c)
+	if !ok {
+		return
+	}
+
+	userID, role := currentUser(c)
+	timeline, err := h.holidayService.GetHolidayTimeline(userID, role, holidayID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
+
+// Handler para obtener las reglas de cobertura y los periodos bloqueados de una tienda (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) GetCoverageRules(c *gin.Context) {
+	rules, blackouts, err := h.holidayService.GetCoverageRules(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las reglas de cobertura", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":     rules,
+		"blackouts": blackouts,
+	})
+}
+
+// Handler para crear una regla de cobertura en una tienda (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) CreateLeaveRule(c *gin.Context) {
+	var rule models.StoreLeaveRule
+	if err := c.ShouldBind(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.holidayService.CreateLeaveRule(c.Param("id"), &rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regla de cobertura creada correctamente",
+		"rule":    rule,
+	})
+}
+
+// Handler para eliminar una regla de cobertura (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) DeleteLeaveRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de regla invalido",
+		})
+		return
+	}
+
+	if err := h.holidayService.DeleteLeaveRule(ruleID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regla de cobertura eliminada correctamente",
+	})
+}
+
+// Handler para crear un periodo bloqueado en una tienda (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) CreateBlackout(c *gin.Context) {
+	var blackout models.StoreBlackout
+	if err := c.ShouldBind(&blackout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.holidayService.CreateBlackout(c.Param("id"), &blackout); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Periodo bloqueado creado correctamente",
+		"blackout": blackout,
+	})
+}
+
+// Handler para eliminar un periodo bloqueado (admin)
+// --------------------------------------------------------------------
+func (h *HolidayHandler) DeleteBlackout(c *gin.Context) {
+	blackoutID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de periodo bloqueado invalido",
+		})
+		return
+	}
+
+	if err := h.holidayService.DeleteBlackout(blackoutID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Periodo bloqueado eliminado correctamente",
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+)
+
+// Tamaño maximo del CSV o XLSX de trabajadores o tiendas
+const maxPeopleImportSize = 5 << 20
+
+// readImportFile - Lee el fichero subido en el campo file; si falla ya ha respondido
+func readImportFile(c *gin.Context) ([]byte, bool) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fichero requerido en el campo file",
+		})
+		return nil, false
+	}
+	if file.Size > maxPeopleImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "El fichero no puede superar 5 MB",
+		})
+		return nil, false
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No se pudo leer el fichero",
+		})
+		return nil, false
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No se pudo leer el fichero",
+		})
+		return nil, false
+	}
+	return data, true
+}
+
+// respondImport - Devuelve el informe de una importacion
+func respondImport(c *gin.Context, result *dtos.ImportResult, err error, message string) {
+	if err != nil {
+		response := gin.H{"error": err.Error()}
+		if result != nil {
+			response["errors"] = result.Errors
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if !result.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message": message,
+			"created": result.Created,
+			"preview": result.Preview,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Handler para importar trabajadores desde un CSV o XLSX (campo file)
+// --------------------------------------------------------------------
+// Con ?dry_run=true solo valida y devuelve el informe sin guardar nada
+func (h *AdminHandler) ImportWorkers(c *gin.Context) {
+	data, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+	result, err := h.adminService.ImportWorkers(data, c.Query("dry_run") == "true")
+	respondImport(c, result, err, "Trabajadores importados correctamente")
+}
+
+// Handler para importar tiendas desde un CSV o XLSX (campo file)
+// --------------------------------------------------------------------
+// Con ?dry_run=true solo valida y devuelve el informe sin guardar nada
+func (h *AdminHandler) ImportStores(c *gin.Context) {
+	data, ok := readImportFile(c)
+	if !ok {
+		return
+	}
+	result, err := h.adminService.ImportStores(data, c.Query("dry_run") == "true")
+	respondImport(c, result, err, "Tiendas importadas correctamente")
+}
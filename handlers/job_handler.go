@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/scheduler"
+)
+
+type JobHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewJobHandler(scheduler *scheduler.Scheduler) *JobHandler {
+	return &JobHandler{scheduler: scheduler}
+}
+
+// Handler para obtener las tareas programadas
+// --------------------------------------------------------------------
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	jobs, err := h.scheduler.Jobs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las tareas", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// Handler para obtener el historial de ejecuciones
+// --------------------------------------------------------------------
+// Parametros: job (opcional) para filtrar por tarea
+func (h *JobHandler) GetJobRuns(c *gin.Context) {
+	runs, err := h.scheduler.Runs(c.Query("job"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo obtener el historial", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// Handler para lanzar una tarea en el momento
+// --------------------------------------------------------------------
+func (h *JobHandler) RunJob(c *gin.Context) {
+	run, err := h.scheduler.RunNow(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tarea ejecutada",
+		"run":     run,
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// Handler para obtener las notificaciones del usuario autenticado
+// --------------------------------------------------------------------
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, _ := currentUser(c)
+	notifications, err := h.notificationService.GetNotifications(userID, c.Query("unread") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las notificaciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+	})
+}
+
+// Handler para marcar una notificacion como leida
+// --------------------------------------------------------------------
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+	notificationID := c.Param("id")
+	if notificationID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la notificacion requerido",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.notificationService.MarkAsRead(userID, notificationID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notificacion marcada como leida",
+	})
+}
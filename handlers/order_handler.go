@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type OrderHandler struct {
+	orderService *services.OrderService
+}
+
+func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
+	return &OrderHandler{orderService: orderService}
+}
+
+// orderTransitionConflict - Responde 409 con los estados permitidos si el cambio de estado no es valido
+// --------------------------------------------------------------------
+func orderTransitionConflict(c *gin.Context, err error) bool {
+	var transitionErr *services.OrderTransitionError
+	if !errors.As(err, &transitionErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":         err.Error(),
+		"status":        transitionErr.From,
+		"next_statuses": transitionErr.Allowed,
+	})
+	return true
+}
+
+// Handler para que una tienda cree un pedido
+// --------------------------------------------------------------------
+// Con draft=true se guarda como borrador sin enviarlo.
+func (h *OrderHandler) CreateOrder(c *gin.Context) {
+	var order models.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	draft := c.Query("draft") == "true"
+	if err := h.orderService.CreateOrder(userID, &order, draft); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pedido guardado correctamente",
+		"order":   order,
+	})
+}
+
+// Handler para obtener los pedidos
+// --------------------------------------------------------------------
+// Parametros: status, from, to (YYYY-MM-DD) y store_id (solo admin)
+func (h *OrderHandler) GetOrders(c *gin.Context) {
+	userID, role := currentUser(c)
+	orders, err := h.orderService.GetOrders(userID, role,
+		c.Query("store_id"), c.Query("status"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// Handler para obtener un pedido con sus lineas
+// --------------------------------------------------------------------
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	userID, role := currentUser(c)
+	order, err := h.orderService.GetOrder(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Handler para aprobar un pedido (admin)
+// --------------------------------------------------------------------
+func (h *OrderHandler) ApproveOrder(c *gin.Context) {
+	userID, _ := currentUser(c)
+	if err := h.orderService.ApproveOrder(userID, c.Param("id"), bindNote(c)); err != nil {
+		if orderTransitionConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pedido aprobado",
+	})
+}
+
+// Handler para cancelar un pedido (tienda) o rechazarlo (admin)
+// --------------------------------------------------------------------
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	userID, role := currentUser(c)
+	if err := h.orderService.CancelOrder(userID, role, c.Param("id"), bindNote(c)); err != nil {
+		if orderTransitionConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pedido cancelado",
+	})
+}
+
+// Handler para que una tienda modifique un pedido en borrador
+// --------------------------------------------------------------------
+func (h *OrderHandler) UpdateDraft(c *gin.Context) {
+	var order models.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.orderService.UpdateDraft(userID, c.Param("id"), &order); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pedido actualizado correctamente",
+	})
+}
+
+// Handler para que una tienda envie un pedido en borrador
+// --------------------------------------------------------------------
+func (h *OrderHandler) SubmitOrder(c *gin.Context) {
+	userID, role := currentUser(c)
+	if err := h.orderService.TransitionOrder(userID, role, c.Param("id"), models.OrderStatusSubmitted, bindNote(c)); err != nil {
+		if orderTransitionConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pedido enviado",
+	})
+}
+
+// Handler para cambiar el estado de un pedido
+// --------------------------------------------------------------------
+// Cuerpo: status y note (opcional)
+func (h *OrderHandler) TransitionOrder(c *gin.Context) {
+	var request struct {
+		Status string `form:"status" json:"status" binding:"required"`
+		Note   string `form:"note" json:"note"`
+	}
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.orderService.TransitionOrder(userID, role, c.Param("id"), request.Status, request.Note); err != nil {
+		if orderTransitionConflict(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Estado del pedido actualizado",
+		"status":  request.Status,
+	})
+}
+
+// Handler para obtener el historial de estados de un pedido
+// --------------------------------------------------------------------
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
+	userID, role := currentUser(c)
+	history, next, err := h.orderService.GetOrderHistory(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history":       history,
+		"next_statuses": next,
+	})
+}
+
+// Handler para registrar una entrega de mercancia de un pedido
+// --------------------------------------------------------------------
+func (h *OrderHandler) ReceiveOrder(c *gin.Context) {
+	var receipt models.OrderReceipt
+	if err := c.ShouldBindJSON(&receipt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.orderService.ReceiveOrder(userID, role, c.Param("id"), &receipt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recepcion registrada correctamente",
+		"receipt": receipt,
+	})
+}
+
+// Handler para obtener las recepciones de un pedido
+// --------------------------------------------------------------------
+func (h *OrderHandler) GetOrderReceipts(c *gin.Context) {
+	userID, role := currentUser(c)
+	receipts, err := h.orderService.GetOrderReceipts(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, receipts)
+}
+
+// Handler para adjuntar la foto de una recepcion (campo file)
+// --------------------------------------------------------------------
+func (h *OrderHandler) AttachReceiptPhoto(c *gin.Context) {
+	path, err := saveUpload(c, "file", "recepciones", documentExtensions, 5<<20)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	previous, err := h.orderService.AttachReceiptPhoto(userID, role, c.Param("id"), path)
+	if err != nil {
+		removeUpload(path)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	removeUpload(previous)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Foto adjuntada correctamente",
+	})
+}
+
+// Handler para descargar la foto de una recepcion
+// --------------------------------------------------------------------
+func (h *OrderHandler) GetReceiptPhoto(c *gin.Context) {
+	userID, role := currentUser(c)
+	path, err := h.orderService.GetReceiptPhoto(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// Handler para el informe de incidencias de las recepciones
+// --------------------------------------------------------------------
+// Parametros: from, to, store_id y supplier_id (opcionales los dos ultimos)
+func (h *OrderHandler) GetDiscrepancyReport(c *gin.Context) {
+	report, err := h.orderService.GetDiscrepancyReport(c.Query("from"), c.Query("to"), c.Query("store_id"), c.Query("supplier_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Handler para obtener las cantidades sugeridas del proximo pedido
+// --------------------------------------------------------------------
+// El admin indica la tienda en la ruta; la tienda ve la suya.
+func (h *OrderHandler) GetReorderSuggestions(c *gin.Context) {
+	userID, role := currentUser(c)
+	suggestions, err := h.orderService.GetReorderSuggestions(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// Handler para crear borradores de pedido con las cantidades sugeridas
+// --------------------------------------------------------------------
+// El admin puede indicar store_id; sin ella se crean para todas las tiendas.
+func (h *OrderHandler) CreateSuggestedDrafts(c *gin.Context) {
+	userID, role := currentUser(c)
+	orders, err := h.orderService.CreateSuggestedDrafts(userID, role, c.Query("store_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  err.Error(),
+			"orders": orders,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("%d borradores creados", len(orders)),
+		"orders":  orders,
+	})
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+// Tamaño maximo de un CSV de productos
+const maxProductImportSize = 5 << 20
+
+type ProductHandler struct {
+	productService *services.ProductService
+}
+
+func NewProductHandler(productService *services.ProductService) *ProductHandler {
+	return &ProductHandler{productService: productService}
+}
+
+// Handler para crear un producto del catalogo
+// --------------------------------------------------------------------
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var product models.Product
+	if err := c.ShouldBind(&product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.productService.CreateProduct(&product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Producto creado correctamente",
+		"product": product,
+	})
+}
+
+// Handler para actualizar un producto del catalogo
+// --------------------------------------------------------------------
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	var product models.Product
+	if err := c.ShouldBind(&product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.productService.UpdateProduct(c.Param("id"), &product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Producto actualizado correctamente",
+		"product": product,
+	})
+}
+
+// Handler para obtener el catalogo
+// --------------------------------------------------------------------
+// Parametros: category y active=true para ver solo los activos
+func (h *ProductHandler) GetProducts(c *gin.Context) {
+	products, err := h.productService.GetProducts(c.Query("category"), c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo obtener el catalogo", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// Handler para importar el catalogo desde un CSV (campo file)
+// --------------------------------------------------------------------
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fichero requerido en el campo file",
+		})
+		return
+	}
+	if file.Size > maxProductImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "El fichero no puede superar 5 MB",
+		})
+		return
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No se pudo leer el fichero",
+		})
+		return
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No se pudo leer el fichero",
+		})
+		return
+	}
+
+	result, err := h.productService.ImportProducts(data)
+	if err != nil {
+		response := gin.H{"error": err.Error()}
+		if result != nil {
+			response["errors"] = result.Errors
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Catalogo importado correctamente",
+		"created": result.Created,
+		"updated": result.Updated,
+	})
+}
+
+// Handler para obtener los productos que puede pedir una tienda
+// --------------------------------------------------------------------
+// El admin indica la tienda en la ruta; la tienda ve los suyos.
+func (h *ProductHandler) GetStoreProducts(c *gin.Context) {
+	userID, role := currentUser(c)
+	products, err := h.productService.GetStoreProducts(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// Handler para fijar o ampliar los productos que puede pedir una tienda (admin)
+// --------------------------------------------------------------------
+// Cuerpo: product_ids y replace=true para sustituir la lista entera
+func (h *ProductHandler) SetStoreProducts(c *gin.Context) {
+	var request struct {
+		ProductIDs []string `json:"product_ids"`
+		Replace    bool     `json:"replace"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.productService.SetStoreProducts(c.Param("id"), request.ProductIDs, request.Replace); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Productos de la tienda guardados correctamente",
+	})
+}
+
+// Handler para quitar un producto de la lista de una tienda (admin)
+// --------------------------------------------------------------------
+func (h *ProductHandler) RemoveStoreProduct(c *gin.Context) {
+	if err := h.productService.RemoveStoreProduct(c.Param("id"), c.Param("product_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo quitar el producto", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Producto quitado de la tienda",
+	})
+}
+
+// Handler para obtener los parametros de reposicion (product_id opcional)
+// --------------------------------------------------------------------
+func (h *ProductHandler) GetReorderParameters(c *gin.Context) {
+	parameters, err := h.productService.GetReorderParameters(c.Query("product_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, parameters)
+}
+
+// Handler para fijar los parametros de reposicion de un producto
+// --------------------------------------------------------------------
+// Sin store_id valen para todas las tiendas.
+func (h *ProductHandler) SaveReorderParameter(c *gin.Context) {
+	var parameter models.ReorderParameter
+	if err := c.ShouldBindJSON(&parameter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.productService.SaveReorderParameter(&parameter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Parametros de reposicion guardados correctamente",
+		"parameter": parameter,
+	})
+}
+
+// Handler para borrar los parametros de reposicion de un producto (store_id opcional)
+// --------------------------------------------------------------------
+func (h *ProductHandler) DeleteReorderParameter(c *gin.Context) {
+	if err := h.productService.DeleteReorderParameter(c.Param("product_id"), c.Query("store_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Parametros de reposicion borrados correctamente",
+	})
+}
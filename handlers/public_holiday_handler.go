@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+// Tamaño maximo de un fichero de festivos importado
+const maxHolidayImportSize = 1 << 20
+
+type PublicHolidayHandler struct {
+	publicHolidayService *services.PublicHolidayService
+}
+
+func NewPublicHolidayHandler(publicHolidayService *services.PublicHolidayService) *PublicHolidayHandler {
+	return &PublicHolidayHandler{publicHolidayService: publicHolidayService}
+}
+
+// Handler para crear un calendario de festivos
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) CreateCalendar(c *gin.Context) {
+	var calendar models.PublicHolidayCalendar
+	if err := c.ShouldBind(&calendar); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.publicHolidayService.CreateCalendar(&calendar); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Calendario creado correctamente",
+		"calendar": calendar,
+	})
+}
+
+// Handler para obtener todos los calendarios de festivos
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) GetCalendars(c *gin.Context) {
+	calendars, err := h.publicHolidayService.GetCalendars()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener los calendarios", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, calendars)
+}
+
+// Handler para obtener un calendario con sus festivos
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) GetCalendar(c *gin.Context) {
+	calendar, holidays, err := h.publicHolidayService.GetCalendar(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"calendar": calendar,
+		"holidays": holidays,
+	})
+}
+
+// Handler para eliminar un calendario de festivos
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) DeleteCalendar(c *gin.Context) {
+	if err := h.publicHolidayService.DeleteCalendar(c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Calendario eliminado correctamente",
+	})
+}
+
+// Handler para añadir un festivo a un calendario
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) AddHoliday(c *gin.Context) {
+	var holiday models.PublicHoliday
+	if err := c.ShouldBind(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.publicHolidayService.AddHoliday(c.Param("id"), &holiday); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Festivo guardado correctamente",
+	})
+}
+
+// Handler para eliminar un festivo de un calendario
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) DeleteHoliday(c *gin.Context) {
+	if err := h.publicHolidayService.DeleteHoliday(c.Param("id"), c.Param("holiday_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo eliminar el festivo", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Festivo eliminado correctamente",
+	})
+}
+
+// Handler para importar los festivos de un calendario desde un fichero .ics o .csv
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) ImportHolidays(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Fichero requerido en el campo file",
+		})
+		return
+	}
+	if file.Size > maxHolidayImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "El fichero no puede superar 1 MB",
+		})
+		return
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No se pudo leer el fichero",
+		})
+		return
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No se pudo leer el fichero",
+		})
+		return
+	}
+
+	imported, err := h.publicHolidayService.ImportHolidays(c.Param("id"), file.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Festivos importados correctamente",
+		"imported": imported,
+	})
+}
+
+// Handler para obtener los calendarios que se aplican a una tienda
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) GetStoreCalendars(c *gin.Context) {
+	calendars, err := h.publicHolidayService.GetStoreCalendars(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, calendars)
+}
+
+// Handler para asignar un calendario de festivos a una tienda
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) AssignCalendar(c *gin.Context) {
+	if err := h.publicHolidayService.AssignCalendar(c.Param("id"), c.Param("calendar_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Calendario asignado correctamente",
+	})
+}
+
+// Handler para quitar un calendario de festivos de una tienda
+// --------------------------------------------------------------------
+func (h *PublicHolidayHandler) UnassignCalendar(c *gin.Context) {
+	if err := h.publicHolidayService.UnassignCalendar(c.Param("id"), c.Param("calendar_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudo quitar el calendario", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Calendario quitado correctamente",
+	})
+}
+
+// Handler para obtener los festivos de una tienda entre dos fechas
+// --------------------------------------------------------------------
+// Parametros: from, to (YYYY-MM-DD) y store_id (solo admin)
+func (h *PublicHolidayHandler) GetStorePublicHolidays(c *gin.Context) {
+	userID, role := currentUser(c)
+	holidays, err := h.publicHolidayService.GetStorePublicHolidays(userID, role,
+		c.Query("store_id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, holidays)
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/utils"
+)
+
+// Handler para consolidar los pedidos aprobados en pedidos a proveedor
+// --------------------------------------------------------------------
+// Cuerpo opcional: delivery_date para usar la misma fecha con todos los proveedores
+func (h *OrderHandler) ConsolidateOrders(c *gin.Context) {
+	var request struct {
+		DeliveryDate string `form:"delivery_date" json:"delivery_date"`
+	}
+	_ = c.ShouldBind(&request) // Sin cuerpo se usa el reparto de cada proveedor
+
+	userID, _ := currentUser(c)
+	result, err := h.orderService.ConsolidateOrders(userID, request.DeliveryDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Handler para obtener los pedidos a proveedor
+// --------------------------------------------------------------------
+// Parametros opcionales: supplier_id, status, from y to (fecha de entrega)
+func (h *OrderHandler) GetPurchaseOrders(c *gin.Context) {
+	purchases, err := h.orderService.GetPurchaseOrders(c.Query("supplier_id"), c.Query("status"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, purchases)
+}
+
+// Handler para obtener un pedido a proveedor con el reparto por tienda
+// --------------------------------------------------------------------
+func (h *OrderHandler) GetPurchaseOrder(c *gin.Context) {
+	detail, err := h.orderService.GetPurchaseOrder(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// Handler para marcar un pedido a proveedor como enviado
+// --------------------------------------------------------------------
+func (h *OrderHandler) SendPurchaseOrder(c *gin.Context) {
+	if err := h.orderService.SendPurchaseOrder(c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pedido a proveedor enviado",
+	})
+}
+
+// Handler para descargar un pedido a proveedor (format=csv o pdf)
+// --------------------------------------------------------------------
+func (h *OrderHandler) ExportPurchaseOrder(c *gin.Context) {
+	detail, err := h.orderService.GetPurchaseOrder(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	switch c.DefaultQuery("format", "pdf") {
+	case "csv":
+		writePurchaseOrderCSV(c, detail)
+	case "pdf":
+		writePurchaseOrderPDF(c, detail)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format debe ser csv o pdf",
+		})
+	}
+}
+
+// money - Importe con dos decimales y coma decimal
+func money(amount float64) string {
+	return strings.Replace(strconv.FormatFloat(amount, 'f', 2, 64), ".", ",", 1)
+}
+
+// writePurchaseOrderCSV - Escribe un pedido a proveedor como CSV, una fila por producto y tienda
+// --------------------------------------------------------------------
+func writePurchaseOrderCSV(c *gin.Context, detail *dtos.PurchaseOrderDetail) {
+	purchase := detail.PurchaseOrder
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", purchase.Number+".csv"))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Comma = ';'
+	writer.Write([]string{
+		"purchase_order", "delivery_date", "sku", "product", "unit",
+		"store", "store_order", "quantity", "unit_price", "amount",
+	})
+	for _, product := range detail.Products {
+		for _, store := range product.Stores {
+			writer.Write([]string{
+				purchase.Number, purchase.DeliveryDate, product.SKU, product.Product, product.Unit,
+				store.StoreName, store.OrderNumber, strconv.Itoa(store.Quantity),
+				money(product.UnitPrice), money(float64(store.Quantity) * product.UnitPrice),
+			})
+		}
+	}
+	writer.Flush()
+}
+
+// writePurchaseOrderPDF - Escribe un pedido a proveedor como PDF
+// --------------------------------------------------------------------
+// Primero el total de cada producto con su reparto por tienda debajo y al
+// final el total de cada tienda.
+func writePurchaseOrderPDF(c *gin.Context, detail *dtos.PurchaseOrderDetail) {
+	purchase, supplier := detail.PurchaseOrder, detail.Supplier
+	pdf := utils.NewPDFDocument()
+	pdf.Heading("Pedido " + purchase.Number)
+	pdf.Paragraph("Proveedor: " + supplier.Name)
+	if supplier.TaxID != "" {
+		pdf.Paragraph("CIF: " + supplier.TaxID)
+	}
+	if supplier.ContactName != "" || supplier.Email != "" || supplier.Phone != "" {
+		pdf.Paragraph(strings.Trim(fmt.Sprintf("Contacto: %s  %s  %s", supplier.ContactName, supplier.Email, supplier.Phone), " "))
+	}
+	pdf.Paragraph("Fecha de entrega: " + purchase.DeliveryDate)
+	pdf.Rule()
+
+	pdf.Row([]utils.PDFColumn{{X: 0, Text: "SKU"}, {X: 80, Text: "Producto"}, {X: 300, Text: "Cantidad"},
+		{X: 370, Text: "Precio"}, {X: 440, Text: "Importe"}}, true)
+	for _, product := range detail.Products {
+		pdf.Row([]utils.PDFColumn{
+			{X: 0, Text: product.SKU},
+			{X: 80, Text: product.Product},
+			{X: 300, Text: fmt.Sprintf("%d %s", product.Quantity, product.Unit)},
+			{X: 370, Text: money(product.UnitPrice)},
+			{X: 440, Text: money(product.Amount)},
+		}, false)
+		for _, store := range product.Stores {
+			pdf.Row([]utils.PDFColumn{
+				{X: 95, Text: fmt.Sprintf("%s (%s)", store.StoreName, store.OrderNumber)},
+				{X: 300, Text: strconv.Itoa(store.Quantity)},
+			}, false)
+		}
+	}
+	pdf.Rule()
+
+	pdf.Row([]utils.PDFColumn{{X: 0, Text: "Tienda"}, {X: 300, Text: "Lineas"}, {X: 440, Text: "Importe"}}, true)
+	for _, store := range detail.Stores {
+		pdf.Row([]utils.PDFColumn{{X: 0, Text: store.StoreName}, {X: 300, Text: strconv.Itoa(store.Lines)}, {X: 440, Text: money(store.Amount)}}, false)
+	}
+	pdf.Rule()
+	pdf.Row([]utils.PDFColumn{{X: 0, Text: "Total sin IVA"}, {X: 440, Text: money(purchase.Total) + " €"}}, true)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", purchase.Number+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", pdf.Bytes())
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// Handler para el informe de asistencia planificada frente a fichajes reales
+// --------------------------------------------------------------------
+// Parametros: from, to (YYYY-MM-DD), store_id, worker_id, grace_minutes y format (json o csv)
+func (h *ReportHandler) AttendanceReport(c *gin.Context) {
+	graceMinutes := services.DefaultGraceMinutes
+	if value := c.Query("grace_minutes"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "grace_minutes debe ser un numero entero",
+			})
+			return
+		}
+		graceMinutes = parsed
+	}
+
+	userID, role := currentUser(c)
+	report, err := h.reportService.AttendanceReport(userID, role,
+		c.Query("from"), c.Query("to"), c.Query("store_id"), c.Query("worker_id"), graceMinutes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeAttendanceCSV(c, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report": report,
+	})
+}
+
+// writeAttendanceCSV - Escribe las filas del informe de asistencia como CSV
+// --------------------------------------------------------------------
+func writeAttendanceCSV(c *gin.Context, report *dtos.AttendanceReport) {
+	filename := fmt.Sprintf("asistencia_%s_%s.csv", report.From, report.To)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{
+		"date", "worker_id", "worker_name", "store_id", "shift_store_id", "shift_id",
+		"scheduled_start", "scheduled_end", "actual_in", "actual_out",
+		"scheduled_minutes", "worked_minutes", "late_minutes",
+		"early_leave_minutes", "overtime_minutes", "flags", "public_holiday",
+	})
+	for _, row := range report.Rows {
+		shiftID := ""
+		if row.ShiftID != nil {
+			shiftID = strconv.Itoa(*row.ShiftID)
+		}
+		writer.Write([]string{
+			row.Date, row.WorkerID, row.WorkerName, row.StoreID, row.ShiftStoreID, shiftID,
+			row.ScheduledStart, row.ScheduledEnd, row.ActualIn, row.ActualOut,
+			strconv.Itoa(row.ScheduledMinutes), strconv.Itoa(row.WorkedMinutes),
+			strconv.Itoa(row.LateMinutes), strconv.Itoa(row.EarlyLeaveMinutes),
+			strconv.Itoa(row.OvertimeMinutes), strings.Join(row.Flags, "|"), row.PublicHoliday,
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,600 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/logger"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+	"go.uber.org/zap"
+)
+
+type ShiftHandler struct {
+	shiftService *services.ShiftService
+}
+
+func NewShiftHandler(shiftService *services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{shiftService: shiftService}
+}
+
+// bindNote - Lee el comentario opcional que acompaña a una decision
+// --------------------------------------------------------------------
+func bindNote(c *gin.Context) string {
+	var request struct {
+		Note string `form:"note" json:"note"`
+	}
+	_ = c.ShouldBind(&request) // El comentario es opcional
+	return request.Note
+}
+
+// Handler para que un trabajador solicite un cambio de turno
+// --------------------------------------------------------------------
+func (h *ShiftHandler) CreateSwap(c *gin.Context) {
+
+	// Parseamos el cuerpo de la solicitud
+	var swap models.ShiftSwap
+	if err := c.ShouldBind(&swap); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	// Llamamos al servicio para crear la solicitud
+	userID, _ := currentUser(c)
+	if err := h.shiftService.CreateSwap(userID, &swap); err != nil {
+		logger.Logger.Error("CreateSwap: Swap creation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Devolvemos una respuesta exitosa
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Solicitud de cambio creada correctamente",
+		"swap":    swap,
+	})
+}
+
+// Handler para obtener las solicitudes del trabajador y las ofertas abiertas
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetWorkerSwaps(c *gin.Context) {
+	userID, _ := currentUser(c)
+	swaps, open, err := h.shiftService.GetWorkerSwaps(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las solicitudes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"swaps":      swaps,
+		"open_swaps": open,
+	})
+}
+
+// Handler para que la contraparte acepte una solicitud
+// --------------------------------------------------------------------
+func (h *ShiftHandler) AcceptSwap(c *gin.Context) {
+	swapID := c.Param("id")
+	if swapID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la solicitud requerido",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.shiftService.AcceptSwap(userID, swapID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Solicitud aceptada, pendiente de aprobacion",
+	})
+}
+
+// Handler para que la contraparte rechace una solicitud
+// --------------------------------------------------------------------
+func (h *ShiftHandler) DeclineSwap(c *gin.Context) {
+	swapID := c.Param("id")
+	if swapID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la solicitud requerido",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.shiftService.DeclineSwap(userID, swapID, bindNote(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Solicitud rechazada",
+	})
+}
+
+// Handler para que el solicitante cancele su solicitud
+// --------------------------------------------------------------------
+func (h *ShiftHandler) CancelSwap(c *gin.Context) {
+	swapID := c.Param("id")
+	if swapID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la solicitud requerido",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.shiftService.CancelSwap(userID, swapID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Solicitud cancelada",
+	})
+}
+
+// Handler para obtener las solicitudes de cambio (encargado o admin)
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetSwaps(c *gin.Context) {
+	userID, role := currentUser(c)
+	swaps, err := h.shiftService.GetSwaps(userID, role, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las solicitudes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"swaps": swaps,
+	})
+}
+
+// Handler para aprobar una solicitud de cambio (encargado o admin)
+// --------------------------------------------------------------------
+func (h *ShiftHandler) ApproveSwap(c *gin.Context) {
+	swapID := c.Param("id")
+	if swapID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la solicitud requerido",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.ApproveSwap(userID, role, swapID, bindNote(c)); err != nil {
+		logger.Logger.Error("ApproveSwap: Swap approval failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cambio de turno aprobado correctamente",
+	})
+}
+
+// Handler para rechazar una solicitud de cambio (encargado o admin)
+// --------------------------------------------------------------------
+func (h *ShiftHandler) RejectSwap(c *gin.Context) {
+	swapID := c.Param("id")
+	if swapID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la solicitud requerido",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.RejectSwap(userID, role, swapID, bindNote(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cambio de turno rechazado",
+	})
+}
+
+// parseShiftID - Lee el ID numerico de un turno de la ruta
+// --------------------------------------------------------------------
+func parseShiftID(c *gin.Context) (int, bool) {
+	shiftID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID del turno requerido",
+		})
+		return 0, false
+	}
+	return shiftID, true
+}
+
+// Handler para crear un periodo de planificacion en borrador
+// --------------------------------------------------------------------
+func (h *ShiftHandler) CreatePeriod(c *gin.Context) {
+	var period models.SchedulePeriod
+	if err := c.ShouldBind(&period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.CreatePeriod(userID, role, &period); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Periodo creado correctamente",
+		"period":  period,
+	})
+}
+
+// Handler para obtener los periodos de planificacion
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetPeriods(c *gin.Context) {
+	userID, role := currentUser(c)
+	periods, err := h.shiftService.GetPeriods(userID, role, c.Query("store_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener los periodos", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"periods": periods,
+	})
+}
+
+// Handler para obtener un periodo con sus turnos
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetPeriod(c *gin.Context) {
+	periodID := c.Param("id")
+	if periodID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID del periodo requerido",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	period, shifts, publicHolidays, err := h.shiftService.GetPeriod(userID, role, periodID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":          period,
+		"shifts":          shifts,
+		"public_holidays": publicHolidays,
+	})
+}
+
+// Handler para obtener los cambios hechos sobre un periodo publicado
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetPeriodChanges(c *gin.Context) {
+	periodID := c.Param("id")
+	if periodID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID del periodo requerido",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	changes, err := h.shiftService.GetPeriodChanges(userID, role, periodID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes": changes,
+	})
+}
+
+// Handler para publicar un periodo de planificacion
+// --------------------------------------------------------------------
+func (h *ShiftHandler) PublishPeriod(c *gin.Context) {
+	periodID := c.Param("id")
+	if periodID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID del periodo requerido",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.PublishPeriod(userID, role, periodID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Horario publicado correctamente",
+	})
+}
+
+// Handler para crear un turno
+// --------------------------------------------------------------------
+func (h *ShiftHandler) CreateShift(c *gin.Context) {
+	var shift models.WorkShift
+	if err := c.ShouldBind(&shift); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.CreateShift(userID, role, &shift); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Turno creado correctamente",
+		"shift":   shift,
+	})
+}
+
+// Handler para actualizar un turno
+// --------------------------------------------------------------------
+func (h *ShiftHandler) UpdateShift(c *gin.Context) {
+	shiftID, ok := parseShiftID(c)
+	if !ok {
+		return
+	}
+
+	var shift models.WorkShift
+	if err := c.ShouldBind(&shift); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.UpdateShift(userID, role, shiftID, &shift); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Turno actualizado correctamente",
+	})
+}
+
+// Handler para eliminar un turno
+// --------------------------------------------------------------------
+func (h *ShiftHandler) DeleteShift(c *gin.Context) {
+	shiftID, ok := parseShiftID(c)
+	if !ok {
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.DeleteShift(userID, role, shiftID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Turno eliminado correctamente",
+	})
+}
+
+// Handler para obtener los turnos publicados del trabajador
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetWorkerShifts(c *gin.Context) {
+	userID, _ := currentUser(c)
+	shifts, err := h.shiftService.GetWorkerShifts(userID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shifts": shifts,
+	})
+}
+
+// Handler para obtener los cambios de horario que afectan al trabajador
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetWorkerChanges(c *gin.Context) {
+	userID, _ := currentUser(c)
+	changes, err := h.shiftService.GetWorkerChanges(userID, c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener los cambios", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes": changes,
+	})
+}
+
+// Handler para obtener los turnos abiertos que puede reclamar el trabajador
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetClaimableShifts(c *gin.Context) {
+	userID, _ := currentUser(c)
+	shifts, err := h.shiftService.GetClaimableShifts(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener los turnos abiertos", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shifts": shifts,
+	})
+}
+
+// Handler para que un trabajador reclame un turno abierto
+// --------------------------------------------------------------------
+func (h *ShiftHandler) ClaimShift(c *gin.Context) {
+	shiftID, ok := parseShiftID(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := currentUser(c)
+	claim, err := h.shiftService.ClaimShift(userID, shiftID)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	message := "Reclamacion enviada, pendiente de aprobacion"
+	if claim.Status == models.ClaimStatusApproved {
+		message = "Turno asignado correctamente"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"claim":   claim,
+	})
+}
+
+// Handler para que un trabajador retire su reclamacion
+// --------------------------------------------------------------------
+func (h *ShiftHandler) WithdrawClaim(c *gin.Context) {
+	claimID := c.Param("id")
+	if claimID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la reclamacion requerido",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.shiftService.WithdrawClaim(userID, claimID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reclamacion retirada",
+	})
+}
+
+// Handler para obtener las reclamaciones del trabajador
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetWorkerClaims(c *gin.Context) {
+	userID, _ := currentUser(c)
+	claims, err := h.shiftService.GetWorkerClaims(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las reclamaciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"claims": claims,
+	})
+}
+
+// Handler para obtener las reclamaciones de turnos abiertos (encargado o admin)
+// --------------------------------------------------------------------
+func (h *ShiftHandler) GetClaims(c *gin.Context) {
+	userID, role := currentUser(c)
+	claims, err := h.shiftService.GetClaims(userID, role, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "No se pudieron obtener las reclamaciones", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"claims": claims,
+	})
+}
+
+// Handler para aprobar una reclamacion de turno abierto (encargado o admin)
+// --------------------------------------------------------------------
+func (h *ShiftHandler) ApproveClaim(c *gin.Context) {
+	claimID := c.Param("id")
+	if claimID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la reclamacion requerido",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.ApproveClaim(userID, role, claimID, bindNote(c)); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Turno asignado correctamente",
+	})
+}
+
+// Handler para rechazar una reclamacion de turno abierto (encargado o admin)
+// --------------------------------------------------------------------
+func (h *ShiftHandler) RejectClaim(c *gin.Context) {
+	claimID := c.Param("id")
+	if claimID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de la reclamacion requerido",
+		})
+		return
+	}
+
+	userID, role := currentUser(c)
+	if err := h.shiftService.RejectClaim(userID, role, claimID, bindNote(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reclamacion rechazada",
+	})
+}
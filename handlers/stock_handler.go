@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type StockHandler struct {
+	stockService *services.StockService
+}
+
+func NewStockHandler(stockService *services.StockService) *StockHandler {
+	return &StockHandler{stockService: stockService}
+}
+
+// Handler para obtener el stock actual de una tienda
+// --------------------------------------------------------------------
+// El admin indica la tienda en la ruta; la tienda ve la suya.
+func (h *StockHandler) GetStock(c *gin.Context) {
+	userID, role := currentUser(c)
+	levels, err := h.stockService.GetStock(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, levels)
+}
+
+// Handler para obtener el historial de stock de una tienda
+// --------------------------------------------------------------------
+// Parametros: from, to y product_id (opcional)
+func (h *StockHandler) GetStockHistory(c *gin.Context) {
+	userID, role := currentUser(c)
+	movements, err := h.stockService.GetStockHistory(userID, role, c.Param("id"), c.Query("product_id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+}
+
+// Handler para que una tienda registre un recuento de stock
+// --------------------------------------------------------------------
+func (h *StockHandler) SubmitCount(c *gin.Context) {
+	var count models.StockCount
+	if err := c.ShouldBindJSON(&count); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.stockService.SubmitCount(userID, &count); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recuento registrado correctamente",
+		"count":   count,
+	})
+}
+
+// Handler para obtener los recuentos de una tienda
+// --------------------------------------------------------------------
+// Parametros: from, to y store_id (solo admin)
+func (h *StockHandler) GetCounts(c *gin.Context) {
+	userID, role := currentUser(c)
+	counts, err := h.stockService.GetCounts(userID, role, c.Query("store_id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// Handler para obtener un recuento con sus lineas
+// --------------------------------------------------------------------
+func (h *StockHandler) GetCount(c *gin.Context) {
+	userID, role := currentUser(c)
+	count, err := h.stockService.GetCount(userID, role, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// Handler para que una tienda registre mermas
+// --------------------------------------------------------------------
+// Cuerpo: lista de {product_id, quantity, reason}
+func (h *StockHandler) RecordWaste(c *gin.Context) {
+	var entries []dtos.StockWaste
+	if err := c.ShouldBindJSON(&entries); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if err := h.stockService.RecordWaste(userID, entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Merma registrada correctamente",
+	})
+}
+
+// Handler para el informe de diferencias de recuento
+// --------------------------------------------------------------------
+// Parametros: from, to y store_id (solo admin, opcional)
+func (h *StockHandler) GetVarianceReport(c *gin.Context) {
+	userID, role := currentUser(c)
+	report, err := h.stockService.GetVarianceReport(userID, role, c.Query("store_id"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/services"
+)
+
+type SupplierHandler struct {
+	supplierService *services.SupplierService
+}
+
+func NewSupplierHandler(supplierService *services.SupplierService) *SupplierHandler {
+	return &SupplierHandler{supplierService: supplierService}
+}
+
+// Handler para crear un proveedor
+// --------------------------------------------------------------------
+func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
+	var supplier models.Supplier
+	if err := c.ShouldBindJSON(&supplier); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.supplierService.CreateSupplier(&supplier); err != nil {
+		if invalidField(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Proveedor creado correctamente",
+		"supplier": supplier,
+	})
+}
+
+// Handler para actualizar un proveedor
+// --------------------------------------------------------------------
+func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
+	var supplier models.Supplier
+	if err := c.ShouldBindJSON(&supplier); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.supplierService.UpdateSupplier(c.Param("id"), &supplier); err != nil {
+		if invalidField(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Proveedor actualizado correctamente",
+		"supplier": supplier,
+	})
+}
+
+// Handler para obtener los proveedores (active=true para solo los activos)
+// --------------------------------------------------------------------
+func (h *SupplierHandler) GetSuppliers(c *gin.Context) {
+	suppliers, err := h.supplierService.GetSuppliers(c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, suppliers)
+}
+
+// Handler para obtener un proveedor con sus productos
+// --------------------------------------------------------------------
+func (h *SupplierHandler) GetSupplier(c *gin.Context) {
+	supplier, products, err := h.supplierService.GetSupplier(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"supplier": supplier,
+		"products": products,
+	})
+}
+
+// Handler para asignar productos a un proveedor
+// --------------------------------------------------------------------
+func (h *SupplierHandler) AssignProducts(c *gin.Context) {
+	var request struct {
+		ProductIDs []string `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+
+	if err := h.supplierService.AssignProducts(c.Param("id"), request.ProductIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Productos asignados al proveedor",
+	})
+}
+
+// Handler para quitar un producto de un proveedor
+// --------------------------------------------------------------------
+func (h *SupplierHandler) UnassignProduct(c *gin.Context) {
+	if err := h.supplierService.UnassignProduct(c.Param("id"), c.Param("product_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Producto quitado del proveedor",
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/config"
+)
+
+// Extensiones admitidas para justificantes y fotos
+var documentExtensions = []string{".pdf", ".jpg", ".jpeg", ".png"}
+
+// saveUpload - Guarda el fichero de un campo del formulario en el directorio de subidas
+// --------------------------------------------------------------------
+// El fichero se renombra con un UUID para que no se pueda adivinar ni pisar
+// a otro. Devuelve la ruta donde se ha guardado.
+func saveUpload(c *gin.Context, field, subdir string, extensions []string, maxSize int64) (string, error) {
+	file, err := c.FormFile(field)
+	if err != nil {
+		return "", fmt.Errorf("fichero requerido en el campo %s", field)
+	}
+	if file.Size > maxSize {
+		return "", fmt.Errorf("el fichero no puede superar %d MB", maxSize>>20)
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	allowed := false
+	for _, extension := range extensions {
+		allowed = allowed || ext == extension
+	}
+	if !allowed {
+		return "", fmt.Errorf("solo se admiten ficheros %s", strings.Join(extensions, ", "))
+	}
+
+	root := config.Env.UploadDir
+	if root == "" {
+		root = "uploads"
+	}
+	dir := filepath.Join(root, subdir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", errors.New("no se pudo guardar el fichero")
+	}
+	path := filepath.Join(dir, uuid.New().String()+ext)
+	if err := c.SaveUploadedFile(file, path); err != nil {
+		return "", errors.New("no se pudo guardar el fichero")
+	}
+	return path, nil
+}
+
+// removeUpload - Borra un fichero subido, si existe
+// --------------------------------------------------------------------
+func removeUpload(path string) {
+	if path != "" {
+		_ = os.Remove(path) // Si ya no existe no hay nada que limpiar
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Suscripcion a un calendario iCalendar, de un trabajador o de una tienda
+type CalendarFeed struct {
+	ID        string     `json:"id" gorm:"primaryKey;uniqueIndex"`
+	Token     string     `json:"token" gorm:"size:64;not null;uniqueIndex"` // Secreto que forma parte de la URL
+	WorkerID  *string    `json:"worker_id" gorm:"size:50;index"`
+	StoreID   *string    `json:"store_id" gorm:"size:50;index"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// Evento eliminado de un calendario, se publica como cancelado con el mismo UID
+type CalendarCancellation struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UID         string    `json:"uid" gorm:"size:100;not null;index"`
+	WorkerID    *string   `json:"worker_id" gorm:"size:50;index"`
+	StoreID     *string   `json:"store_id" gorm:"size:50;index"`
+	Summary     string    `json:"summary" gorm:"size:250"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	AllDay      bool      `json:"all_day"`
+	CancelledAt time.Time `json:"cancelled_at" gorm:"autoCreateTime"`
+}
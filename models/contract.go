@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Modalidades de contrato
+const (
+	ContractTypeIndefinite   = "indefinido"
+	ContractTypeTemporary    = "temporal"
+	ContractTypeDiscontinued = "fijo-discontinuo"
+)
+
+// Resultados del periodo de prueba
+const (
+	TrialOutcomePassed = "superado"
+	TrialOutcomeFailed = "no superado"
+)
+
+// Contrato de un trabajador; las renovaciones son contratos nuevos y se guarda el historial completo
+type Contract struct {
+	ID          string  `json:"id" gorm:"primaryKey"`
+	WorkerID    string  `json:"worker_id" gorm:"size:50;not null;index"`
+	Type        string  `json:"type" gorm:"size:25;not null"`
+	StartDate   string  `json:"start_date" gorm:"type:date;not null"`
+	EndDate     *string `json:"end_date" gorm:"type:date"` // Sin fecha de fin sigue en vigor
+	WeeklyHours float64 `json:"weekly_hours" gorm:"not null"`
+	SalaryBand  string  `json:"salary_band" gorm:"size:50"`
+	Category    string  `json:"category" gorm:"size:100"` // Categoria profesional del convenio
+	Notes       string  `json:"notes" gorm:"size:500"`
+
+	// Periodo de prueba; la fecha de fin se calcula al guardar el contrato
+	TrialStartDate   *string    `json:"trial_start_date" gorm:"type:date"` // Por defecto el inicio del contrato
+	TrialDays        *int       `json:"trial_days"`                        // Dias naturales; sin valor se toman de las reglas
+	TrialEndDate     *string    `json:"trial_end_date" gorm:"type:date;index"`
+	TrialOutcome     string     `json:"trial_outcome" gorm:"size:25"`
+	TrialOutcomeDate *string    `json:"trial_outcome_date" gorm:"type:date"`
+	TrialNotes       string     `json:"trial_notes" gorm:"size:500"`
+	TrialAlertedAt   *time.Time `json:"-"` // Aviso de fin de prueba ya enviado
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Worker    Worker    `json:"-" gorm:"foreignKey:WorkerID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Covers - Indica si el contrato esta en vigor en una fecha YYYY-MM-DD
+func (c *Contract) Covers(date string) bool {
+	return c.StartDate[:10] <= date && (c.EndDate == nil || (*c.EndDate)[:10] >= date)
+}
+
+// InTrial - Indica si el trabajador esta a prueba en una fecha YYYY-MM-DD
+func (c *Contract) InTrial(date string) bool {
+	return c.TrialEndDate != nil && c.TrialOutcome == "" &&
+		(*c.TrialStartDate)[:10] <= date && (*c.TrialEndDate)[:10] >= date
+}
+
+// Duracion del periodo de prueba segun la modalidad y la categoria del contrato
+// ------------------------------------------------------------------
+// Los campos vacios valen para cualquier valor; se aplica la regla mas concreta.
+type TrialPeriodRule struct {
+	ID           int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ContractType string    `json:"contract_type" gorm:"size:25;not null;default:'';uniqueIndex:idx_trial_rule"`
+	Category     string    `json:"category" gorm:"size:100;not null;default:'';uniqueIndex:idx_trial_rule"`
+	Days         int       `json:"days" gorm:"not null"` // Dias naturales; 0 = sin periodo de prueba
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DefaultTrialPeriodRules - Reglas que se crean al arrancar si no existen (art. 14 ET)
+func DefaultTrialPeriodRules() []TrialPeriodRule {
+	return []TrialPeriodRule{
+		{Days: 60},
+		{ContractType: ContractTypeTemporary, Days: 30},
+	}
+}
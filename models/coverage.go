@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Limite de trabajadores ausentes a la vez en una tienda, opcionalmente por cargo
+type StoreLeaveRule struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID    string    `json:"store_id" gorm:"size:50;not null;index"`
+	Cargo      string    `json:"cargo" gorm:"size:50"` // Vacio = toda la plantilla
+	MaxAbsent  *int      `json:"max_absent"`           // Maximo de ausentes a la vez
+	MaxPercent *float64  `json:"max_percent"`          // Maximo de ausentes sobre la plantilla, en %
+	CreatedAt  time.Time `json:"created_at"`
+	Store      Store     `json:"-" gorm:"foreignKey:StoreID;references:ID"`
+}
+
+// Periodo en el que una tienda no concede vacaciones (por ejemplo, Navidad)
+type StoreBlackout struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID   string    `json:"store_id" gorm:"size:50;not null;index"`
+	StartDate string    `json:"start_date" gorm:"type:date;not null"` // Formato YYYY-MM-DD
+	EndDate   string    `json:"end_date" gorm:"type:date;not null"`
+	Reason    string    `json:"reason" gorm:"size:250"`
+	CreatedAt time.Time `json:"created_at"`
+	Store     Store     `json:"-" gorm:"foreignKey:StoreID;references:ID"`
+}
@@ -0,0 +1,63 @@
+package dtos
+
+// Incidencias que puede tener una fila del informe de asistencia
+const (
+	AttendanceLate            = "retraso"
+	AttendanceEarlyDeparture  = "salida_anticipada"
+	AttendanceNoShow          = "ausencia"
+	AttendanceUnscheduled     = "no_planificado"
+	AttendanceOvertime        = "horas_extra"
+	AttendanceMissingClockOut = "sin_salida"
+	AttendancePublicHoliday   = "festivo"
+)
+
+type AttendanceRow struct {
+	WorkerID          string   `json:"worker_id"`
+	WorkerName        string   `json:"worker_name"`
+	StoreID           string   `json:"store_id"`       // Tienda en la que se trabajo
+	ShiftStoreID      string   `json:"shift_store_id"` // Tienda del turno si se ficho en otra
+	Date              string   `json:"date"`
+	ShiftID           *int     `json:"shift_id"`        // Vacio en el trabajo no planificado
+	ScheduledStart    string   `json:"scheduled_start"` // YYYY-MM-DD HH:MM
+	ScheduledEnd      string   `json:"scheduled_end"`
+	ActualIn          string   `json:"actual_in"`
+	ActualOut         string   `json:"actual_out"`
+	ScheduledMinutes  int      `json:"scheduled_minutes"`
+	WorkedMinutes     int      `json:"worked_minutes"`
+	LateMinutes       int      `json:"late_minutes"`
+	EarlyLeaveMinutes int      `json:"early_leave_minutes"`
+	OvertimeMinutes   int      `json:"overtime_minutes"`
+	PublicHoliday     string   `json:"public_holiday,omitempty"` // Nombre del festivo si el dia lo es en la tienda
+	Flags             []string `json:"flags"`
+}
+
+type AttendanceSummary struct {
+	WorkerID          string `json:"worker_id,omitempty"`
+	WorkerName        string `json:"worker_name,omitempty"`
+	StoreID           string `json:"store_id,omitempty"`
+	StoreName         string `json:"store_name,omitempty"`
+	Shifts            int    `json:"shifts"`
+	OnTime            int    `json:"on_time"`
+	Late              int    `json:"late"`
+	EarlyDepartures   int    `json:"early_departures"`
+	NoShows           int    `json:"no_shows"`
+	Unscheduled       int    `json:"unscheduled"`
+	Overtime          int    `json:"overtime"`
+	MissingClockOuts  int    `json:"missing_clock_outs"`
+	ScheduledMinutes  int    `json:"scheduled_minutes"`
+	WorkedMinutes     int    `json:"worked_minutes"`
+	HolidayMinutes    int    `json:"holiday_minutes"`    // Trabajados en festivo
+	ContractedMinutes int    `json:"contracted_minutes"` // Parte proporcional de las horas semanales de contrato
+}
+
+type AttendanceReport struct {
+	From         string              `json:"from"`
+	To           string              `json:"to"`
+	StoreID      string              `json:"store_id,omitempty"`
+	WorkerID     string              `json:"worker_id,omitempty"`
+	GraceMinutes int                 `json:"grace_minutes"`
+	Summary      AttendanceSummary   `json:"summary"`
+	ByWorker     []AttendanceSummary `json:"by_worker"`
+	ByStore      []AttendanceSummary `json:"by_store"` // Segun la tienda en la que se trabajo
+	Rows         []AttendanceRow     `json:"rows"`
+}
@@ -0,0 +1,22 @@
+package dtos
+
+// Periodo de prueba pendiente de decision
+type TrialPeriodAlert struct {
+	ContractID     string `json:"contract_id"`
+	WorkerID       string `json:"worker_id"`
+	WorkerName     string `json:"worker_name"`
+	WorkerLastName string `json:"worker_last_name"`
+	StoreID        string `json:"store_id"`
+	ContractType   string `json:"contract_type"`
+	Category       string `json:"category"`
+	TrialStartDate string `json:"trial_start_date"`
+	TrialEndDate   string `json:"trial_end_date"`
+	DaysLeft       int    `json:"days_left" gorm:"-"`
+}
+
+// Resultado del periodo de prueba que registra un encargado o el admin
+type TrialOutcomeRequest struct {
+	Outcome string `json:"outcome" binding:"required"` // superado o no superado
+	Date    string `json:"date"`                       // Por defecto hoy
+	Notes   string `json:"notes"`
+}
@@ -0,0 +1,44 @@
+package dtos
+
+// Tipos de conflicto de cobertura
+const (
+	CoverageBlackout   = "bloqueo"
+	CoverageMaxAbsent  = "maximo_ausentes"
+	CoverageMaxPercent = "porcentaje_ausentes"
+)
+
+type CoverageConflict struct {
+	Type    string  `json:"type"`
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	Cargo   string  `json:"cargo,omitempty"`
+	Limit   float64 `json:"limit,omitempty"`
+	Absent  int     `json:"absent,omitempty"` // Ausentes contando la nueva solicitud (maximo del tramo)
+	Staff   int     `json:"staff,omitempty"`
+	Message string  `json:"message"`
+}
+
+type LeaveTimelineEntry struct {
+	HolidayID  int    `json:"holiday_id"`
+	WorkerID   string `json:"worker_id"`
+	WorkerName string `json:"worker_name"`
+	Cargo      string `json:"cargo"`
+	LeaveType  string `json:"leave_type"`
+	Status     string `json:"status"`
+}
+
+type LeaveTimelineDay struct {
+	Date     string               `json:"date"`
+	Absent   []LeaveTimelineEntry `json:"absent"`  // Ausencias aprobadas o disfrutadas
+	Pending  []LeaveTimelineEntry `json:"pending"` // Solicitadas o preaprobadas
+	Blackout string               `json:"blackout,omitempty"`
+}
+
+type LeaveTimeline struct {
+	StoreID   string             `json:"store_id"`
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Staff     int                `json:"staff"`
+	Days      []LeaveTimelineDay `json:"days"`
+	Conflicts []CoverageConflict `json:"conflicts"` // Solo al consultar una solicitud concreta
+}
@@ -2,9 +2,27 @@ package dtos
 
 type HolidayWithWorkerName struct {
 	ID             int    `json:"id"`
+	WorkerID       string `json:"worker_id"`
 	WorkerName     string `json:"worker_name"`
 	WorkerLastName string `json:"worker_last_name"`
 	StartDate      string `json:"start_date"`
 	EndDate        string `json:"end_date"`
 	Status         string `json:"status"`
+	LeaveType      string `json:"leave_type"`
+}
+
+type HolidayBalance struct {
+	WorkerID         string  `json:"worker_id"`
+	Year             int     `json:"year"`
+	DayType          string  `json:"day_type"`
+	AnnualDays       float64 `json:"annual_days"`        // Dias de un año completo
+	HireDate         string  `json:"hire_date"`          // Inicio del contrato, si se conoce
+	Entitled         float64 `json:"entitled"`           // Dias del año en proporcion al tiempo contratado
+	Accrued          float64 `json:"accrued"`            // Dias devengados hasta hoy
+	CarriedOver      float64 `json:"carried_over"`       // Dias arrastrados del año anterior
+	CarryOverExpired float64 `json:"carry_over_expired"` // Dias arrastrados que caducaron sin disfrutar
+	Used             float64 `json:"used"`               // Aprobadas o disfrutadas
+	Pending          float64 `json:"pending"`            // Solicitadas o preaprobadas
+	Remaining        float64 `json:"remaining"`          // Derecho + arrastre - usadas
+	Available        float64 `json:"available"`          // Lo que aun se puede solicitar
 }
@@ -0,0 +1,24 @@
+package dtos
+
+// Error de una fila de un fichero importado
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+	Field string `json:"field,omitempty"` // Campo y codigo si el error es de un dato de identidad o contacto
+	Code  string `json:"code,omitempty"`
+}
+
+// Fila que se crea (o se crearia en una prueba) al importar trabajadores o tiendas
+type ImportPreview struct {
+	Line     int    `json:"line"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+type ImportResult struct {
+	DryRun  bool            `json:"dry_run,omitempty"`
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Errors  []ImportError   `json:"errors"`
+	Preview []ImportPreview `json:"preview,omitempty"`
+}
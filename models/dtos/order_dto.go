@@ -0,0 +1,134 @@
+package dtos
+
+import "github.com/javimartzs/worker-hub-backend/models"
+
+// Linea con incidencias en la recepcion de un pedido
+type DiscrepancyLine struct {
+	ReceiptID          string `json:"receipt_id"`
+	Date               string `json:"date"`
+	OrderID            string `json:"order_id"`
+	OrderNumber        string `json:"order_number"`
+	StoreID            string `json:"store_id"`
+	StoreName          string `json:"store_name"`
+	SupplierID         string `json:"supplier_id"`
+	Supplier           string `json:"supplier"`
+	SKU                string `json:"sku"`
+	Product            string `json:"product"`
+	Unit               string `json:"unit"`
+	Ordered            int    `json:"ordered"`
+	Received           int    `json:"received"`
+	Damaged            int    `json:"damaged"`
+	Short              int    `json:"short"`
+	SubstituteProduct  string `json:"substitute_product,omitempty"`
+	SubstituteQuantity int    `json:"substitute_quantity"`
+	Comment            string `json:"comment"`
+	Photo              bool   `json:"photo"` // La entrega tiene foto adjunta
+}
+
+// Totales de incidencias de un proveedor o de una tienda
+type DiscrepancySummary struct {
+	SupplierID       string `json:"supplier_id,omitempty"`
+	Supplier         string `json:"supplier,omitempty"`
+	StoreID          string `json:"store_id,omitempty"`
+	StoreName        string `json:"store_name,omitempty"`
+	Orders           int    `json:"orders"` // Pedidos con alguna incidencia
+	Lines            int    `json:"lines"`
+	ShortUnits       int    `json:"short_units"`
+	DamagedUnits     int    `json:"damaged_units"`
+	SubstitutedUnits int    `json:"substituted_units"`
+}
+
+type DiscrepancyReport struct {
+	From       string               `json:"from"`
+	To         string               `json:"to"`
+	BySupplier []DiscrepancySummary `json:"by_supplier"`
+	ByStore    []DiscrepancySummary `json:"by_store"`
+	Lines      []DiscrepancyLine    `json:"lines"`
+}
+
+// Origen del consumo medio de una sugerencia de pedido
+const (
+	ConsumptionFromCounts = "recuentos" // Diferencia entre recuentos mas lo recibido entre ellos
+	ConsumptionFromOrders = "pedidos"   // Unidades pedidas en el periodo
+	ConsumptionNoData     = "sin_datos"
+)
+
+// Cantidad sugerida de un producto con los datos que la explican
+type ReorderSuggestion struct {
+	ProductID         string  `json:"product_id"`
+	SKU               string  `json:"sku"`
+	Product           string  `json:"product"`
+	Unit              string  `json:"unit"`
+	PackSize          int     `json:"pack_size"`
+	Stock             int     `json:"stock"`              // Stock actual de la tienda
+	Incoming          int     `json:"incoming"`           // Pendiente de llegar de pedidos en curso
+	DailyConsumption  float64 `json:"daily_consumption"`  // Unidades al dia
+	ConsumptionSource string  `json:"consumption_source"` // recuentos, pedidos o sin_datos
+	ConsumptionFrom   string  `json:"consumption_from"`   // Periodo usado para el consumo
+	ConsumptionTo     string  `json:"consumption_to"`
+	LeadTimeDays      int     `json:"lead_time_days"`
+	ReviewDays        int     `json:"review_days"`
+	MinLevel          int     `json:"min_level"`
+	MaxLevel          int     `json:"max_level"`
+	ProjectedStock    float64 `json:"projected_stock"` // Stock previsto cuando llegue el pedido
+	Quantity          int     `json:"quantity"`        // Cantidad sugerida, en multiplos del bulto
+	Explanation       string  `json:"explanation"`
+}
+
+type ReorderSuggestions struct {
+	StoreID     string              `json:"store_id"`
+	StoreName   string              `json:"store_name"`
+	GeneratedAt string              `json:"generated_at"`
+	Lines       []ReorderSuggestion `json:"lines"`
+}
+
+// Pedido aprobado que no se ha podido pasar a un pedido a proveedor
+type SkippedOrder struct {
+	OrderID   string `json:"order_id"`
+	Number    string `json:"number"`
+	StoreName string `json:"store_name"`
+	Reason    string `json:"reason"`
+}
+
+type ConsolidationResult struct {
+	PurchaseOrders []models.PurchaseOrder `json:"purchase_orders"`
+	Orders         int                    `json:"orders"` // Pedidos de tienda consolidados
+	Skipped        []SkippedOrder         `json:"skipped"`
+	Warnings       []string               `json:"warnings"`
+}
+
+// Cantidad de un producto que pide una tienda
+type PurchaseStoreQuantity struct {
+	StoreID     string `json:"store_id"`
+	StoreName   string `json:"store_name"`
+	OrderNumber string `json:"order_number"`
+	Quantity    int    `json:"quantity"`
+}
+
+// Producto de un pedido a proveedor con el reparto por tienda
+type PurchaseProduct struct {
+	ProductID string                  `json:"product_id"`
+	SKU       string                  `json:"sku"`
+	Product   string                  `json:"product"`
+	Unit      string                  `json:"unit"`
+	Quantity  int                     `json:"quantity"`
+	UnitPrice float64                 `json:"unit_price"`
+	Amount    float64                 `json:"amount"`
+	Stores    []PurchaseStoreQuantity `json:"stores"`
+}
+
+// Total de una tienda en un pedido a proveedor
+type PurchaseStoreTotal struct {
+	StoreID   string  `json:"store_id"`
+	StoreName string  `json:"store_name"`
+	Lines     int     `json:"lines"`
+	Amount    float64 `json:"amount"`
+}
+
+type PurchaseOrderDetail struct {
+	PurchaseOrder *models.PurchaseOrder `json:"purchase_order"`
+	Supplier      *models.Supplier      `json:"supplier"`
+	Products      []PurchaseProduct     `json:"products"`
+	Stores        []PurchaseStoreTotal  `json:"stores"`
+	BelowMinimum  bool                  `json:"below_minimum"` // No llega al importe minimo del proveedor
+}
@@ -0,0 +1,18 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+)
+
+// Cambio de horario visto desde el trabajador afectado
+type WorkerScheduleChange struct {
+	ID          int                   `json:"id"`
+	ShiftID     int                   `json:"shift_id"`
+	ChangeType  string                `json:"change_type"`
+	Before      *models.ShiftSnapshot `json:"before"`
+	After       *models.ShiftSnapshot `json:"after"`
+	Description string                `json:"description"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
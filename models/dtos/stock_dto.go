@@ -0,0 +1,39 @@
+package dtos
+
+import "time"
+
+// Merma de un producto que registra una tienda
+type StockWaste struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+// Diferencias de recuento de un producto en una tienda
+type StockVarianceRow struct {
+	StoreID          string `json:"store_id"`
+	StoreName        string `json:"store_name"`
+	ProductID        string `json:"product_id"`
+	SKU              string `json:"sku"`
+	Product          string `json:"product"`
+	Unit             string `json:"unit"`
+	Counts           int    `json:"counts"`            // Recuentos en los que aparece
+	Expected         int    `json:"expected"`          // Suma del stock esperado
+	Counted          int    `json:"counted"`           // Suma de lo contado
+	Variance         int    `json:"variance"`          // Contado - esperado
+	AbsoluteVariance int    `json:"absolute_variance"` // Suma de las diferencias sin signo
+	Waste            int    `json:"waste"`             // Merma registrada en el periodo
+}
+
+type StockVarianceReport struct {
+	From string             `json:"from"`
+	To   string             `json:"to"`
+	Rows []StockVarianceRow `json:"rows"`
+}
+
+// Cantidad contada de un producto en un recuento
+type StockCountPoint struct {
+	ProductID string
+	Counted   int
+	CountedAt time.Time
+}
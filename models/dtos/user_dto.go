@@ -1,6 +1,7 @@
 package dtos
 
 type UserList struct {
+	ID       string `json:"id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
 }
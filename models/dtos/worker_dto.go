@@ -0,0 +1,24 @@
+package dtos
+
+import "github.com/javimartzs/worker-hub-backend/models"
+
+// Filtros, orden y pagina del listado de trabajadores (vacios = todos)
+type WorkerQuery struct {
+	Search  string `form:"search"` // Nombre, apellidos, email o NIE
+	StoreID string `form:"store_id"`
+	Cargo   string `form:"cargo"`
+	Status  string `form:"status"` // Alta o Baja
+	Prueba  string `form:"prueba"` // Si o No
+	Sort    string `form:"sort"`   // name, last_name, email, nie, cargo, status, hire_date o store
+	Order   string `form:"order"`  // asc o desc
+	Limit   int    `form:"limit"`
+	Offset  int    `form:"offset"`
+}
+
+// Pagina del listado de trabajadores con el total que cumple los filtros
+type WorkerPage struct {
+	Workers []models.Worker `json:"workers"`
+	Total   int64           `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+}
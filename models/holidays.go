@@ -1,10 +1,71 @@
 package models
 
+import "time"
+
+// Estados de una vacacion
+const (
+	HolidayStatusRequested   = "Solicitadas"  // Pedidas por el trabajador, pendientes del encargado
+	HolidayStatusPreApproved = "Preaprobadas" // Aprobadas por el encargado, pendientes del admin
+	HolidayStatusApproved    = "Aprobadas"    // Aprobadas por el admin, aun no disfrutadas
+	HolidayStatusRejected    = "Rechazadas"
+	HolidayStatusTaken       = "Disfrutadas"
+	HolidayStatusCancelled   = "Canceladas"
+
+	// Estado anterior al flujo de aprobacion; se sigue aceptando como Aprobadas
+	HolidayStatusLegacyPending = "Pendientes"
+)
+
 type Holiday struct {
-	ID        int    `json:"id" gorm:"primaryKey;autoIncrement"`
-	WorkerID  string `json:"worker_id" gorm:"not null"`
-	StartDate string `json:"start_date" gorm:"type:date;not null"` // Formato YYYY-MM-DD
-	EndDate   string `json:"end_date" gorm:"type:date;not null"`
-	Status    string `json:"status" gorm:"size:50"`
-	Worker    Worker `json:"-" gorm:"foreignKey:WorkerID;references:ID"`
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	WorkerID  string    `json:"worker_id" gorm:"not null"`
+	StartDate string    `json:"start_date" gorm:"type:date;not null"` // Formato YYYY-MM-DD
+	EndDate   string    `json:"end_date" gorm:"type:date;not null"`
+	Status    string    `json:"status" gorm:"size:50"`
+	Comment   string    `json:"comment" gorm:"size:250"`                                     // Comentario del trabajador al solicitarlas
+	LeaveType string    `json:"leave_type" gorm:"size:50;not null;default:vacaciones;index"` // Ver models.LeaveType
+	Document  string    `json:"document" gorm:"size:250"`                                    // Justificante adjunto
+	CreatedAt time.Time `json:"created_at"`
+	Worker    Worker    `json:"-" gorm:"foreignKey:WorkerID;references:ID"`
+}
+
+// Niveles de decision sobre una vacacion
+const (
+	DecisionLevelStore  = "tienda"
+	DecisionLevelAdmin  = "admin"
+	DecisionLevelWorker = "trabajador"
+	DecisionLevelSystem = "sistema" // Cambios de las tareas programadas
+)
+
+// Decision tomada sobre una vacacion (aprobacion, rechazo o cancelacion)
+type HolidayDecision struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	HolidayID   int       `json:"holiday_id" gorm:"not null;index"`
+	Level       string    `json:"level" gorm:"size:25;not null"`
+	FromStatus  string    `json:"from_status" gorm:"size:50;not null"`
+	ToStatus    string    `json:"to_status" gorm:"size:50;not null"`
+	Comment     string    `json:"comment" gorm:"size:250"`
+	Override    bool      `json:"override"` // El admin aprobo por encima del saldo disponible
+	DecidedByID string    `json:"decided_by_id" gorm:"size:50;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	Holiday     Holiday   `json:"-" gorm:"foreignKey:HolidayID;references:ID"`
+}
+
+// Tipos de computo de los dias de vacaciones
+const (
+	HolidayDayTypeCalendar = "naturales"
+	HolidayDayTypeWorking  = "laborables"
+)
+
+// Derecho a vacaciones de un trabajador en un año concreto. Sin registro se
+// aplican los valores por defecto de la configuracion.
+type HolidayEntitlement struct {
+	ID            int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	WorkerID      string    `json:"worker_id" gorm:"size:50;not null;uniqueIndex:idx_entitlement_worker_year"`
+	Year          int       `json:"year" gorm:"not null;uniqueIndex:idx_entitlement_worker_year"`
+	DayType       string    `json:"day_type" gorm:"size:25;not null"`
+	Days          float64   `json:"days" gorm:"not null"`
+	CarryOverDays *float64  `json:"carry_over_days"` // Fija los dias arrastrados del año anterior en lugar de calcularlos
+	Comment       string    `json:"comment" gorm:"size:250"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Worker        Worker    `json:"-" gorm:"foreignKey:WorkerID;references:ID"`
 }
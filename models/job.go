@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Estados de una ejecucion de una tarea programada
+const (
+	JobRunRunning = "en_curso"
+	JobRunSuccess = "correcta"
+	JobRunFailed  = "fallida"
+)
+
+// Tarea programada. La fila sirve tambien de cerrojo para que solo una
+// instancia del servidor la ejecute a la vez.
+type ScheduledJob struct {
+	Name        string     `json:"name" gorm:"primaryKey;size:100"`
+	Schedule    string     `json:"schedule" gorm:"size:100;not null"` // Expresion cron
+	LockedBy    string     `json:"locked_by" gorm:"size:150"`
+	LockedUntil *time.Time `json:"locked_until"`
+	LastRunAt   *time.Time `json:"last_run_at"` // Ultima ejecucion programada que se lanzo
+	NextRunAt   *time.Time `json:"next_run_at" gorm:"-"`
+}
+
+// Ejecucion de una tarea programada
+type JobRun struct {
+	ID         int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	JobName    string     `json:"job_name" gorm:"size:100;not null;index"`
+	Instance   string     `json:"instance" gorm:"size:150"`
+	Manual     bool       `json:"manual"` // Lanzada a mano por un admin
+	Status     string     `json:"status" gorm:"size:25;not null"`
+	Message    string     `json:"message" gorm:"type:text"`
+	StartedAt  time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+// Codigos de los tipos de permiso que se crean por defecto
+const (
+	LeaveTypeVacation    = "vacaciones"
+	LeaveTypeSickLeave   = "it"            // Incapacidad temporal
+	LeaveTypeBirth       = "nacimiento"    // Maternidad y paternidad (art. 48.4 ET)
+	LeaveTypeMarriage    = "matrimonio"    // 15 dias naturales (art. 37.3.a ET)
+	LeaveTypeMoving      = "mudanza"       // 1 dia (art. 37.3.c ET)
+	LeaveTypeMedical     = "medico"        // Consulta medica
+	LeaveTypeUnpaidLeave = "no_retribuido" // Permiso sin sueldo
+)
+
+// Tipo de ausencia configurable. Las vacaciones son un tipo mas.
+type LeaveType struct {
+	Code             string   `json:"code" gorm:"primaryKey;size:50"`
+	Name             string   `json:"name" gorm:"size:100;not null"`
+	Paid             bool     `json:"paid"`
+	CountsAsHoliday  bool     `json:"counts_as_holiday"`                // Descuenta del saldo de vacaciones
+	RequiresDocument bool     `json:"requires_document"`                // Necesita justificante antes de la aprobacion final
+	DayType          string   `json:"day_type" gorm:"size:25;not null"` // naturales o laborables
+	MaxDays          *float64 `json:"max_days"`                         // Maximo por solicitud; vacio = sin limite
+	Active           bool     `json:"active"`
+}
+
+// DefaultLeaveTypes - Tipos de ausencia que se crean al arrancar si no existen
+func DefaultLeaveTypes() []LeaveType {
+	days := func(value float64) *float64 { return &value }
+	return []LeaveType{
+		{Code: LeaveTypeVacation, Name: "Vacaciones", Paid: true, CountsAsHoliday: true, DayType: HolidayDayTypeCalendar, Active: true},
+		{Code: LeaveTypeSickLeave, Name: "Incapacidad temporal", Paid: true, RequiresDocument: true, DayType: HolidayDayTypeCalendar, Active: true},
+		{Code: LeaveTypeBirth, Name: "Nacimiento y cuidado de menor", Paid: true, RequiresDocument: true, DayType: HolidayDayTypeCalendar, MaxDays: days(112), Active: true},
+		{Code: LeaveTypeMarriage, Name: "Matrimonio", Paid: true, RequiresDocument: true, DayType: HolidayDayTypeCalendar, MaxDays: days(15), Active: true},
+		{Code: LeaveTypeMoving, Name: "Traslado de domicilio", Paid: true, DayType: HolidayDayTypeWorking, MaxDays: days(1), Active: true},
+		{Code: LeaveTypeMedical, Name: "Consulta medica", Paid: true, RequiresDocument: true, DayType: HolidayDayTypeWorking, MaxDays: days(1), Active: true},
+		{Code: LeaveTypeUnpaidLeave, Name: "Permiso no retribuido", DayType: HolidayDayTypeCalendar, Active: true},
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Notificacion dirigida a un usuario (trabajador, tienda o admin)
+type Notification struct {
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    string     `json:"user_id" gorm:"size:50;not null;index"`
+	Type      string     `json:"type" gorm:"size:50;not null"`
+	Title     string     `json:"title" gorm:"size:250;not null"`
+	Body      string     `json:"body" gorm:"type:text"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
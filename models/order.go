@@ -1,11 +1,134 @@
 package models
 
+import "time"
+
+// Estados de un pedido
+const (
+	OrderStatusDraft             = "Borrador"              // La tienda aun lo esta preparando
+	OrderStatusSubmitted         = "Enviado"               // Enviado por la tienda, pendiente de revision
+	OrderStatusApproved          = "Aprobado"              // Revisado por el admin
+	OrderStatusOrdered           = "Pedido al proveedor"   // Incluido en un pedido a proveedor
+	OrderStatusPartiallyReceived = "Recibido parcialmente" // Falta mercancia por llegar
+	OrderStatusReceived          = "Recibido"
+	OrderStatusCancelled         = "Cancelado"
+)
+
+// Estados en el orden del ciclo de vida
+var OrderStatuses = []string{OrderStatusDraft, OrderStatusSubmitted, OrderStatusApproved, OrderStatusOrdered,
+	OrderStatusPartiallyReceived, OrderStatusReceived, OrderStatusCancelled}
+
+// Cambios de estado permitidos y roles que pueden hacer cada uno
+var OrderTransitions = map[string]map[string][]string{
+	OrderStatusDraft: {
+		OrderStatusSubmitted: {"store"},
+		OrderStatusCancelled: {"store"},
+	},
+	OrderStatusSubmitted: {
+		OrderStatusDraft:     {"store", "admin"}, // La tienda lo retira o el admin lo devuelve para corregirlo
+		OrderStatusApproved:  {"admin"},
+		OrderStatusCancelled: {"store", "admin"},
+	},
+	OrderStatusApproved: {
+		OrderStatusOrdered:   {"admin"},
+		OrderStatusCancelled: {"admin"},
+	},
+	OrderStatusOrdered: {
+		OrderStatusCancelled: {"admin"},
+	},
+	// Recibido parcialmente y Recibido no se eligen: salen de las recepciones
+}
+
+// NextOrderStatuses - Estados a los que un rol puede llevar un pedido
+func NextOrderStatuses(from, role string) []string {
+	next := []string{}
+	for _, to := range OrderStatuses {
+		for _, allowed := range OrderTransitions[from][to] {
+			if allowed == role {
+				next = append(next, to)
+			}
+		}
+	}
+	return next
+}
+
+// Pedido de suministros de una tienda
 type Order struct {
-	ID       string `json:"id" gorm:"primaryKey;uniqueIndex"`
-	Date     string `json:"date" gorm:"type:date;not null"`
-	Status   string `json:"status" gorm:"size:50;not null"`
-	Product  string `json:"product" gorm:"size:250;not null"`
-	Quantity int    `json:"quantity" gorm:"not null"`
-	StoreID  string `json:"store_id" gorm:"not null"`
-	Store    Store  `json:"-" gorm:"foreignKey:StoreID;references:ID"`
+	ID          string      `json:"id" gorm:"primaryKey;uniqueIndex"`
+	Number      string      `json:"number" gorm:"size:25;not null;uniqueIndex:idx_order_store_number"` // AAAA-NNNN, correlativo por tienda y año
+	Sequence    int         `json:"-" gorm:"not null"`
+	Date        string      `json:"date" gorm:"type:date;not null"` // Formato YYYY-MM-DD
+	Status      string      `json:"status" gorm:"size:50;not null;index"`
+	Comment     string      `json:"comment" gorm:"size:250"` // Comentario de la tienda
+	StoreID     string      `json:"store_id" gorm:"not null;uniqueIndex:idx_order_store_number"`
+	StoreName   string      `json:"store_name,omitempty" gorm:"->;-:migration"` // Solo en los listados
+	CreatedByID string      `json:"created_by_id" gorm:"size:50"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Lines       []OrderLine `json:"lines" gorm:"foreignKey:OrderID;references:ID;constraint:OnDelete:CASCADE"`
+	Store       Store       `json:"-" gorm:"foreignKey:StoreID;references:ID"`
+}
+
+// Linea de un pedido
+type OrderLine struct {
+	ID        int     `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID   string  `json:"order_id" gorm:"not null;index"`
+	ProductID *string `json:"product_id" gorm:"index"`          // Vacio en los pedidos anteriores al catalogo
+	SKU       string  `json:"sku" gorm:"size:50"`               // Copia del catalogo al hacer el pedido
+	Product   string  `json:"product" gorm:"size:250;not null"` // Nombre del producto al hacer el pedido
+	Unit      string  `json:"unit" gorm:"size:25"`
+	Quantity  int     `json:"quantity" gorm:"not null"`
+	Comment   string  `json:"comment" gorm:"size:250"`
+
+	// Acumulado de las recepciones
+	Received    int `json:"received" gorm:"not null;default:0"`    // Unidades en buen estado
+	Damaged     int `json:"damaged" gorm:"not null;default:0"`     // Unidades llegadas en mal estado
+	Substituted int `json:"substituted" gorm:"not null;default:0"` // Unidades servidas con otro producto
+	Short       int `json:"short" gorm:"not null;default:0"`       // Unidades que no llegaron al cerrar la recepcion
+}
+
+// Pending - Unidades de la linea que aun no han llegado
+func (l *OrderLine) Pending() int {
+	return max(l.Quantity-l.Received-l.Damaged-l.Substituted-l.Short, 0)
+}
+
+// Cambio de estado de un pedido
+type OrderStatusChange struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID    string    `json:"order_id" gorm:"not null;index"`
+	FromStatus string    `json:"from_status" gorm:"size:50"` // Vacio al crear el pedido
+	ToStatus   string    `json:"to_status" gorm:"size:50;not null"`
+	ActorID    string    `json:"actor_id" gorm:"size:50;not null"`
+	ActorRole  string    `json:"actor_role" gorm:"size:25;not null"`
+	Comment    string    `json:"comment" gorm:"size:250"`
+	CreatedAt  time.Time `json:"created_at"`
+	Order      Order     `json:"-" gorm:"foreignKey:OrderID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Recepcion de mercancia de un pedido; un pedido puede llegar en varias entregas
+type OrderReceipt struct {
+	ID           string             `json:"id" gorm:"primaryKey;uniqueIndex"`
+	OrderID      string             `json:"order_id" gorm:"not null;index"`
+	Date         string             `json:"date" gorm:"type:date;not null;index"` // Formato YYYY-MM-DD
+	Final        bool               `json:"final"`                                // La tienda no espera mas mercancia de este pedido
+	Comment      string             `json:"comment" gorm:"size:250"`
+	Photo        string             `json:"photo" gorm:"size:250"` // Foto adjunta como prueba
+	ReceivedByID string             `json:"received_by_id" gorm:"size:50;not null"`
+	CreatedAt    time.Time          `json:"created_at"`
+	Lines        []OrderReceiptLine `json:"lines" gorm:"foreignKey:ReceiptID;references:ID;constraint:OnDelete:CASCADE"`
+	Order        Order              `json:"-" gorm:"foreignKey:OrderID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Linea recibida de un pedido en una entrega
+type OrderReceiptLine struct {
+	ID                  int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReceiptID           string    `json:"receipt_id" gorm:"not null;index"`
+	OrderLineID         int       `json:"order_line_id" gorm:"not null;index"`
+	Received            int       `json:"received" gorm:"not null"`
+	Damaged             int       `json:"damaged" gorm:"not null"`
+	Short               int       `json:"short" gorm:"not null"`                         // Solo en la entrega final: lo que sigue pendiente
+	SubstituteProductID *string   `json:"substitute_product_id"`                         // Producto del catalogo servido en su lugar
+	SubstituteProduct   string    `json:"substitute_product" gorm:"size:250"`            // Nombre, tambien si no esta en el catalogo
+	SubstituteQuantity  int       `json:"substitute_quantity" gorm:"not null;default:0"` // Unidades pedidas que cubre el sustituto
+	Comment             string    `json:"comment" gorm:"size:250"`
+	OrderLine           OrderLine `json:"-" gorm:"foreignKey:OrderLineID;references:ID;constraint:OnDelete:CASCADE"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Producto del catalogo que las tiendas pueden pedir
+type Product struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	SKU        string    `json:"sku" gorm:"size:50;not null;uniqueIndex"`
+	Name       string    `json:"name" gorm:"size:250;not null"`
+	Category   string    `json:"category" gorm:"size:100;index"`
+	Unit       string    `json:"unit" gorm:"size:25;not null"`    // Unidad de medida: ud, kg, l, caja...
+	PackSize   int       `json:"pack_size" gorm:"not null"`       // Unidades por bulto; se pide en multiplos
+	Active     bool      `json:"active"`                          // Los inactivos no se pueden pedir
+	Price      float64   `json:"price" gorm:"not null;default:0"` // Precio de compra por unidad, sin IVA
+	SupplierID *string   `json:"supplier_id" gorm:"index"`        // Proveedor que lo sirve, opcional
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Supplier   *Supplier `json:"-" gorm:"foreignKey:SupplierID;references:ID;constraint:OnDelete:SET NULL"`
+}
+
+// Producto que una tienda puede pedir
+type StoreProduct struct {
+	StoreID   string  `json:"store_id" gorm:"primaryKey"`
+	ProductID string  `json:"product_id" gorm:"primaryKey"`
+	Store     Store   `json:"-" gorm:"foreignKey:StoreID;references:ID;constraint:OnDelete:CASCADE"`
+	Product   Product `json:"-" gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Ambitos de un calendario de festivos
+const (
+	PublicHolidayScopeNational = "nacional"   // Se aplica a todas las tiendas
+	PublicHolidayScopeRegional = "autonomico" // Se aplica a las tiendas asignadas
+	PublicHolidayScopeLocal    = "local"      // Se aplica a las tiendas de su ciudad y a las asignadas
+)
+
+// Calendario de festivos de un ambito (Estado, comunidad autonoma o municipio)
+type PublicHolidayCalendar struct {
+	ID        string    `json:"id" gorm:"primaryKey;uniqueIndex"`
+	Name      string    `json:"name" gorm:"size:100;not null"`
+	Scope     string    `json:"scope" gorm:"size:25;not null"`
+	Region    string    `json:"region" gorm:"size:100"`
+	City      string    `json:"city" gorm:"size:100;index"` // Debe coincidir con Store.City en los locales
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Dia festivo de un calendario
+type PublicHoliday struct {
+	ID         int                   `json:"id" gorm:"primaryKey;autoIncrement"`
+	CalendarID string                `json:"calendar_id" gorm:"size:50;not null;uniqueIndex:idx_public_holiday_calendar_date"`
+	Date       string                `json:"date" gorm:"type:date;not null;uniqueIndex:idx_public_holiday_calendar_date"` // Formato YYYY-MM-DD
+	Name       string                `json:"name" gorm:"size:150"`
+	Calendar   PublicHolidayCalendar `json:"-" gorm:"foreignKey:CalendarID;references:ID"`
+}
+
+// Asignacion de un calendario de festivos a una tienda
+type StoreHolidayCalendar struct {
+	StoreID    string                `json:"store_id" gorm:"primaryKey;size:50"`
+	CalendarID string                `json:"calendar_id" gorm:"primaryKey;size:50"`
+	Store      Store                 `json:"-" gorm:"foreignKey:StoreID;references:ID"`
+	Calendar   PublicHolidayCalendar `json:"-" gorm:"foreignKey:CalendarID;references:ID"`
+}
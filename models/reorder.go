@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Parametros de reposicion de un producto
+// ------------------------------------------------------------------
+// Con StoreID vacio valen para todas las tiendas; una fila de una tienda
+// concreta pisa solo los campos que tenga rellenos. Los campos vacios se
+// calculan a partir del consumo.
+type ReorderParameter struct {
+	ProductID    string    `json:"product_id" gorm:"primaryKey"`
+	StoreID      string    `json:"store_id" gorm:"primaryKey;size:50"`
+	MinLevel     *int      `json:"min_level"`      // Stock por debajo del cual se pide
+	MaxLevel     *int      `json:"max_level"`      // Stock hasta el que se pide
+	LeadTimeDays *int      `json:"lead_time_days"` // Dias desde que se pide hasta que llega
+	ReviewDays   *int      `json:"review_days"`    // Dias que debe cubrir cada pedido
+	UpdatedAt    time.Time `json:"updated_at"`
+	Product      Product   `json:"-" gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE"`
+}
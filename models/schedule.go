@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Estados de un periodo de planificacion
+const (
+	PeriodStatusDraft     = "Borrador"  // Solo visible para encargados y admin
+	PeriodStatusPublished = "Publicado" // Visible para los trabajadores, los cambios quedan registrados
+)
+
+// Tipos de cambio sobre un horario publicado
+const (
+	ChangeTypeCreated  = "creado"
+	ChangeTypeModified = "modificado"
+	ChangeTypeDeleted  = "eliminado"
+)
+
+// Periodo de planificacion de una tienda (p.ej. una semana)
+type SchedulePeriod struct {
+	ID            string     `json:"id" gorm:"primaryKey;uniqueIndex"`
+	StoreID       string     `json:"store_id" gorm:"size:50;not null;index"`
+	StartDate     string     `json:"start_date" gorm:"type:date;not null"` // Formato YYYY-MM-DD
+	EndDate       string     `json:"end_date" gorm:"type:date;not null"`
+	Status        string     `json:"status" gorm:"size:25;not null"`
+	PublishedAt   *time.Time `json:"published_at"`
+	PublishedByID *string    `json:"published_by_id" gorm:"size:50"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Store         Store      `json:"-" gorm:"foreignKey:StoreID;references:ID"`
+}
+
+// Copia de los datos de un turno en un momento dado
+type ShiftSnapshot struct {
+	WorkDate      string `json:"work_date"`
+	StartInterval string `json:"start_interval"`
+	EndInterval   string `json:"end_interval"`
+	Store         string `json:"store"`
+	WorkerID      string `json:"worker_id"`
+}
+
+// Cambio sobre un turno de un periodo ya publicado
+type ScheduleChange struct {
+	ID          int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	PeriodID    string         `json:"period_id" gorm:"size:50;not null;index"`
+	ShiftID     int            `json:"shift_id" gorm:"not null;index"`
+	ChangeType  string         `json:"change_type" gorm:"size:25;not null"`
+	Before      *ShiftSnapshot `json:"before" gorm:"type:jsonb;serializer:json"` // Vacio si el turno es nuevo
+	After       *ShiftSnapshot `json:"after" gorm:"type:jsonb;serializer:json"`  // Vacio si el turno se ha eliminado
+	ChangedByID string         `json:"changed_by_id" gorm:"size:50"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
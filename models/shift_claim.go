@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Modos de asignacion de los turnos abiertos de una tienda
+const (
+	ClaimModeFirstCome = "Directo"    // El primer trabajador elegible que lo reclama se queda el turno
+	ClaimModeApproval  = "Aprobacion" // El encargado elige entre los trabajadores que lo reclaman
+)
+
+// Estados de una reclamacion de turno abierto
+const (
+	ClaimStatusPending   = "Pendiente"
+	ClaimStatusApproved  = "Aprobada"
+	ClaimStatusRejected  = "Rechazada"
+	ClaimStatusWithdrawn = "Retirada"
+)
+
+// Reclamacion de un turno abierto por parte de un trabajador
+type ShiftClaim struct {
+	ID           string     `json:"id" gorm:"primaryKey;uniqueIndex"`
+	ShiftID      int        `json:"shift_id" gorm:"not null;index"`
+	WorkerID     string     `json:"worker_id" gorm:"size:50;not null;index"`
+	Status       string     `json:"status" gorm:"size:25;not null"`
+	DecidedByID  *string    `json:"decided_by_id" gorm:"size:50"`
+	DecisionNote string     `json:"decision_note" gorm:"size:250"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DecidedAt    *time.Time `json:"decided_at"`
+	Shift        WorkShift  `json:"shift" gorm:"foreignKey:ShiftID;references:ID"`
+	Worker       Worker     `json:"-" gorm:"foreignKey:WorkerID;references:ID"`
+}
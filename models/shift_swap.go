@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Tipos de solicitud de cambio de turno
+const (
+	SwapTypeExchange = "Intercambio" // Intercambio directo de turnos entre dos trabajadores
+	SwapTypeGiveAway = "Cesion"      // Cesion del turno a un trabajador concreto
+	SwapTypeOpen     = "Abierta"     // Cesion ofrecida a cualquier trabajador elegible
+)
+
+// Estados de una solicitud de cambio de turno
+const (
+	SwapStatusPending   = "Pendiente" // Esperando a que la contraparte acepte
+	SwapStatusAccepted  = "Aceptada"  // Aceptada por la contraparte, pendiente de aprobacion
+	SwapStatusApproved  = "Aprobada"  // Aprobada por el encargado o el admin, turnos cambiados
+	SwapStatusRejected  = "Rechazada" // Rechazada por la contraparte, el encargado o el admin
+	SwapStatusCancelled = "Cancelada" // Cancelada por el solicitante
+)
+
+type ShiftSwap struct {
+	ID             string     `json:"id" gorm:"primaryKey;uniqueIndex"`
+	Type           string     `json:"type" gorm:"size:25;not null"`
+	Status         string     `json:"status" gorm:"size:25;not null;index"`
+	ShiftID        int        `json:"shift_id" gorm:"not null;index"`  // Turno que cede el solicitante
+	RequesterID    string     `json:"requester_id" gorm:"not null"`    // Trabajador que solicita el cambio
+	TargetWorkerID *string    `json:"target_worker_id" gorm:"size:50"` // Contraparte (vacio en las ofertas abiertas)
+	TargetShiftID  *int       `json:"target_shift_id"`                 // Turno que recibe el solicitante en un intercambio
+	AcceptedByID   *string    `json:"accepted_by_id" gorm:"size:50"`   // Trabajador que acepta la solicitud
+	DecidedByID    *string    `json:"decided_by_id" gorm:"size:50"`    // Usuario que aprueba o rechaza
+	Comment        string     `json:"comment" gorm:"size:250"`         // Comentario del solicitante
+	DecisionNote   string     `json:"decision_note" gorm:"size:250"`   // Comentario de la decision
+	CreatedAt      time.Time  `json:"created_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	DecidedAt      *time.Time `json:"decided_at"`
+	Shift          WorkShift  `json:"shift" gorm:"foreignKey:ShiftID;references:ID"`
+	Requester      Worker     `json:"-" gorm:"foreignKey:RequesterID;references:ID"`
+}
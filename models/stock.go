@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Tipos de movimiento de stock
+const (
+	StockMovementReceipt = "recepcion" // Mercancia recibida de un pedido
+	StockMovementCount   = "recuento"  // Ajuste al contar el stock
+	StockMovementWaste   = "merma"     // Producto tirado, caducado o roto en tienda
+)
+
+// Stock actual de un producto en una tienda
+type StockLevel struct {
+	StoreID   string    `json:"store_id" gorm:"primaryKey"`
+	ProductID string    `json:"product_id" gorm:"primaryKey"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	CountedAt *string   `json:"counted_at" gorm:"type:date"` // Fecha del ultimo recuento
+	UpdatedAt time.Time `json:"updated_at"`
+	SKU       string    `json:"sku,omitempty" gorm:"->;-:migration"` // Solo en los listados
+	Product   string    `json:"product,omitempty" gorm:"->;-:migration"`
+	Unit      string    `json:"unit,omitempty" gorm:"->;-:migration"`
+	Category  string    `json:"category,omitempty" gorm:"->;-:migration"`
+	Store     Store     `json:"-" gorm:"foreignKey:StoreID;references:ID;constraint:OnDelete:CASCADE"`
+	Catalog   Product   `json:"-" gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Movimiento de stock; el historial de cada producto en cada tienda
+type StockMovement struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID   string    `json:"store_id" gorm:"not null;index:idx_stock_movement_product"`
+	ProductID string    `json:"product_id" gorm:"not null;index:idx_stock_movement_product"`
+	Type      string    `json:"type" gorm:"size:25;not null;index"`
+	Quantity  int       `json:"quantity" gorm:"not null"` // Positivo entra, negativo sale
+	Balance   int       `json:"balance" gorm:"not null"`  // Stock despues del movimiento
+	OrderID   *string   `json:"order_id"`                 // Pedido de la recepcion
+	CountID   *string   `json:"count_id"`                 // Recuento que lo ajusto
+	Reason    string    `json:"reason" gorm:"size:250"`
+	ActorID   string    `json:"actor_id" gorm:"size:50;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	SKU       string    `json:"sku,omitempty" gorm:"->;-:migration"` // Solo en los listados
+	Product   string    `json:"product,omitempty" gorm:"->;-:migration"`
+	Store     Store     `json:"-" gorm:"foreignKey:StoreID;references:ID;constraint:OnDelete:CASCADE"`
+	Catalog   Product   `json:"-" gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Recuento de stock de una tienda
+type StockCount struct {
+	ID          string           `json:"id" gorm:"primaryKey;uniqueIndex"`
+	StoreID     string           `json:"store_id" gorm:"not null;index"`
+	Date        string           `json:"date" gorm:"type:date;not null;index"` // Formato YYYY-MM-DD
+	Comment     string           `json:"comment" gorm:"size:250"`
+	CreatedByID string           `json:"created_by_id" gorm:"size:50;not null"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Lines       []StockCountLine `json:"lines" gorm:"foreignKey:CountID;references:ID;constraint:OnDelete:CASCADE"`
+	Store       Store            `json:"-" gorm:"foreignKey:StoreID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Producto contado en un recuento
+type StockCountLine struct {
+	ID        int     `json:"id" gorm:"primaryKey;autoIncrement"`
+	CountID   string  `json:"count_id" gorm:"not null;index"`
+	ProductID string  `json:"product_id" gorm:"not null;index"`
+	Expected  int     `json:"expected" gorm:"not null"` // Stock del sistema antes de contar
+	Counted   int     `json:"counted" gorm:"not null"`
+	Variance  int     `json:"variance" gorm:"not null"` // Contado - esperado
+	SKU       string  `json:"sku,omitempty" gorm:"->;-:migration"`
+	Product   string  `json:"product,omitempty" gorm:"->;-:migration"`
+	Catalog   Product `json:"-" gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE"`
+}
@@ -1,11 +1,15 @@
 package models
 
+import "gorm.io/gorm"
+
 type Store struct {
-	ID     string `form:"id" json:"id" gorm:"uniqueIndex"`
-	Name   string `form:"name" json:"name" gorm:"not null size:100"`
-	City   string `form:"city" json:"city" gorm:"not null size:100"`
-	Phone  int    `form:"phone" json:"phone" gorm:"size:25"`
-	Status string `form:"status" json:"status" gorm:"not null size:100"`
-	UserID string `json:"user_id" gorm:"not null"`
-	User   User   `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	ID        string         `form:"id" json:"id" gorm:"uniqueIndex"`
+	Name      string         `form:"name" json:"name" gorm:"not null size:100"`
+	City      string         `form:"city" json:"city" gorm:"not null size:100"`
+	Phone     string         `form:"phone" json:"phone" gorm:"size:25"` // Formato internacional, +34612345678
+	Status    string         `form:"status" json:"status" gorm:"not null size:100"`
+	ClaimMode string         `form:"claim_mode" json:"claim_mode" gorm:"size:25"` // Directo o Aprobacion (por defecto)
+	UserID    string         `json:"user_id" gorm:"not null"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Fecha de archivo; se purga pasado el plazo de conservacion
+	User      User           `json:"-" gorm:"foreignKey:UserID;references:ID"`
 }
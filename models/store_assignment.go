@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Tipos de asignacion de un trabajador a una tienda
+const (
+	AssignmentPrimary   = "principal"  // Tienda del trabajador; solo una a la vez
+	AssignmentSecondary = "secundaria" // Tienda en la que tambien puede fichar
+)
+
+// Asignacion de un trabajador a una tienda con fechas de efecto
+// ------------------------------------------------------------------
+// Un traslado cierra la asignacion principal y abre otra; si empieza en el
+// futuro queda programado y se aplica solo ese dia. Worker.StoreID guarda
+// la tienda principal en vigor.
+type StoreAssignment struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	WorkerID    string    `json:"worker_id" gorm:"size:50;not null;index"`
+	StoreID     string    `json:"store_id" gorm:"size:50;not null;index"`
+	StoreName   string    `json:"store_name,omitempty" gorm:"->;-:migration"` // Solo en los listados
+	Kind        string    `json:"kind" gorm:"size:25;not null"`
+	StartDate   string    `json:"start_date" gorm:"type:date;not null"`
+	EndDate     *string   `json:"end_date" gorm:"type:date"` // Sin fecha de fin sigue en vigor
+	Comment     string    `json:"comment" gorm:"size:250"`
+	CreatedByID string    `json:"created_by_id" gorm:"size:50"`
+	CreatedAt   time.Time `json:"created_at"`
+	Worker      Worker    `json:"-" gorm:"foreignKey:WorkerID;references:ID;constraint:OnDelete:CASCADE"`
+	Store       Store     `json:"-" gorm:"foreignKey:StoreID;references:ID;constraint:OnDelete:CASCADE"`
+}
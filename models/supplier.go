@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Estados de un pedido a proveedor
+const (
+	PurchaseOrderOpen = "Abierto" // Se le pueden seguir sumando pedidos de tiendas
+	PurchaseOrderSent = "Enviado" // Enviado al proveedor
+)
+
+// Proveedor al que se compra para todas las tiendas
+type Supplier struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"size:150;not null;uniqueIndex"`
+	TaxID          string    `json:"tax_id" gorm:"size:25"` // CIF
+	ContactName    string    `json:"contact_name" gorm:"size:150"`
+	Email          string    `json:"email" gorm:"size:150"`
+	Phone          string    `json:"phone" gorm:"size:25"`
+	Address        string    `json:"address" gorm:"size:250"`
+	DeliveryDays   []int     `json:"delivery_days" gorm:"type:jsonb;serializer:json"` // Dias de reparto, 1 lunes ... 7 domingo; vacio = cualquiera
+	LeadTimeDays   int       `json:"lead_time_days" gorm:"not null;default:0"`        // Dias desde el pedido hasta el primer reparto posible
+	MinOrderAmount float64   `json:"min_order_amount" gorm:"not null;default:0"`      // Importe minimo de un pedido, sin IVA
+	Active         bool      `json:"active"`
+	Notes          string    `json:"notes" gorm:"size:500"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Pedido a proveedor que agrupa las lineas aprobadas de varias tiendas
+type PurchaseOrder struct {
+	ID           string              `json:"id" gorm:"primaryKey"`
+	Number       string              `json:"number" gorm:"size:25;not null;uniqueIndex"` // OC-AAAA-NNNN
+	Sequence     int                 `json:"-" gorm:"not null"`
+	SupplierID   string              `json:"supplier_id" gorm:"not null;index"`
+	SupplierName string              `json:"supplier_name,omitempty" gorm:"->;-:migration"` // Solo en los listados
+	DeliveryDate string              `json:"delivery_date" gorm:"type:date;not null;index"`
+	Status       string              `json:"status" gorm:"size:25;not null;index"`
+	Total        float64             `json:"total" gorm:"not null;default:0"` // Importe sin IVA
+	CreatedByID  string              `json:"created_by_id" gorm:"size:50;not null"`
+	SentAt       *time.Time          `json:"sent_at"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	Lines        []PurchaseOrderLine `json:"lines,omitempty" gorm:"foreignKey:PurchaseOrderID;references:ID;constraint:OnDelete:CASCADE"`
+	Supplier     Supplier            `json:"-" gorm:"foreignKey:SupplierID;references:ID"`
+}
+
+// Linea de un pedido a proveedor; una por cada linea de pedido de tienda
+type PurchaseOrderLine struct {
+	ID              int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	PurchaseOrderID string    `json:"purchase_order_id" gorm:"not null;index"`
+	OrderLineID     int       `json:"order_line_id" gorm:"not null;uniqueIndex"` // Cada linea de tienda va a un solo pedido a proveedor
+	OrderID         string    `json:"order_id" gorm:"not null;index"`
+	StoreID         string    `json:"store_id" gorm:"not null"`
+	StoreName       string    `json:"store_name,omitempty" gorm:"->;-:migration"`
+	OrderNumber     string    `json:"order_number,omitempty" gorm:"->;-:migration"`
+	ProductID       string    `json:"product_id" gorm:"not null"`
+	SKU             string    `json:"sku" gorm:"size:50"`
+	Product         string    `json:"product" gorm:"size:250;not null"`
+	Unit            string    `json:"unit" gorm:"size:25"`
+	Quantity        int       `json:"quantity" gorm:"not null"`
+	UnitPrice       float64   `json:"unit_price" gorm:"not null;default:0"` // Precio de compra al consolidar
+	OrderLine       OrderLine `json:"-" gorm:"foreignKey:OrderLineID;references:ID"`
+}
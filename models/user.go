@@ -2,12 +2,15 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        string    `json:"id" gorm:"primaryKey;uniqueIndex;autoIncrement"`
-	Username  string    `json:"username" gorm:"size:100;not null;uniqueIndex"`
-	Password  string    `json:"password" gorm:"size:255;not null"`
-	Role      string    `json:"role" gorm:"size:50;not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	ID        string         `json:"id" gorm:"primaryKey;uniqueIndex;autoIncrement"`
+	Username  string         `json:"username" gorm:"size:100;not null;uniqueIndex"`
+	Password  string         `json:"password" gorm:"size:255;not null"`
+	Role      string         `json:"role" gorm:"size:50;not null"`
+	CreatedAt time.Time      `json:"created_at" gorm:"not null"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Archivado; no puede iniciar sesion
 }
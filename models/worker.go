@@ -1,16 +1,21 @@
 package models
 
+import "gorm.io/gorm"
+
 type Worker struct {
-	ID       string  `json:"id" gorm:"primaryKey;uniqueIndex"`
-	Name     string  `json:"name" gorm:"size:100"`
-	LastName string  `json:"last_name" gorm:"size:100"`
-	Email    string  `json:"email" gorm:"size:100"`
-	Nie      string  `json:"nie" gorm:"uniqueIndex"`
-	Cargo    string  `json:"cargo" gorm:"size:50"`
-	Status   string  `json:"status" gorm:"size:25"`
-	Prueba   string  `json:"prueba" gorm:"size:25"`
-	StoreID  *string `json:"store_id" gorm:"size:50"`
-	UserID   string  `json:"user_id" gorm:"not null"`
-	Store    Store   `json:"store" gorm:"foreignKey:StoreID;references:ID"`
-	User     User    `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	ID        string         `json:"id" gorm:"primaryKey;uniqueIndex"`
+	Name      string         `json:"name" gorm:"size:100"`
+	LastName  string         `json:"last_name" gorm:"size:100"`
+	Email     string         `json:"email" gorm:"size:100"`
+	Nie       string         `json:"nie" gorm:"uniqueIndex"` // DNI o NIE en mayusculas y sin separadores
+	Nss       string         `json:"nss" gorm:"size:12"`     // Numero de afiliacion a la Seguridad Social, opcional
+	Cargo     string         `json:"cargo" gorm:"size:50"`
+	Status    string         `json:"status" gorm:"size:25"`
+	Prueba    string         `json:"prueba" gorm:"size:25"`
+	HireDate  *string        `json:"hire_date" gorm:"type:date"` // Inicio del contrato, base del devengo de vacaciones
+	StoreID   *string        `json:"store_id" gorm:"size:50"`
+	UserID    string         `json:"user_id" gorm:"not null"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Archivado: fuera de las consultas hasta que se restaure o se purgue
+	Store     Store          `json:"store" gorm:"foreignKey:StoreID;references:ID"`
+	User      User           `json:"-" gorm:"foreignKey:UserID;references:ID"`
 }
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+)
+
+type AssignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAssignmentRepository(db *gorm.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+// CreateAssignment - Crea una asignacion de tienda
+// --------------------------------------------------------------------
+func (r *AssignmentRepository) CreateAssignment(tx *gorm.DB, assignment *models.StoreAssignment) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Omit("Worker", "Store").Create(assignment).Error
+}
+
+// SetAssignmentEnd - Cambia la fecha de fin de una asignacion (nil = sin fin)
+// --------------------------------------------------------------------
+func (r *AssignmentRepository) SetAssignmentEnd(tx *gorm.DB, assignmentID int, endDate *string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.StoreAssignment{}).Where("id = ?", assignmentID).Update("end_date", endDate).Error
+}
+
+// DeleteAssignment - Elimina una asignacion
+// --------------------------------------------------------------------
+func (r *AssignmentRepository) DeleteAssignment(tx *gorm.DB, assignmentID int) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Delete(&models.StoreAssignment{}, assignmentID).Error
+}
+
+// FindAssignmentByID - Busca una asignacion por su ID
+// --------------------------------------------------------------------
+func (r *AssignmentRepository) FindAssignmentByID(assignmentID int) (*models.StoreAssignment, error) {
+	var assignment models.StoreAssignment
+	if err := r.db.First(&assignment, assignmentID).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// GetWorkerAssignments - Obtiene el historial de tiendas de un trabajador por fecha de inicio
+// --------------------------------------------------------------------
+// Con kind vacio devuelve las principales y las secundarias.
+func (r *AssignmentRepository) GetWorkerAssignments(tx *gorm.DB, workerID, kind string) ([]models.StoreAssignment, error) {
+	var assignments []models.StoreAssignment
+	if tx == nil {
+		tx = r.db
+	}
+	query := tx.Table("store_assignments").
+		Select("store_assignments.*, stores.name AS store_name").
+		Joins("JOIN stores ON stores.id = store_assignments.store_id").
+		Where("store_assignments.worker_id = ?", workerID)
+	if kind != "" {
+		query = query.Where("store_assignments.kind = ?", kind)
+	}
+	if err := query.Order("store_assignments.start_date, store_assignments.id").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// FindOverlappingAssignment - Busca una asignacion que se solape con el periodo
+// --------------------------------------------------------------------
+// Las principales se comparan con todas las principales del trabajador y
+// las secundarias con las de la misma tienda. excludeID deja fuera la que se edita.
+func (r *AssignmentRepository) FindOverlappingAssignment(tx *gorm.DB, assignment *models.StoreAssignment, excludeID int) (*models.StoreAssignment, error) {
+	var other models.StoreAssignment
+	if tx == nil {
+		tx = r.db
+	}
+	query := tx.Where("worker_id = ? AND kind = ? AND id <> ?", assignment.WorkerID, assignment.Kind, excludeID).
+		Where("end_date IS NULL OR end_date >= ?", assignment.StartDate)
+	if assignment.Kind == models.AssignmentSecondary {
+		query = query.Where("store_id = ?", assignment.StoreID)
+	}
+	if assignment.EndDate != nil {
+		query = query.Where("start_date <= ?", *assignment.EndDate)
+	}
+	if err := query.First(&other).Error; err != nil {
+		return nil, err
+	}
+	return &other, nil
+}
+
+// HasAssignments - Indica si el trabajador tiene alguna asignacion de tienda
+// --------------------------------------------------------------------
+func (r *AssignmentRepository) HasAssignments(tx *gorm.DB, workerID string) (bool, error) {
+	var count int64
+	if tx == nil {
+		tx = r.db
+	}
+	if err := tx.Model(&models.StoreAssignment{}).Where("worker_id = ?", workerID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsAssignedOn - Indica si el trabajador esta asignado a una tienda en una fecha, como principal o secundaria
+// --------------------------------------------------------------------
+func (r *AssignmentRepository) IsAssignedOn(workerID, storeID, date string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.StoreAssignment{}).
+		Where("worker_id = ? AND store_id = ? AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)", workerID, storeID, date, date).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SyncWorkerStores - Pone en Worker.StoreID la tienda principal en vigor en una fecha
+// --------------------------------------------------------------------
+// Solo toca a los trabajadores con asignaciones; sin asignacion principal en
+// vigor se quedan sin tienda. Con workerID vacio revisa todos. Devuelve
+// cuantos trabajadores han cambiado de tienda.
+func (r *AssignmentRepository) SyncWorkerStores(tx *gorm.DB, workerID, date string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	current := `SELECT store_id FROM store_assignments
+		WHERE worker_id = workers.id AND kind = ? AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)
+		LIMIT 1`
+	query := tx.Model(&models.Worker{}).
+		Where("EXISTS (SELECT 1 FROM store_assignments WHERE worker_id = workers.id)").
+		Where("store_id IS DISTINCT FROM ("+current+")", models.AssignmentPrimary, date, date)
+	if workerID != "" {
+		query = query.Where("id = ?", workerID)
+	}
+	result := query.Update("store_id", gorm.Expr("("+current+")", models.AssignmentPrimary, date, date))
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+)
+
+type CalendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) *CalendarRepository {
+	return &CalendarRepository{db: db}
+}
+
+// CreateFeed - Crea una nueva suscripcion de calendario
+// --------------------------------------------------------------------
+func (r *CalendarRepository) CreateFeed(feed *models.CalendarFeed) error {
+	return r.db.Create(feed).Error
+}
+
+// FindActiveFeedByToken - Busca una suscripcion no revocada por su token
+// --------------------------------------------------------------------
+func (r *CalendarRepository) FindActiveFeedByToken(token string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.Where("token = ? AND revoked_at IS NULL", token).First(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// GetFeeds - Obtiene las suscripciones de un trabajador o una tienda (vacios = todas)
+// --------------------------------------------------------------------
+func (r *CalendarRepository) GetFeeds(workerID, storeID string) ([]models.CalendarFeed, error) {
+	var feeds []models.CalendarFeed
+	query := r.db.Order("created_at DESC")
+	if workerID != "" {
+		query = query.Where("worker_id = ?", workerID)
+	}
+	if storeID != "" {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.Find(&feeds).Error; err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+// RevokeOwnerFeeds - Revoca las suscripciones activas de un trabajador o una tienda
+// --------------------------------------------------------------------
+func (r *CalendarRepository) RevokeOwnerFeeds(workerID, storeID *string) error {
+	query := r.db.Model(&models.CalendarFeed{}).Where("revoked_at IS NULL")
+	if workerID != nil {
+		query = query.Where("worker_id = ?", *workerID)
+	} else {
+		query = query.Where("worker_id IS NULL")
+	}
+	if storeID != nil {
+		query = query.Where("store_id = ?", *storeID)
+	} else {
+		query = query.Where("store_id IS NULL")
+	}
+	return query.Update("revoked_at", time.Now()).Error
+}
+
+// RevokeFeed - Revoca una suscripcion por su ID
+// --------------------------------------------------------------------
+func (r *CalendarRepository) RevokeFeed(feedID string) error {
+	return r.db.Model(&models.CalendarFeed{}).
+		Where("id = ? AND revoked_at IS NULL", feedID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// CreateCancellation - Registra un evento eliminado de un calendario
+// --------------------------------------------------------------------
+func (r *CalendarRepository) CreateCancellation(tx *gorm.DB, cancellation *models.CalendarCancellation) error {
+	if tx != nil {
+		return tx.Create(cancellation).Error
+	}
+	return r.db.Create(cancellation).Error
+}
+
+// GetCancellations - Obtiene los eventos cancelados de un trabajador o una tienda desde una fecha
+// --------------------------------------------------------------------
+func (r *CalendarRepository) GetCancellations(workerID, storeID string, since time.Time) ([]models.CalendarCancellation, error) {
+	var cancellations []models.CalendarCancellation
+	query := r.db.Where("ends_at >= ?", since)
+	if workerID != "" {
+		query = query.Where("worker_id = ?", workerID)
+	}
+	if storeID != "" {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.Order("cancelled_at").Find(&cancellations).Error; err != nil {
+		return nil, err
+	}
+	return cancellations, nil
+}
@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ContractRepository struct {
+	db *gorm.DB
+}
+
+func NewContractRepository(db *gorm.DB) *ContractRepository {
+	return &ContractRepository{db: db}
+}
+
+// CreateContract - Crea un contrato
+// --------------------------------------------------------------------
+func (r *ContractRepository) CreateContract(tx *gorm.DB, contract *models.Contract) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Create(contract).Error
+}
+
+// UpdateContract - Guarda todos los campos de un contrato
+// --------------------------------------------------------------------
+func (r *ContractRepository) UpdateContract(tx *gorm.DB, contract *models.Contract) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Omit("Worker").Save(contract).Error
+}
+
+// FindContractByID - Busca un contrato por su ID
+// --------------------------------------------------------------------
+func (r *ContractRepository) FindContractByID(contractID string) (*models.Contract, error) {
+	var contract models.Contract
+	if err := r.db.Where("id = ?", contractID).First(&contract).Error; err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// GetWorkerContracts - Obtiene el historial de contratos de un trabajador por fecha de inicio
+// --------------------------------------------------------------------
+func (r *ContractRepository) GetWorkerContracts(tx *gorm.DB, workerID string) ([]models.Contract, error) {
+	var contracts []models.Contract
+	if tx == nil {
+		tx = r.db
+	}
+	if err := tx.Where("worker_id = ?", workerID).Order("start_date").Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+	return contracts, nil
+}
+
+// FindOverlappingContract - Busca un contrato del trabajador que se solape con el periodo
+// --------------------------------------------------------------------
+// Un periodo sin fin llega hasta el infinito. excludeID deja fuera el contrato que se edita.
+func (r *ContractRepository) FindOverlappingContract(tx *gorm.DB, workerID, start string, end *string, excludeID string) (*models.Contract, error) {
+	var contract models.Contract
+	if tx == nil {
+		tx = r.db
+	}
+	query := tx.Where("worker_id = ? AND id <> ?", workerID, excludeID).
+		Where("end_date IS NULL OR end_date >= ?", start)
+	if end != nil {
+		query = query.Where("start_date <= ?", *end)
+	}
+	if err := query.First(&contract).Error; err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// FindContractOn - Busca el contrato en vigor de un trabajador en una fecha
+// --------------------------------------------------------------------
+func (r *ContractRepository) FindContractOn(tx *gorm.DB, workerID, date string) (*models.Contract, error) {
+	var contract models.Contract
+	if tx == nil {
+		tx = r.db
+	}
+	err := tx.Where("worker_id = ? AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)", workerID, date, date).
+		First(&contract).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// HasContracts - Indica si el trabajador tiene algun contrato registrado
+// --------------------------------------------------------------------
+func (r *ContractRepository) HasContracts(tx *gorm.DB, workerID string) (bool, error) {
+	var count int64
+	if tx == nil {
+		tx = r.db
+	}
+	if err := tx.Model(&models.Contract{}).Where("worker_id = ?", workerID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetContractsBetween - Obtiene los contratos en vigor en algun dia del periodo
+// --------------------------------------------------------------------
+func (r *ContractRepository) GetContractsBetween(workerIDs []string, from, to string) ([]models.Contract, error) {
+	var contracts []models.Contract
+	if len(workerIDs) == 0 {
+		return contracts, nil
+	}
+	err := r.db.Where("worker_id IN ? AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)", workerIDs, to, from).
+		Order("worker_id, start_date").Find(&contracts).Error
+	if err != nil {
+		return nil, err
+	}
+	return contracts, nil
+}
+
+// GetContractedWorkerIDs - Obtiene los trabajadores con algun contrato registrado
+// --------------------------------------------------------------------
+func (r *ContractRepository) GetContractedWorkerIDs() ([]string, error) {
+	var workerIDs []string
+	if err := r.db.Model(&models.Contract{}).Distinct("worker_id").Pluck("worker_id", &workerIDs).Error; err != nil {
+		return nil, err
+	}
+	return workerIDs, nil
+}
+
+// GetPendingTrials - Obtiene los periodos de prueba sin decidir que terminan en el periodo
+// --------------------------------------------------------------------
+// Con unalertedOnly solo los que aun no se han avisado.
+func (r *ContractRepository) GetPendingTrials(from, to, storeID string, unalertedOnly bool) ([]dtos.TrialPeriodAlert, error) {
+	var trials []dtos.TrialPeriodAlert
+	query := r.db.Table("contracts").
+		Select(`contracts.id AS contract_id, contracts.worker_id, workers.name AS worker_name,
+			workers.last_name AS worker_last_name, COALESCE(workers.store_id, '') AS store_id,
+			contracts.type AS contract_type, contracts.category, contracts.trial_start_date, contracts.trial_end_date`).
+		Joins("JOIN workers ON workers.id = contracts.worker_id").
+		Where("contracts.trial_outcome = '' AND contracts.trial_end_date BETWEEN ? AND ? AND workers.deleted_at IS NULL", from, to)
+	if storeID != "" {
+		query = query.Where("workers.store_id = ?", storeID)
+	}
+	if unalertedOnly {
+		query = query.Where("contracts.trial_alerted_at IS NULL")
+	}
+	if err := query.Order("contracts.trial_end_date, workers.name").Scan(&trials).Error; err != nil {
+		return nil, err
+	}
+	return trials, nil
+}
+
+// MarkTrialAlerted - Marca el aviso de fin de prueba de un contrato como enviado
+// --------------------------------------------------------------------
+func (r *ContractRepository) MarkTrialAlerted(tx *gorm.DB, contractID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.Contract{}).Where("id = ?", contractID).Update("trial_alerted_at", time.Now()).Error
+}
+
+// FindTrialRule - Busca la regla de periodo de prueba mas concreta para un contrato
+// --------------------------------------------------------------------
+// Prima la que fija la modalidad, despues la que fija la categoria y por ultimo la general.
+func (r *ContractRepository) FindTrialRule(contractType, category string) (*models.TrialPeriodRule, error) {
+	var rule models.TrialPeriodRule
+	err := r.db.Where("contract_type IN ('', ?) AND category IN ('', ?)", contractType, category).
+		Order("contract_type = '', category = ''").First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetTrialRules - Obtiene las reglas de periodo de prueba
+// --------------------------------------------------------------------
+func (r *ContractRepository) GetTrialRules() ([]models.TrialPeriodRule, error) {
+	var rules []models.TrialPeriodRule
+	if err := r.db.Order("contract_type, category").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// SaveTrialRule - Crea o sustituye la regla de una modalidad y categoria
+// --------------------------------------------------------------------
+func (r *ContractRepository) SaveTrialRule(rule *models.TrialPeriodRule) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "contract_type"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"days", "updated_at"}),
+	}).Create(rule).Error
+}
+
+// DeleteTrialRule - Elimina una regla de periodo de prueba
+// --------------------------------------------------------------------
+func (r *ContractRepository) DeleteTrialRule(ruleID int) (int64, error) {
+	result := r.db.Delete(&models.TrialPeriodRule{}, ruleID)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+)
+
+type CoverageRepository struct {
+	db *gorm.DB
+}
+
+func NewCoverageRepository(db *gorm.DB) *CoverageRepository {
+	return &CoverageRepository{db: db}
+}
+
+// CreateRule - Crea una regla de cobertura
+// --------------------------------------------------------------------
+func (r *CoverageRepository) CreateRule(rule *models.StoreLeaveRule) error {
+	return r.db.Create(rule).Error
+}
+
+// GetStoreRules - Obtiene las reglas de cobertura de una tienda
+// --------------------------------------------------------------------
+func (r *CoverageRepository) GetStoreRules(storeID string) ([]models.StoreLeaveRule, error) {
+	var rules []models.StoreLeaveRule
+	if err := r.db.Where("store_id = ?", storeID).Order("cargo").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// DeleteRule - Elimina una regla de cobertura
+// --------------------------------------------------------------------
+func (r *CoverageRepository) DeleteRule(ruleID int) (int64, error) {
+	result := r.db.Delete(&models.StoreLeaveRule{}, ruleID)
+	return result.RowsAffected, result.Error
+}
+
+// CreateBlackout - Crea un periodo bloqueado
+// --------------------------------------------------------------------
+func (r *CoverageRepository) CreateBlackout(blackout *models.StoreBlackout) error {
+	return r.db.Create(blackout).Error
+}
+
+// GetStoreBlackouts - Obtiene los periodos bloqueados de una tienda
+// --------------------------------------------------------------------
+func (r *CoverageRepository) GetStoreBlackouts(storeID string) ([]models.StoreBlackout, error) {
+	var blackouts []models.StoreBlackout
+	if err := r.db.Where("store_id = ?", storeID).Order("start_date").Find(&blackouts).Error; err != nil {
+		return nil, err
+	}
+	return blackouts, nil
+}
+
+// GetBlackoutsBetween - Obtiene los periodos bloqueados de una tienda que se solapan con un rango
+// --------------------------------------------------------------------
+func (r *CoverageRepository) GetBlackoutsBetween(storeID, from, to string) ([]models.StoreBlackout, error) {
+	var blackouts []models.StoreBlackout
+	err := r.db.Where("store_id = ? AND start_date <= ? AND end_date >= ?", storeID, to, from).
+		Order("start_date").
+		Find(&blackouts).Error
+	if err != nil {
+		return nil, err
+	}
+	return blackouts, nil
+}
+
+// DeleteBlackout - Elimina un periodo bloqueado
+// --------------------------------------------------------------------
+func (r *CoverageRepository) DeleteBlackout(blackoutID int) (int64, error) {
+	result := r.db.Delete(&models.StoreBlackout{}, blackoutID)
+	return result.RowsAffected, result.Error
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/javimartzs/worker-hub-backend/models"
 	"github.com/javimartzs/worker-hub-backend/models/dtos"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type HolidaysRepository struct {
@@ -18,15 +19,18 @@ func NewHolidaysRepository(db *gorm.DB) *HolidaysRepository {
 
 // CreateHoliday - Crea una nueva vacacion
 // --------------------------------------------------------------------
-func (r *HolidaysRepository) CreateHoliday(holiday *models.Holiday) error {
+func (r *HolidaysRepository) CreateHoliday(tx *gorm.DB, holiday *models.Holiday) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Create(holiday).Error
+	}
 	return r.db.Create(holiday).Error
 }
 
 // GetHolidayByID - Obtiene una vacacion por su ID
 // --------------------------------------------------------------------
-func (r *HolidaysRepository) GetHolidayByID(holidayID string) (*models.Holiday, error) {
+func (r *HolidaysRepository) GetHolidayByID(holidayID int) (*models.Holiday, error) {
 	var holiday models.Holiday
-	if err := r.db.First(&holiday, holidayID).Error; err != nil {
+	if err := r.db.Where("id = ?", holidayID).First(&holiday).Error; err != nil {
 		return nil, err
 	}
 	return &holiday, nil
@@ -62,12 +66,215 @@ func (r *HolidaysRepository) GetHolidaysWithWorker() ([]dtos.HolidayWithWorkerNa
 
 // DeleteHoliday - Elimina una vacacion
 // --------------------------------------------------------------------
-func (r *HolidaysRepository) DeleteHoliday(holidayID string) error {
-	return r.db.Delete(&models.Holiday{}, holidayID).Error
+func (r *HolidaysRepository) DeleteHoliday(tx *gorm.DB, holidayID int) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Where("id = ?", holidayID).Delete(&models.Holiday{}).Error
 }
 
 // UpdateHoliday - Actualiza una vacacion
 // --------------------------------------------------------------------
-func (r *HolidaysRepository) UpdateHoliday(holidayID string, holiday *models.Holiday) error {
-	return r.db.Model(&models.Holiday{}).Where("id = ?", holidayID).Updates(holiday).Error
+func (r *HolidaysRepository) UpdateHoliday(tx *gorm.DB, holiday *models.Holiday) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.Holiday{}).Where("id = ?", holiday.ID).
+		Select("start_date", "end_date", "status", "comment", "leave_type").
+		Updates(holiday).Error
+}
+
+// GetWorkerHolidaysBetween - Obtiene las vacaciones de un trabajador que se solapan con un rango de fechas
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) GetWorkerHolidaysBetween(tx *gorm.DB, workerID, from, to string) ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	if tx == nil {
+		tx = r.db
+	}
+	err := tx.Where("worker_id = ? AND start_date <= ? AND end_date >= ?", workerID, to, from).
+		Find(&holidays).Error
+	if err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// GetHolidaysForWorkers - Obtiene las vacaciones de varios trabajadores que se solapan con un rango de fechas
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) GetHolidaysForWorkers(workerIDs []string, from, to string) ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	err := r.db.Where("worker_id IN ? AND start_date <= ? AND end_date >= ?", workerIDs, to, from).
+		Order("start_date").
+		Find(&holidays).Error
+	if err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// LockHolidayByID - Busca una vacacion bloqueando la fila hasta el fin de la transaccion
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) LockHolidayByID(tx *gorm.DB, holidayID int) (*models.Holiday, error) {
+	var holiday models.Holiday
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", holidayID).First(&holiday).Error
+	if err != nil {
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+// LockFinishedHolidays - Busca las vacaciones en un estado que terminaron antes de una fecha
+// --------------------------------------------------------------------
+// Bloquea las filas hasta el fin de la transaccion.
+func (r *HolidaysRepository) LockFinishedHolidays(tx *gorm.DB, status, before string) ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status = ? AND end_date < ?", status, before).
+		Order("end_date").
+		Find(&holidays).Error
+	if err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// UpdateHolidayStatus - Cambia el estado de una vacacion
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) UpdateHolidayStatus(tx *gorm.DB, holidayID int, status string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.Holiday{}).Where("id = ?", holidayID).Update("status", status).Error
+}
+
+// GetHolidaysByWorker - Obtiene las vacaciones de un trabajador
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) GetHolidaysByWorker(workerID string) ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	if err := r.db.Where("worker_id = ?", workerID).Order("start_date DESC").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// GetHolidayRequests - Obtiene las vacaciones con el nombre del trabajador filtrando por tienda y estado (vacios = todas)
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) GetHolidayRequests(storeID, status string) ([]dtos.HolidayWithWorkerName, error) {
+	var holidays []dtos.HolidayWithWorkerName
+	query := r.db.Table("holidays").
+		Select("holidays.*, workers.name as worker_name, workers.last_name as worker_last_name").
+		Joins("left join workers on workers.id = holidays.worker_id")
+	if storeID != "" {
+		query = query.Where("workers.store_id = ?", storeID)
+	}
+	if status != "" {
+		query = query.Where("holidays.status = ?", status)
+	}
+	if err := query.Order("holidays.start_date").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// CountActiveHolidays - Cuenta las vacaciones en curso de un trabajador que se solapan con un rango de fechas
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) CountActiveHolidays(tx *gorm.DB, workerID, from, to string, excludeID int) (int64, error) {
+	var count int64
+	if tx == nil {
+		tx = r.db
+	}
+	err := tx.Model(&models.Holiday{}).
+		Where("worker_id = ? AND start_date <= ? AND end_date >= ? AND id <> ?", workerID, to, from, excludeID).
+		Where("status NOT IN ?", []string{models.HolidayStatusRejected, models.HolidayStatusCancelled}).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateDecision - Registra una decision sobre una vacacion
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) CreateDecision(tx *gorm.DB, decision *models.HolidayDecision) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Create(decision).Error
+	}
+	return r.db.Omit(clause.Associations).Create(decision).Error
+}
+
+// GetDecisions - Obtiene el historial de decisiones de una vacacion
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) GetDecisions(holidayID int) ([]models.HolidayDecision, error) {
+	var decisions []models.HolidayDecision
+	if err := r.db.Where("holiday_id = ?", holidayID).Order("created_at").Find(&decisions).Error; err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// DeleteDecisions - Elimina el historial de decisiones de una vacacion
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) DeleteDecisions(tx *gorm.DB, holidayID int) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Where("holiday_id = ?", holidayID).Delete(&models.HolidayDecision{}).Error
+}
+
+// FindEntitlement - Busca el derecho a vacaciones de un trabajador en un año
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) FindEntitlement(workerID string, year int) (*models.HolidayEntitlement, error) {
+	var entitlement models.HolidayEntitlement
+	if err := r.db.Where("worker_id = ? AND year = ?", workerID, year).First(&entitlement).Error; err != nil {
+		return nil, err
+	}
+	return &entitlement, nil
+}
+
+// SaveEntitlement - Crea o reemplaza el derecho a vacaciones de un trabajador en un año
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) SaveEntitlement(entitlement *models.HolidayEntitlement) error {
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "worker_id"}, {Name: "year"}},
+		DoUpdates: clause.AssignmentColumns([]string{"day_type", "days", "carry_over_days", "comment", "updated_at"}),
+	}).Create(entitlement).Error
+}
+
+// GetLeaveTypes - Obtiene los tipos de ausencia
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) GetLeaveTypes(activeOnly bool) ([]models.LeaveType, error) {
+	var leaveTypes []models.LeaveType
+	query := r.db.Order("name")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&leaveTypes).Error; err != nil {
+		return nil, err
+	}
+	return leaveTypes, nil
+}
+
+// FindLeaveType - Busca un tipo de ausencia por su codigo
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) FindLeaveType(code string) (*models.LeaveType, error) {
+	var leaveType models.LeaveType
+	if err := r.db.Where("code = ?", code).First(&leaveType).Error; err != nil {
+		return nil, err
+	}
+	return &leaveType, nil
+}
+
+// CreateLeaveType - Crea un tipo de ausencia
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) CreateLeaveType(leaveType *models.LeaveType) error {
+	return r.db.Create(leaveType).Error
+}
+
+// UpdateLeaveType - Actualiza todos los campos de un tipo de ausencia
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) UpdateLeaveType(leaveType *models.LeaveType) error {
+	return r.db.Save(leaveType).Error
+}
+
+// UpdateHolidayDocument - Guarda la ruta del justificante de una ausencia
+// --------------------------------------------------------------------
+func (r *HolidaysRepository) UpdateHolidayDocument(holidayID int, document string) error {
+	return r.db.Model(&models.Holiday{}).Where("id = ?", holidayID).Update("document", document).Error
 }
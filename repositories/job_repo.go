@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// SaveJob - Registra una tarea programada o actualiza su expresion cron
+// --------------------------------------------------------------------
+func (r *JobRepository) SaveJob(job *models.ScheduledJob) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"schedule"}),
+	}).Create(job).Error
+}
+
+// GetJobs - Obtiene las tareas programadas
+// --------------------------------------------------------------------
+func (r *JobRepository) GetJobs() ([]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	if err := r.db.Order("name").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// AcquireLock - Intenta bloquear una tarea para esta instancia
+// --------------------------------------------------------------------
+// Lo consigue si nadie la tiene bloqueada o el bloqueo ha caducado. Con slot
+// solo se bloquea si esa ejecucion programada no la ha lanzado ya otra
+// instancia. La actualizacion es atomica en Postgres.
+func (r *JobRepository) AcquireLock(name, instance string, until time.Time, slot *time.Time) (bool, error) {
+	query := r.db.Model(&models.ScheduledJob{}).
+		Where("name = ?", name).
+		Where("locked_until IS NULL OR locked_until < ?", time.Now())
+	updates := map[string]interface{}{
+		"locked_by":    instance,
+		"locked_until": until,
+	}
+	if slot != nil {
+		query = query.Where("last_run_at IS NULL OR last_run_at < ?", *slot)
+		updates["last_run_at"] = *slot
+	}
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseLock - Libera el bloqueo de una tarea si lo tiene esta instancia
+// --------------------------------------------------------------------
+func (r *JobRepository) ReleaseLock(name, instance string) error {
+	return r.db.Model(&models.ScheduledJob{}).
+		Where("name = ? AND locked_by = ?", name, instance).
+		Updates(map[string]interface{}{
+			"locked_by":    "",
+			"locked_until": nil,
+		}).Error
+}
+
+// CreateRun - Registra el inicio de una ejecucion
+// --------------------------------------------------------------------
+func (r *JobRepository) CreateRun(run *models.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+// FinishRun - Guarda el resultado de una ejecucion
+// --------------------------------------------------------------------
+func (r *JobRepository) FinishRun(run *models.JobRun) error {
+	return r.db.Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"message":     run.Message,
+		"finished_at": run.FinishedAt,
+	}).Error
+}
+
+// GetRuns - Obtiene las ultimas ejecuciones, de una tarea o de todas
+// --------------------------------------------------------------------
+func (r *JobRepository) GetRuns(name string, limit int) ([]models.JobRun, error) {
+	var runs []models.JobRun
+	query := r.db.Order("started_at DESC").Limit(limit)
+	if name != "" {
+		query = query.Where("job_name = ?", name)
+	}
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// CloseStaleRuns - Marca como fallidas las ejecuciones que quedaron a medias
+// --------------------------------------------------------------------
+// Una ejecucion queda en curso si el servidor se paro mientras corria.
+func (r *JobRepository) CloseStaleRuns(before time.Time) error {
+	return r.db.Model(&models.JobRun{}).
+		Where("status = ? AND started_at < ?", models.JobRunRunning, before).
+		Updates(map[string]interface{}{
+			"status":      models.JobRunFailed,
+			"message":     "ejecucion interrumpida",
+			"finished_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// CreateNotification - Crea una notificacion para un usuario
+// --------------------------------------------------------------------
+func (r *NotificationRepository) CreateNotification(tx *gorm.DB, notification *models.Notification) error {
+	if tx != nil {
+		return tx.Create(notification).Error
+	}
+	return r.db.Create(notification).Error
+}
+
+// GetUserNotifications - Obtiene las notificaciones de un usuario
+// --------------------------------------------------------------------
+func (r *NotificationRepository) GetUserNotifications(userID string, unreadOnly bool) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("created_at DESC").Limit(200).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// MarkAsRead - Marca como leida una notificacion del usuario
+// --------------------------------------------------------------------
+func (r *NotificationRepository) MarkAsRead(userID string, notificationID string) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", notificationID, userID).
+		Update("read_at", time.Now()).Error
+}
@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// NextOrderSequence - Siguiente numero de pedido de una tienda en un año
+// --------------------------------------------------------------------
+// Bloquea la fila de la tienda hasta el fin de la transaccion para que dos
+// pedidos simultaneos no reciban el mismo numero.
+func (r *OrderRepository) NextOrderSequence(tx *gorm.DB, storeID string, year int) (int, error) {
+	var store models.Store
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", storeID).First(&store).Error; err != nil {
+		return 0, err
+	}
+
+	var last int
+	err := tx.Model(&models.Order{}).
+		Select("COALESCE(MAX(sequence), 0)").
+		Where("store_id = ? AND EXTRACT(YEAR FROM date) = ?", storeID, year).
+		Scan(&last).Error
+	if err != nil {
+		return 0, err
+	}
+	return last + 1, nil
+}
+
+// CreateOrder - Crea un pedido con sus lineas
+// --------------------------------------------------------------------
+func (r *OrderRepository) CreateOrder(tx *gorm.DB, order *models.Order) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Omit("Store").Create(order).Error
+}
+
+// FindOrderByID - Busca un pedido con sus lineas
+// --------------------------------------------------------------------
+func (r *OrderRepository) FindOrderByID(orderID string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Select("orders.*, stores.name AS store_name").
+		Joins("LEFT JOIN stores ON stores.id = orders.store_id").
+		Where("orders.id = ?", orderID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// LockOrderByID - Busca un pedido bloqueando la fila hasta el fin de la transaccion
+// --------------------------------------------------------------------
+func (r *OrderRepository) LockOrderByID(tx *gorm.DB, orderID string) (*models.Order, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orderID).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrders - Obtiene los pedidos con sus lineas filtrando por tienda, estado y fechas (vacios = todos)
+// --------------------------------------------------------------------
+func (r *OrderRepository) GetOrders(storeID, status, from, to string) ([]models.Order, error) {
+	var orders []models.Order
+	query := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Select("orders.*, stores.name AS store_name").
+		Joins("LEFT JOIN stores ON stores.id = orders.store_id")
+	if storeID != "" {
+		query = query.Where("orders.store_id = ?", storeID)
+	}
+	if status != "" {
+		query = query.Where("orders.status = ?", status)
+	}
+	if from != "" {
+		query = query.Where("orders.date >= ?", from)
+	}
+	if to != "" {
+		query = query.Where("orders.date <= ?", to)
+	}
+	if err := query.Order("orders.date DESC, orders.sequence DESC").Limit(500).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetOrderLines - Obtiene las lineas de un pedido
+// --------------------------------------------------------------------
+func (r *OrderRepository) GetOrderLines(tx *gorm.DB, orderID string) ([]models.OrderLine, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var lines []models.OrderLine
+	if err := tx.Where("order_id = ?", orderID).Order("id").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// UpdateOrderStatus - Cambia el estado de un pedido
+// --------------------------------------------------------------------
+func (r *OrderRepository) UpdateOrderStatus(tx *gorm.DB, orderID, status string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.Order{}).Where("id = ?", orderID).Update("status", status).Error
+}
+
+// ReplaceOrderLines - Sustituye las lineas y el comentario de un pedido
+// --------------------------------------------------------------------
+func (r *OrderRepository) ReplaceOrderLines(tx *gorm.DB, orderID, comment string, lines []models.OrderLine) error {
+	if err := tx.Where("order_id = ?", orderID).Delete(&models.OrderLine{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&lines).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Order{}).Where("id = ?", orderID).Update("comment", comment).Error
+}
+
+// CreateStatusChange - Registra un cambio de estado de un pedido
+// --------------------------------------------------------------------
+func (r *OrderRepository) CreateStatusChange(tx *gorm.DB, change *models.OrderStatusChange) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Omit(clause.Associations).Create(change).Error
+}
+
+// GetStatusChanges - Obtiene el historial de estados de un pedido
+// --------------------------------------------------------------------
+func (r *OrderRepository) GetStatusChanges(orderID string) ([]models.OrderStatusChange, error) {
+	var changes []models.OrderStatusChange
+	if err := r.db.Where("order_id = ?", orderID).Order("created_at, id").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// UpdateLineReception - Guarda el acumulado recibido de una linea
+// --------------------------------------------------------------------
+func (r *OrderRepository) UpdateLineReception(tx *gorm.DB, line *models.OrderLine) error {
+	return tx.Model(&models.OrderLine{}).Where("id = ?", line.ID).Updates(map[string]interface{}{
+		"received":    line.Received,
+		"damaged":     line.Damaged,
+		"substituted": line.Substituted,
+		"short":       line.Short,
+	}).Error
+}
+
+// CreateReceipt - Crea una recepcion con sus lineas
+// --------------------------------------------------------------------
+func (r *OrderRepository) CreateReceipt(tx *gorm.DB, receipt *models.OrderReceipt) error {
+	return tx.Omit("Order").Create(receipt).Error
+}
+
+// FindReceiptByID - Busca una recepcion con sus lineas
+// --------------------------------------------------------------------
+func (r *OrderRepository) FindReceiptByID(receiptID string) (*models.OrderReceipt, error) {
+	var receipt models.OrderReceipt
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("id = ?", receiptID).First(&receipt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// GetReceipts - Obtiene las recepciones de un pedido
+// --------------------------------------------------------------------
+func (r *OrderRepository) GetReceipts(orderID string) ([]models.OrderReceipt, error) {
+	var receipts []models.OrderReceipt
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("order_id = ?", orderID).Order("created_at").Find(&receipts).Error
+	if err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// UpdateReceiptPhoto - Guarda la ruta de la foto de una recepcion
+// --------------------------------------------------------------------
+func (r *OrderRepository) UpdateReceiptPhoto(receiptID, path string) error {
+	return r.db.Model(&models.OrderReceipt{}).Where("id = ?", receiptID).Update("photo", path).Error
+}
+
+// GetDiscrepancies - Lineas recibidas con faltas, roturas o sustituciones entre dos fechas
+// --------------------------------------------------------------------
+// La tienda y el proveedor son opcionales.
+func (r *OrderRepository) GetDiscrepancies(from, to, storeID, supplierID string) ([]dtos.DiscrepancyLine, error) {
+	var lines []dtos.DiscrepancyLine
+	query := r.db.Table("order_receipt_lines AS rl").
+		Select(`r.id AS receipt_id, r.date, o.id AS order_id, o.number AS order_number,
+			o.store_id, stores.name AS store_name, COALESCE(sp.id, '') AS supplier_id, COALESCE(sp.name, '') AS supplier,
+			ol.sku, ol.product, ol.unit, ol.quantity AS ordered,
+			rl.received, rl.damaged, rl.short, rl.substitute_product, rl.substitute_quantity,
+			rl.comment, r.photo <> '' AS photo`).
+		Joins("JOIN order_receipts AS r ON r.id = rl.receipt_id").
+		Joins("JOIN order_lines AS ol ON ol.id = rl.order_line_id").
+		Joins("JOIN orders AS o ON o.id = r.order_id").
+		Joins("LEFT JOIN stores ON stores.id = o.store_id").
+		Joins("LEFT JOIN products AS p ON p.id = ol.product_id").
+		Joins("LEFT JOIN suppliers AS sp ON sp.id = p.supplier_id").
+		Where("r.date BETWEEN ? AND ?", from, to).
+		Where("rl.short > 0 OR rl.damaged > 0 OR rl.substitute_quantity > 0")
+	if storeID != "" {
+		query = query.Where("o.store_id = ?", storeID)
+	}
+	if supplierID != "" {
+		query = query.Where("p.supplier_id = ?", supplierID)
+	}
+	if err := query.Order("r.date, o.number, rl.id").Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// GetOrderedQuantities - Unidades pedidas por producto desde una fecha, sin borradores ni cancelados
+// --------------------------------------------------------------------
+func (r *OrderRepository) GetOrderedQuantities(storeID, from string) (map[string]int, error) {
+	var rows []struct {
+		ProductID string
+		Quantity  int
+	}
+	err := r.db.Table("order_lines").
+		Select("order_lines.product_id, SUM(order_lines.quantity) AS quantity").
+		Joins("JOIN orders ON orders.id = order_lines.order_id").
+		Where("orders.store_id = ? AND orders.date >= ?", storeID, from).
+		Where("orders.status NOT IN ?", []string{models.OrderStatusDraft, models.OrderStatusCancelled}).
+		Where("order_lines.product_id IS NOT NULL").
+		Group("order_lines.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	quantities := make(map[string]int, len(rows))
+	for _, row := range rows {
+		quantities[row.ProductID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// GetPendingQuantities - Unidades por producto de pedidos en curso que aun no han llegado a la tienda
+// --------------------------------------------------------------------
+func (r *OrderRepository) GetPendingQuantities(storeID string) (map[string]int, error) {
+	var rows []struct {
+		ProductID string
+		Quantity  int
+	}
+	err := r.db.Table("order_lines").
+		Select(`order_lines.product_id, SUM(GREATEST(order_lines.quantity - order_lines.received
+			- order_lines.damaged - order_lines.substituted - order_lines.short, 0)) AS quantity`).
+		Joins("JOIN orders ON orders.id = order_lines.order_id").
+		Where("orders.store_id = ?", storeID).
+		Where("orders.status IN ?", []string{models.OrderStatusSubmitted, models.OrderStatusApproved,
+			models.OrderStatusOrdered, models.OrderStatusPartiallyReceived}).
+		Where("order_lines.product_id IS NOT NULL").
+		Group("order_lines.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	quantities := make(map[string]int, len(rows))
+	for _, row := range rows {
+		quantities[row.ProductID] = row.Quantity
+	}
+	return quantities, nil
+}
+
+// LockOrdersByStatus - Obtiene los pedidos en un estado con sus lineas, bloqueandolos
+// --------------------------------------------------------------------
+func (r *OrderRepository) LockOrdersByStatus(tx *gorm.DB, status string) ([]models.Order, error) {
+	var orders []models.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "orders"}}).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("status = ?", status).
+		Order("date, sequence").
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductRepository struct {
+	db *gorm.DB
+}
+
+func NewProductRepository(db *gorm.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// CreateProduct - Crea un producto del catalogo
+// --------------------------------------------------------------------
+func (r *ProductRepository) CreateProduct(tx *gorm.DB, product *models.Product) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Create(product).Error
+}
+
+// UpdateProduct - Guarda todos los campos de un producto
+// --------------------------------------------------------------------
+func (r *ProductRepository) UpdateProduct(tx *gorm.DB, product *models.Product) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Save(product).Error
+}
+
+// FindProductByID - Busca un producto por su ID
+// --------------------------------------------------------------------
+func (r *ProductRepository) FindProductByID(productID string) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.Where("id = ?", productID).First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// FindProductBySKU - Busca un producto por su SKU
+// --------------------------------------------------------------------
+func (r *ProductRepository) FindProductBySKU(sku string) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.Where("sku = ?", sku).First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetProductsBySKU - Obtiene los productos con alguno de los SKU indicados
+// --------------------------------------------------------------------
+func (r *ProductRepository) GetProductsBySKU(skus []string) ([]models.Product, error) {
+	var products []models.Product
+	if err := r.db.Where("sku IN ?", skus).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetProducts - Obtiene el catalogo filtrando por categoria (vacia = todas)
+// --------------------------------------------------------------------
+func (r *ProductRepository) GetProducts(category string, activeOnly bool) ([]models.Product, error) {
+	var products []models.Product
+	query := r.db.Order("category, name")
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetStoreProducts - Obtiene los productos que puede pedir una tienda
+// --------------------------------------------------------------------
+func (r *ProductRepository) GetStoreProducts(storeID string, activeOnly bool) ([]models.Product, error) {
+	var products []models.Product
+	query := r.db.Joins("JOIN store_products ON store_products.product_id = products.id").
+		Where("store_products.store_id = ?", storeID).
+		Order("products.category, products.name")
+	if activeOnly {
+		query = query.Where("products.active = ?", true)
+	}
+	if err := query.Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetAllowedProducts - Obtiene, de entre los indicados, los productos activos que puede pedir una tienda
+// --------------------------------------------------------------------
+func (r *ProductRepository) GetAllowedProducts(storeID string, productIDs []string) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Joins("JOIN store_products ON store_products.product_id = products.id").
+		Where("store_products.store_id = ? AND products.id IN ? AND products.active = ?", storeID, productIDs, true).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// SetStoreProducts - Sustituye la lista de productos de una tienda
+// --------------------------------------------------------------------
+func (r *ProductRepository) SetStoreProducts(tx *gorm.DB, storeID string, productIDs []string) error {
+	if err := tx.Where("store_id = ?", storeID).Delete(&models.StoreProduct{}).Error; err != nil {
+		return err
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+	storeProducts := make([]models.StoreProduct, 0, len(productIDs))
+	for _, productID := range productIDs {
+		storeProducts = append(storeProducts, models.StoreProduct{StoreID: storeID, ProductID: productID})
+	}
+	return tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&storeProducts).Error
+}
+
+// AddStoreProducts - Añade productos a la lista de una tienda
+// --------------------------------------------------------------------
+func (r *ProductRepository) AddStoreProducts(storeID string, productIDs []string) error {
+	storeProducts := make([]models.StoreProduct, 0, len(productIDs))
+	for _, productID := range productIDs {
+		storeProducts = append(storeProducts, models.StoreProduct{StoreID: storeID, ProductID: productID})
+	}
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&storeProducts).Error
+}
+
+// RemoveStoreProduct - Quita un producto de la lista de una tienda
+// --------------------------------------------------------------------
+func (r *ProductRepository) RemoveStoreProduct(storeID, productID string) error {
+	return r.db.Where("store_id = ? AND product_id = ?", storeID, productID).Delete(&models.StoreProduct{}).Error
+}
+
+// CountProducts - Cuenta los productos con alguno de los IDs indicados
+// --------------------------------------------------------------------
+func (r *ProductRepository) CountProducts(productIDs []string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Product{}).Where("id IN ?", productIDs).Count(&count).Error
+	return count, err
+}
+
+// SaveReorderParameter - Crea o sustituye los parametros de reposicion de un producto
+// --------------------------------------------------------------------
+func (r *ProductRepository) SaveReorderParameter(parameter *models.ReorderParameter) error {
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "store_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"min_level", "max_level", "lead_time_days", "review_days", "updated_at"}),
+	}).Create(parameter).Error
+}
+
+// DeleteReorderParameter - Borra los parametros de reposicion de un producto
+// --------------------------------------------------------------------
+func (r *ProductRepository) DeleteReorderParameter(productID, storeID string) (int64, error) {
+	result := r.db.Where("product_id = ? AND store_id = ?", productID, storeID).Delete(&models.ReorderParameter{})
+	return result.RowsAffected, result.Error
+}
+
+// GetReorderParameters - Obtiene los parametros de reposicion de un producto o de todos
+// --------------------------------------------------------------------
+func (r *ProductRepository) GetReorderParameters(productID string) ([]models.ReorderParameter, error) {
+	var parameters []models.ReorderParameter
+	query := r.db.Order("product_id, store_id")
+	if productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if err := query.Find(&parameters).Error; err != nil {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+// GetStoreReorderParameters - Parametros generales y de una tienda
+// --------------------------------------------------------------------
+func (r *ProductRepository) GetStoreReorderParameters(storeID string) ([]models.ReorderParameter, error) {
+	var parameters []models.ReorderParameter
+	if err := r.db.Where("store_id IN ?", []string{"", storeID}).Find(&parameters).Error; err != nil {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+// GetProductsByIDs - Obtiene los productos con alguno de los IDs indicados, con su proveedor
+// --------------------------------------------------------------------
+func (r *ProductRepository) GetProductsByIDs(productIDs []string) ([]models.Product, error) {
+	var products []models.Product
+	if err := r.db.Preload("Supplier").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
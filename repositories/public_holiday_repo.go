@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PublicHolidayRepository struct {
+	db *gorm.DB
+}
+
+func NewPublicHolidayRepository(db *gorm.DB) *PublicHolidayRepository {
+	return &PublicHolidayRepository{db: db}
+}
+
+// CreateCalendar - Crea un nuevo calendario de festivos
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) CreateCalendar(calendar *models.PublicHolidayCalendar) error {
+	return r.db.Create(calendar).Error
+}
+
+// FindCalendarByID - Busca un calendario de festivos por su ID
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) FindCalendarByID(calendarID string) (*models.PublicHolidayCalendar, error) {
+	var calendar models.PublicHolidayCalendar
+	if err := r.db.Where("id = ?", calendarID).First(&calendar).Error; err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// GetCalendars - Obtiene todos los calendarios de festivos
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) GetCalendars() ([]models.PublicHolidayCalendar, error) {
+	var calendars []models.PublicHolidayCalendar
+	if err := r.db.Order("scope, name").Find(&calendars).Error; err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
+
+// DeleteCalendar - Elimina un calendario con sus festivos y asignaciones
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) DeleteCalendar(tx *gorm.DB, calendarID string) error {
+	if err := tx.Where("calendar_id = ?", calendarID).Delete(&models.PublicHoliday{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("calendar_id = ?", calendarID).Delete(&models.StoreHolidayCalendar{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id = ?", calendarID).Delete(&models.PublicHolidayCalendar{}).Error
+}
+
+// SaveHolidays - Crea los festivos de un calendario, actualizando el nombre de los que ya existen
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) SaveHolidays(tx *gorm.DB, holidays []models.PublicHoliday) error {
+	if tx == nil {
+		tx = r.db
+	}
+	if len(holidays) == 0 {
+		return nil
+	}
+	return tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "calendar_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name"}),
+	}).Create(&holidays).Error
+}
+
+// DeleteHoliday - Elimina un festivo de un calendario
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) DeleteHoliday(calendarID, holidayID string) error {
+	return r.db.Where("calendar_id = ? AND id = ?", calendarID, holidayID).Delete(&models.PublicHoliday{}).Error
+}
+
+// GetCalendarHolidays - Obtiene los festivos de un calendario
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) GetCalendarHolidays(calendarID string) ([]models.PublicHoliday, error) {
+	var holidays []models.PublicHoliday
+	if err := r.db.Where("calendar_id = ?", calendarID).Order("date").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// AssignCalendar - Asigna un calendario de festivos a una tienda
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) AssignCalendar(storeID, calendarID string) error {
+	assignment := &models.StoreHolidayCalendar{StoreID: storeID, CalendarID: calendarID}
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(assignment).Error
+}
+
+// UnassignCalendar - Quita un calendario de festivos de una tienda
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) UnassignCalendar(storeID, calendarID string) error {
+	return r.db.Where("store_id = ? AND calendar_id = ?", storeID, calendarID).Delete(&models.StoreHolidayCalendar{}).Error
+}
+
+// GetStoreCalendars - Obtiene los calendarios que se aplican a una tienda
+// --------------------------------------------------------------------
+// Los nacionales se aplican a todas, los locales a las de su ciudad y el
+// resto solo si estan asignados.
+func (r *PublicHolidayRepository) GetStoreCalendars(storeID, city string) ([]models.PublicHolidayCalendar, error) {
+	var calendars []models.PublicHolidayCalendar
+	err := r.storeCalendarsQuery(r.db.Model(&models.PublicHolidayCalendar{}), storeID, city).
+		Order("scope, name").
+		Find(&calendars).Error
+	if err != nil {
+		return nil, err
+	}
+	return calendars, nil
+}
+
+// GetStoreHolidays - Obtiene los festivos de una tienda en un rango de fechas
+// --------------------------------------------------------------------
+func (r *PublicHolidayRepository) GetStoreHolidays(storeID, city, from, to string) ([]models.PublicHoliday, error) {
+	var holidays []models.PublicHoliday
+	query := r.db.Model(&models.PublicHoliday{}).
+		Select("public_holidays.*").
+		Joins("JOIN public_holiday_calendars ON public_holiday_calendars.id = public_holidays.calendar_id").
+		Where("public_holidays.date BETWEEN ? AND ?", from, to)
+	err := r.storeCalendarsQuery(query, storeID, city).
+		Order("public_holidays.date").
+		Find(&holidays).Error
+	if err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// storeCalendarsQuery - Filtra los calendarios que se aplican a una tienda
+func (r *PublicHolidayRepository) storeCalendarsQuery(query *gorm.DB, storeID, city string) *gorm.DB {
+	assigned := r.db.Model(&models.StoreHolidayCalendar{}).Select("calendar_id").Where("store_id = ?", storeID)
+	return query.Where(
+		"public_holiday_calendars.scope = ? OR (public_holiday_calendars.scope = ? AND public_holiday_calendars.city = ?) OR public_holiday_calendars.id IN (?)",
+		models.PublicHolidayScopeNational, models.PublicHolidayScopeLocal, city, assigned)
+}
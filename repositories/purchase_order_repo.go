@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewPurchaseOrderRepository(db *gorm.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db}
+}
+
+// NextPurchaseSequence - Siguiente numero de pedido a proveedor de un año
+// --------------------------------------------------------------------
+// Toma un bloqueo de la transaccion para que dos consolidaciones a la vez
+// no repitan numero.
+func (r *PurchaseOrderRepository) NextPurchaseSequence(tx *gorm.DB, year int) (int, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('purchase_orders'))").Error; err != nil {
+		return 0, err
+	}
+	var last int
+	err := tx.Model(&models.PurchaseOrder{}).
+		Select("COALESCE(MAX(sequence), 0)").
+		Where("EXTRACT(YEAR FROM created_at) = ?", year).
+		Scan(&last).Error
+	if err != nil {
+		return 0, err
+	}
+	return last + 1, nil
+}
+
+// FindOpenPurchaseOrder - Busca el pedido abierto de un proveedor para una fecha de entrega
+// --------------------------------------------------------------------
+func (r *PurchaseOrderRepository) FindOpenPurchaseOrder(tx *gorm.DB, supplierID, deliveryDate string) (*models.PurchaseOrder, error) {
+	var purchase models.PurchaseOrder
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("supplier_id = ? AND delivery_date = ? AND status = ?", supplierID, deliveryDate, models.PurchaseOrderOpen).
+		First(&purchase).Error
+	if err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// CreatePurchaseOrder - Crea un pedido a proveedor sin lineas
+// --------------------------------------------------------------------
+func (r *PurchaseOrderRepository) CreatePurchaseOrder(tx *gorm.DB, purchase *models.PurchaseOrder) error {
+	return tx.Omit(clause.Associations).Create(purchase).Error
+}
+
+// CreateLines - Añade lineas a un pedido a proveedor
+// --------------------------------------------------------------------
+func (r *PurchaseOrderRepository) CreateLines(tx *gorm.DB, lines []models.PurchaseOrderLine) error {
+	return tx.Omit(clause.Associations).Create(&lines).Error
+}
+
+// UpdateTotal - Recalcula el importe de un pedido a proveedor con sus lineas
+// --------------------------------------------------------------------
+func (r *PurchaseOrderRepository) UpdateTotal(tx *gorm.DB, purchaseID string) (float64, error) {
+	var total float64
+	err := tx.Model(&models.PurchaseOrderLine{}).
+		Select("COALESCE(SUM(quantity * unit_price), 0)").
+		Where("purchase_order_id = ?", purchaseID).
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Model(&models.PurchaseOrder{}).Where("id = ?", purchaseID).Update("total", total).Error
+	return total, err
+}
+
+// FindPurchaseOrderByID - Busca un pedido a proveedor con sus lineas, tiendas y pedidos de origen
+// --------------------------------------------------------------------
+func (r *PurchaseOrderRepository) FindPurchaseOrderByID(purchaseID string) (*models.PurchaseOrder, error) {
+	var purchase models.PurchaseOrder
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Select("purchase_order_lines.*, stores.name AS store_name, orders.number AS order_number").
+			Joins("LEFT JOIN stores ON stores.id = purchase_order_lines.store_id").
+			Joins("LEFT JOIN orders ON orders.id = purchase_order_lines.order_id").
+			Order("purchase_order_lines.product, stores.name")
+	}).
+		Select("purchase_orders.*, suppliers.name AS supplier_name").
+		Joins("LEFT JOIN suppliers ON suppliers.id = purchase_orders.supplier_id").
+		Where("purchase_orders.id = ?", purchaseID).
+		First(&purchase).Error
+	if err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// GetPurchaseOrders - Obtiene los pedidos a proveedor sin lineas filtrando por proveedor, estado y entrega
+// --------------------------------------------------------------------
+func (r *PurchaseOrderRepository) GetPurchaseOrders(supplierID, status, from, to string) ([]models.PurchaseOrder, error) {
+	var purchases []models.PurchaseOrder
+	query := r.db.Select("purchase_orders.*, suppliers.name AS supplier_name").
+		Joins("LEFT JOIN suppliers ON suppliers.id = purchase_orders.supplier_id")
+	if supplierID != "" {
+		query = query.Where("purchase_orders.supplier_id = ?", supplierID)
+	}
+	if status != "" {
+		query = query.Where("purchase_orders.status = ?", status)
+	}
+	if from != "" {
+		query = query.Where("purchase_orders.delivery_date >= ?", from)
+	}
+	if to != "" {
+		query = query.Where("purchase_orders.delivery_date <= ?", to)
+	}
+	if err := query.Order("purchase_orders.delivery_date DESC, purchase_orders.number DESC").Limit(500).Find(&purchases).Error; err != nil {
+		return nil, err
+	}
+	return purchases, nil
+}
+
+// MarkSent - Marca un pedido abierto como enviado al proveedor
+// --------------------------------------------------------------------
+func (r *PurchaseOrderRepository) MarkSent(purchaseID string) (int64, error) {
+	result := r.db.Model(&models.PurchaseOrder{}).
+		Where("id = ? AND status = ?", purchaseID, models.PurchaseOrderOpen).
+		Updates(map[string]interface{}{"status": models.PurchaseOrderSent, "sent_at": gorm.Expr("NOW()")})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// CreatePeriod - Crea un nuevo periodo de planificacion
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) CreatePeriod(period *models.SchedulePeriod) error {
+	return r.db.Create(period).Error
+}
+
+// FindPeriodByID - Busca un periodo por su ID
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) FindPeriodByID(tx *gorm.DB, periodID string) (*models.SchedulePeriod, error) {
+	var period models.SchedulePeriod
+	if tx == nil {
+		tx = r.db
+	}
+	if err := tx.Where("id = ?", periodID).First(&period).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// LockPeriodByID - Busca un periodo bloqueando la fila hasta el fin de la transaccion
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) LockPeriodByID(tx *gorm.DB, periodID string) (*models.SchedulePeriod, error) {
+	var period models.SchedulePeriod
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", periodID).First(&period).Error
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// FindPeriodForDate - Busca el periodo de una tienda que contiene una fecha
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) FindPeriodForDate(storeID, date string) (*models.SchedulePeriod, error) {
+	var period models.SchedulePeriod
+	err := r.db.Where("store_id = ? AND start_date <= ? AND end_date >= ?", storeID, date, date).
+		First(&period).Error
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// CountOverlappingPeriods - Cuenta los periodos de una tienda que se solapan con un rango de fechas
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) CountOverlappingPeriods(storeID, from, to string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SchedulePeriod{}).
+		Where("store_id = ? AND start_date <= ? AND end_date >= ?", storeID, to, from).
+		Count(&count).Error
+	return count, err
+}
+
+// GetPeriods - Obtiene los periodos de una tienda (vacio = todas)
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) GetPeriods(storeID string) ([]models.SchedulePeriod, error) {
+	var periods []models.SchedulePeriod
+	query := r.db.Order("start_date DESC")
+	if storeID != "" {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// UpdatePeriod - Guarda los cambios de un periodo
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) UpdatePeriod(tx *gorm.DB, period *models.SchedulePeriod) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Save(period).Error
+	}
+	return r.db.Omit(clause.Associations).Save(period).Error
+}
+
+// CreateChange - Registra un cambio sobre un horario publicado
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) CreateChange(tx *gorm.DB, change *models.ScheduleChange) error {
+	if tx != nil {
+		return tx.Create(change).Error
+	}
+	return r.db.Create(change).Error
+}
+
+// GetPeriodChanges - Obtiene los cambios registrados en un periodo
+// --------------------------------------------------------------------
+func (r *ScheduleRepository) GetPeriodChanges(periodID string) ([]models.ScheduleChange, error) {
+	var changes []models.ScheduleChange
+	if err := r.db.Where("period_id = ?", periodID).Order("created_at").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetWorkerChanges - Obtiene los cambios que afectan a los turnos de un trabajador
+// --------------------------------------------------------------------
+// Un cambio afecta al trabajador si el turno era suyo antes o lo es despues.
+func (r *ScheduleRepository) GetWorkerChanges(workerID, since string) ([]models.ScheduleChange, error) {
+	var changes []models.ScheduleChange
+	query := r.db.Where("(before ->> 'worker_id' = ? OR after ->> 'worker_id' = ?)", workerID, workerID)
+	if since != "" {
+		query = query.Where("created_at >= ?", since)
+	}
+	if err := query.Order("created_at DESC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShiftClaimRepository struct {
+	db *gorm.DB
+}
+
+func NewShiftClaimRepository(db *gorm.DB) *ShiftClaimRepository {
+	return &ShiftClaimRepository{db: db}
+}
+
+// CreateClaim - Crea una reclamacion de turno abierto
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) CreateClaim(tx *gorm.DB, claim *models.ShiftClaim) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Create(claim).Error
+	}
+	return r.db.Omit(clause.Associations).Create(claim).Error
+}
+
+// LockClaimByID - Busca una reclamacion bloqueando la fila hasta el fin de la transaccion
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) LockClaimByID(tx *gorm.DB, claimID string) (*models.ShiftClaim, error) {
+	var claim models.ShiftClaim
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", claimID).First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// UpdateClaim - Guarda los cambios de una reclamacion
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) UpdateClaim(tx *gorm.DB, claim *models.ShiftClaim) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Save(claim).Error
+	}
+	return r.db.Omit(clause.Associations).Save(claim).Error
+}
+
+// UpdateClaimDecision - Guarda el estado y la decision de una reclamacion
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) UpdateClaimDecision(tx *gorm.DB, claim *models.ShiftClaim) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.ShiftClaim{}).Where("id = ?", claim.ID).
+		Select("status", "decided_by_id", "decided_at", "decision_note").
+		Updates(claim).Error
+}
+
+// CountPendingClaims - Cuenta las reclamaciones pendientes de un trabajador sobre un turno
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) CountPendingClaims(tx *gorm.DB, shiftID int, workerID string) (int64, error) {
+	var count int64
+	if tx == nil {
+		tx = r.db
+	}
+	err := tx.Model(&models.ShiftClaim{}).
+		Where("shift_id = ? AND worker_id = ? AND status = ?", shiftID, workerID, models.ClaimStatusPending).
+		Count(&count).Error
+	return count, err
+}
+
+// RejectOtherClaims - Rechaza las reclamaciones pendientes de un turno salvo la indicada
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) RejectOtherClaims(tx *gorm.DB, shiftID int, exceptClaimID, decidedByID, note string) error {
+	return tx.Model(&models.ShiftClaim{}).
+		Where("shift_id = ? AND id <> ? AND status = ?", shiftID, exceptClaimID, models.ClaimStatusPending).
+		Updates(map[string]interface{}{
+			"status":        models.ClaimStatusRejected,
+			"decided_by_id": decidedByID,
+			"decided_at":    time.Now(),
+			"decision_note": note,
+		}).Error
+}
+
+// GetClaimsByWorker - Obtiene las reclamaciones de un trabajador
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) GetClaimsByWorker(workerID string) ([]models.ShiftClaim, error) {
+	var claims []models.ShiftClaim
+	err := r.db.Preload("Shift").
+		Where("worker_id = ?", workerID).
+		Order("created_at DESC").
+		Find(&claims).Error
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// GetClaims - Obtiene las reclamaciones filtrando por tienda y estado (vacios = todas)
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) GetClaims(storeID, status string) ([]models.ShiftClaim, error) {
+	var claims []models.ShiftClaim
+	query := r.db.Preload("Shift").Joins("JOIN work_shifts ON work_shifts.id = shift_claims.shift_id")
+	if storeID != "" {
+		query = query.Where("work_shifts.store = ?", storeID)
+	}
+	if status != "" {
+		query = query.Where("shift_claims.status = ?", status)
+	}
+	if err := query.Order("shift_claims.created_at").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// DeleteClaimsByShift - Elimina las reclamaciones de un turno
+// --------------------------------------------------------------------
+func (r *ShiftClaimRepository) DeleteClaimsByShift(tx *gorm.DB, shiftID int) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Where("shift_id = ?", shiftID).Delete(&models.ShiftClaim{}).Error
+}
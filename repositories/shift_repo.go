@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShiftRepository struct {
+	db *gorm.DB
+}
+
+func NewShiftRepository(db *gorm.DB) *ShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+// FindShiftByID - Busca un turno por su ID
+// --------------------------------------------------------------------
+func (r *ShiftRepository) FindShiftByID(tx *gorm.DB, shiftID int) (*models.WorkShift, error) {
+	var shift models.WorkShift
+	if tx == nil {
+		tx = r.db
+	}
+	if err := tx.First(&shift, shiftID).Error; err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// LockShiftByID - Busca un turno por su ID bloqueando la fila hasta el fin de la transaccion
+// --------------------------------------------------------------------
+func (r *ShiftRepository) LockShiftByID(tx *gorm.DB, shiftID int) (*models.WorkShift, error) {
+	var shift models.WorkShift
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, shiftID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// GetWorkerShiftsBetween - Obtiene los turnos de un trabajador entre dos fechas
+// --------------------------------------------------------------------
+func (r *ShiftRepository) GetWorkerShiftsBetween(tx *gorm.DB, workerID, from, to string, excludeIDs ...int) ([]models.WorkShift, error) {
+	var shifts []models.WorkShift
+	if tx == nil {
+		tx = r.db
+	}
+	query := tx.Where("worker_id = ? AND work_date BETWEEN ? AND ?", workerID, from, to)
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+	if err := query.Order("work_date, start_interval").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+// UpdateShiftWorker - Reasigna un turno a otro trabajador
+// --------------------------------------------------------------------
+func (r *ShiftRepository) UpdateShiftWorker(tx *gorm.DB, shiftID int, workerID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.WorkShift{}).Where("id = ?", shiftID).Update("worker_id", workerID).Error
+}
+
+// GetShifts - Obtiene los turnos publicados entre dos fechas filtrando por tienda y trabajador (vacios = todos)
+// --------------------------------------------------------------------
+// Los turnos de periodos en borrador no se incluyen; los turnos sin periodo
+// son anteriores a la planificacion por periodos y se consideran publicados.
+func (r *ShiftRepository) GetShifts(from, to, storeID, workerID string) ([]models.WorkShift, error) {
+	var shifts []models.WorkShift
+	query := r.db.Where("work_date BETWEEN ? AND ?", from, to).
+		Where("(period_id IS NULL OR period_id IN (?))",
+			r.db.Model(&models.SchedulePeriod{}).Select("id").Where("status = ?", models.PeriodStatusPublished))
+	if storeID != "" {
+		query = query.Where("store = ?", storeID)
+	}
+	if workerID != "" {
+		query = query.Where("worker_id = ?", workerID)
+	}
+	if err := query.Order("work_date, start_interval").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+// GetPeriodShifts - Obtiene todos los turnos de un periodo de planificacion
+// --------------------------------------------------------------------
+func (r *ShiftRepository) GetPeriodShifts(tx *gorm.DB, periodID string) ([]models.WorkShift, error) {
+	var shifts []models.WorkShift
+	if tx == nil {
+		tx = r.db
+	}
+	err := tx.Where("period_id = ?", periodID).
+		Order("work_date, start_interval").
+		Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+// CreateShift - Crea un nuevo turno
+// --------------------------------------------------------------------
+func (r *ShiftRepository) CreateShift(tx *gorm.DB, shift *models.WorkShift) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Create(shift).Error
+	}
+	return r.db.Omit(clause.Associations).Create(shift).Error
+}
+
+// UpdateShift - Guarda los cambios de un turno
+// --------------------------------------------------------------------
+func (r *ShiftRepository) UpdateShift(tx *gorm.DB, shift *models.WorkShift) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Save(shift).Error
+	}
+	return r.db.Omit(clause.Associations).Save(shift).Error
+}
+
+// DeleteShift - Elimina un turno
+// --------------------------------------------------------------------
+func (r *ShiftRepository) DeleteShift(tx *gorm.DB, shiftID int) error {
+	if tx != nil {
+		return tx.Delete(&models.WorkShift{}, shiftID).Error
+	}
+	return r.db.Delete(&models.WorkShift{}, shiftID).Error
+}
+
+// GetOpenShifts - Obtiene los turnos abiertos publicados de varias tiendas desde una fecha
+// --------------------------------------------------------------------
+func (r *ShiftRepository) GetOpenShifts(storeIDs []string, from string) ([]models.WorkShift, error) {
+	var shifts []models.WorkShift
+	err := r.db.Where("worker_id IS NULL AND store IN ? AND work_date >= ?", storeIDs, from).
+		Where("(period_id IS NULL OR period_id IN (?))",
+			r.db.Model(&models.SchedulePeriod{}).Select("id").Where("status = ?", models.PeriodStatusPublished)).
+		Order("work_date, start_interval").
+		Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShiftSwapRepository struct {
+	db *gorm.DB
+}
+
+func NewShiftSwapRepository(db *gorm.DB) *ShiftSwapRepository {
+	return &ShiftSwapRepository{db: db}
+}
+
+// CreateSwap - Crea una nueva solicitud de cambio de turno
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) CreateSwap(tx *gorm.DB, swap *models.ShiftSwap) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Create(swap).Error
+}
+
+// LockSwapByID - Busca una solicitud bloqueando la fila hasta el fin de la transaccion
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) LockSwapByID(tx *gorm.DB, swapID string) (*models.ShiftSwap, error) {
+	var swap models.ShiftSwap
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", swapID).First(&swap).Error
+	if err != nil {
+		return nil, err
+	}
+	return &swap, nil
+}
+
+// UpdateSwap - Guarda los cambios de una solicitud
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) UpdateSwap(tx *gorm.DB, swap *models.ShiftSwap) error {
+	if tx != nil {
+		return tx.Omit(clause.Associations).Save(swap).Error
+	}
+	return r.db.Omit(clause.Associations).Save(swap).Error
+}
+
+// UpdateSwapDecision - Guarda el estado y la decision de una solicitud
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) UpdateSwapDecision(tx *gorm.DB, swap *models.ShiftSwap) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.ShiftSwap{}).Where("id = ?", swap.ID).
+		Select("status", "decided_by_id", "decided_at", "decision_note").
+		Updates(swap).Error
+}
+
+// GetSwapsByWorker - Obtiene las solicitudes en las que participa un trabajador
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) GetSwapsByWorker(workerID string) ([]models.ShiftSwap, error) {
+	var swaps []models.ShiftSwap
+	err := r.db.Preload("Shift").
+		Where("requester_id = ? OR target_worker_id = ? OR accepted_by_id = ?", workerID, workerID, workerID).
+		Order("created_at DESC").
+		Find(&swaps).Error
+	if err != nil {
+		return nil, err
+	}
+	return swaps, nil
+}
+
+// GetOpenSwaps - Obtiene las ofertas abiertas de una tienda y un cargo
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) GetOpenSwaps(storeID, cargo, excludeWorkerID string) ([]models.ShiftSwap, error) {
+	var swaps []models.ShiftSwap
+	err := r.db.Preload("Shift").
+		Joins("JOIN work_shifts ON work_shifts.id = shift_swaps.shift_id").
+		Joins("JOIN workers ON workers.id = shift_swaps.requester_id").
+		Where("shift_swaps.type = ? AND shift_swaps.status = ?", models.SwapTypeOpen, models.SwapStatusPending).
+		Where("work_shifts.store = ? AND workers.cargo = ? AND shift_swaps.requester_id <> ?", storeID, cargo, excludeWorkerID).
+		Order("work_shifts.work_date").
+		Find(&swaps).Error
+	if err != nil {
+		return nil, err
+	}
+	return swaps, nil
+}
+
+// GetSwaps - Obtiene las solicitudes filtrando por tienda y estado (vacios = todas)
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) GetSwaps(storeID, status string) ([]models.ShiftSwap, error) {
+	var swaps []models.ShiftSwap
+	query := r.db.Preload("Shift").Joins("JOIN work_shifts ON work_shifts.id = shift_swaps.shift_id")
+	if storeID != "" {
+		query = query.Where("work_shifts.store = ?", storeID)
+	}
+	if status != "" {
+		query = query.Where("shift_swaps.status = ?", status)
+	}
+	if err := query.Order("shift_swaps.created_at DESC").Find(&swaps).Error; err != nil {
+		return nil, err
+	}
+	return swaps, nil
+}
+
+// CountActiveSwapsByShift - Cuenta las solicitudes pendientes o aceptadas de un turno
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) CountActiveSwapsByShift(tx *gorm.DB, shiftID int) (int64, error) {
+	var count int64
+	if tx == nil {
+		tx = r.db
+	}
+	err := tx.Model(&models.ShiftSwap{}).
+		Where("shift_id = ? AND status IN ?", shiftID, []string{models.SwapStatusPending, models.SwapStatusAccepted}).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteSwapsByShift - Elimina las solicitudes de cambio de un turno
+// --------------------------------------------------------------------
+func (r *ShiftSwapRepository) DeleteSwapsByShift(tx *gorm.DB, shiftID int) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Where("shift_id = ?", shiftID).Delete(&models.ShiftSwap{}).Error
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockRepository struct {
+	db *gorm.DB
+}
+
+func NewStockRepository(db *gorm.DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+// LockStockLevel - Obtiene el stock de un producto en una tienda bloqueando la fila
+// --------------------------------------------------------------------
+// Si el producto aun no tiene stock en la tienda se crea a cero.
+func (r *StockRepository) LockStockLevel(tx *gorm.DB, storeID, productID string) (*models.StockLevel, error) {
+	level := models.StockLevel{StoreID: storeID, ProductID: productID}
+	if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&level).Error; err != nil {
+		return nil, err
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("store_id = ? AND product_id = ?", storeID, productID).
+		First(&level).Error
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// SaveStockLevel - Guarda el stock de un producto en una tienda
+// --------------------------------------------------------------------
+func (r *StockRepository) SaveStockLevel(tx *gorm.DB, level *models.StockLevel) error {
+	return tx.Model(&models.StockLevel{}).
+		Where("store_id = ? AND product_id = ?", level.StoreID, level.ProductID).
+		Updates(map[string]interface{}{
+			"quantity":   level.Quantity,
+			"counted_at": level.CountedAt,
+			"updated_at": gorm.Expr("NOW()"),
+		}).Error
+}
+
+// CreateMovement - Registra un movimiento de stock
+// --------------------------------------------------------------------
+func (r *StockRepository) CreateMovement(tx *gorm.DB, movement *models.StockMovement) error {
+	return tx.Omit(clause.Associations).Create(movement).Error
+}
+
+// GetStockLevels - Obtiene el stock actual de una tienda con los datos del producto
+// --------------------------------------------------------------------
+func (r *StockRepository) GetStockLevels(storeID string) ([]models.StockLevel, error) {
+	var levels []models.StockLevel
+	err := r.db.Select("stock_levels.*, products.sku, products.name AS product, products.unit, products.category").
+		Joins("JOIN products ON products.id = stock_levels.product_id").
+		Where("stock_levels.store_id = ?", storeID).
+		Order("products.category, products.name").
+		Find(&levels).Error
+	if err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// GetMovements - Obtiene los movimientos de una tienda entre dos fechas, de un producto o de todos
+// --------------------------------------------------------------------
+func (r *StockRepository) GetMovements(storeID, productID, from, to string) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	query := r.db.Select("stock_movements.*, products.sku, products.name AS product").
+		Joins("JOIN products ON products.id = stock_movements.product_id").
+		Where("stock_movements.store_id = ?", storeID).
+		Where("stock_movements.created_at >= ? AND stock_movements.created_at < (?::date + 1)", from, to)
+	if productID != "" {
+		query = query.Where("stock_movements.product_id = ?", productID)
+	}
+	if err := query.Order("stock_movements.created_at DESC, stock_movements.id DESC").Limit(1000).Find(&movements).Error; err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// CreateCount - Crea un recuento con sus lineas
+// --------------------------------------------------------------------
+func (r *StockRepository) CreateCount(tx *gorm.DB, count *models.StockCount) error {
+	return tx.Omit("Store").Create(count).Error
+}
+
+// FindCountByID - Busca un recuento con sus lineas
+// --------------------------------------------------------------------
+func (r *StockRepository) FindCountByID(countID string) (*models.StockCount, error) {
+	var count models.StockCount
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Select("stock_count_lines.*, products.sku, products.name AS product").
+			Joins("JOIN products ON products.id = stock_count_lines.product_id").
+			Order("products.name")
+	}).Where("id = ?", countID).First(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+// GetCounts - Obtiene los recuentos de una tienda entre dos fechas, sin sus lineas
+// --------------------------------------------------------------------
+func (r *StockRepository) GetCounts(storeID, from, to string) ([]models.StockCount, error) {
+	var counts []models.StockCount
+	err := r.db.Where("store_id = ? AND date BETWEEN ? AND ?", storeID, from, to).
+		Order("date DESC, created_at DESC").
+		Find(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// GetCountVariances - Suma las diferencias de los recuentos por tienda y producto entre dos fechas
+// --------------------------------------------------------------------
+// La tienda es opcional. La merma del periodo se suma aparte para poder
+// comparar lo que se tiro con lo que falto al contar.
+func (r *StockRepository) GetCountVariances(storeID, from, to string) ([]dtos.StockVarianceRow, error) {
+	var rows []dtos.StockVarianceRow
+	query := r.db.Table("stock_count_lines AS l").
+		Select(`c.store_id, stores.name AS store_name, l.product_id, products.sku,
+			products.name AS product, products.unit, COUNT(*) AS counts,
+			SUM(l.expected) AS expected, SUM(l.counted) AS counted, SUM(l.variance) AS variance,
+			SUM(ABS(l.variance)) AS absolute_variance,
+			COALESCE((SELECT -SUM(m.quantity) FROM stock_movements AS m
+				WHERE m.store_id = c.store_id AND m.product_id = l.product_id AND m.type = ?
+				AND m.created_at >= ? AND m.created_at < (?::date + 1)), 0) AS waste`,
+			models.StockMovementWaste, from, to).
+		Joins("JOIN stock_counts AS c ON c.id = l.count_id").
+		Joins("JOIN stores ON stores.id = c.store_id").
+		Joins("JOIN products ON products.id = l.product_id").
+		Where("c.date BETWEEN ? AND ?", from, to)
+	if storeID != "" {
+		query = query.Where("c.store_id = ?", storeID)
+	}
+	err := query.Group("c.store_id, stores.name, l.product_id, products.sku, products.name, products.unit").
+		Order("absolute_variance DESC, stores.name, products.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetCountPoints - Cantidades contadas en una tienda desde un instante, por producto y en orden
+// --------------------------------------------------------------------
+func (r *StockRepository) GetCountPoints(storeID string, since time.Time) ([]dtos.StockCountPoint, error) {
+	var points []dtos.StockCountPoint
+	err := r.db.Table("stock_count_lines AS l").
+		Select("l.product_id, l.counted, c.created_at AS counted_at").
+		Joins("JOIN stock_counts AS c ON c.id = l.count_id").
+		Where("c.store_id = ? AND c.created_at >= ?", storeID, since).
+		Order("l.product_id, c.created_at").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// GetMovementsSince - Movimientos de un tipo en una tienda desde un instante
+// --------------------------------------------------------------------
+func (r *StockRepository) GetMovementsSince(storeID, movementType string, since time.Time) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	err := r.db.Where("store_id = ? AND type = ? AND created_at >= ?", storeID, movementType, since).
+		Order("created_at").
+		Find(&movements).Error
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
@@ -2,9 +2,11 @@ package repositories
 
 import (
 	"errors"
+	"time"
 
 	"github.com/javimartzs/worker-hub-backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StoreRepository struct {
@@ -26,11 +28,11 @@ func (r *StoreRepository) CreateStore(tx *gorm.DB, store *models.Store) error {
 	return r.db.Create(store).Error
 }
 
-// FindStoreByName - Busca una tienda por su nombre
+// FindStoreByName - Busca una tienda por su nombre, tambien entre las archivadas
 // --------------------------------------------------------------------
 func (r *StoreRepository) FindStoreByName(name string) (*models.Store, error) {
 	var store models.Store
-	err := r.db.Where("name = ?", name).First(&store).Error
+	err := r.db.Unscoped().Where("name = ?", name).First(&store).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
@@ -54,7 +56,17 @@ func (r *StoreRepository) FindStoreByID(storeID string) (*models.Store, error) {
 	return &store, nil
 }
 
-// DeleteStore - Elimina una tienda
+// LockStore - Obtiene una tienda bloqueandola hasta el final de la transaccion
+// --------------------------------------------------------------------
+func (r *StoreRepository) LockStore(tx *gorm.DB, storeID string) (*models.Store, error) {
+	var store models.Store
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", storeID).First(&store).Error; err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+// DeleteStore - Archiva una tienda
 // --------------------------------------------------------------------
 func (r *StoreRepository) DeleteStore(tx *gorm.DB, storeID string) error {
 	if tx != nil {
@@ -78,3 +90,68 @@ func (r *StoreRepository) GetAllStores() ([]models.Store, error) {
 func (r *StoreRepository) UpdateStore(storeID string, store *models.Store) error {
 	return r.db.Model(&models.Store{}).Where("id = ?", storeID).Updates(store).Error
 }
+
+// FindStoreByUserID - Busca una tienda por el ID de su usuario
+// --------------------------------------------------------------------
+func (r *StoreRepository) FindStoreByUserID(userID string) (*models.Store, error) {
+	var store models.Store
+	if err := r.db.Where("user_id = ?", userID).First(&store).Error; err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+// GetStoresByCity - Obtiene las tiendas de una ciudad
+// --------------------------------------------------------------------
+func (r *StoreRepository) GetStoresByCity(city string) ([]models.Store, error) {
+	var stores []models.Store
+	if err := r.db.Where("city = ?", city).Find(&stores).Error; err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+// GetArchivedStores - Obtiene las tiendas archivadas
+// --------------------------------------------------------------------
+func (r *StoreRepository) GetArchivedStores() ([]models.Store, error) {
+	var stores []models.Store
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&stores).Error; err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+// FindArchivedStore - Busca una tienda archivada por su ID
+// --------------------------------------------------------------------
+func (r *StoreRepository) FindArchivedStore(storeID string) (*models.Store, error) {
+	var store models.Store
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", storeID).First(&store).Error; err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+// RestoreStore - Saca una tienda del archivo
+// --------------------------------------------------------------------
+func (r *StoreRepository) RestoreStore(tx *gorm.DB, storeID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Unscoped().Model(&models.Store{}).Where("id = ?", storeID).Update("deleted_at", nil).Error
+}
+
+// GetStoresArchivedBefore - Obtiene las tiendas archivadas antes de una fecha
+// --------------------------------------------------------------------
+func (r *StoreRepository) GetStoresArchivedBefore(before time.Time) ([]models.Store, error) {
+	var stores []models.Store
+	if err := r.db.Unscoped().Where("deleted_at < ?", before).Find(&stores).Error; err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+// PurgeStore - Borra definitivamente una tienda archivada
+// --------------------------------------------------------------------
+func (r *StoreRepository) PurgeStore(tx *gorm.DB, storeID string) error {
+	return tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", storeID).Delete(&models.Store{}).Error
+}
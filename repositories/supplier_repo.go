@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+)
+
+type SupplierRepository struct {
+	db *gorm.DB
+}
+
+func NewSupplierRepository(db *gorm.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+// CreateSupplier - Crea un proveedor
+// --------------------------------------------------------------------
+func (r *SupplierRepository) CreateSupplier(supplier *models.Supplier) error {
+	return r.db.Create(supplier).Error
+}
+
+// UpdateSupplier - Guarda todos los campos de un proveedor
+// --------------------------------------------------------------------
+func (r *SupplierRepository) UpdateSupplier(supplier *models.Supplier) error {
+	return r.db.Save(supplier).Error
+}
+
+// FindSupplierByID - Busca un proveedor por su ID
+// --------------------------------------------------------------------
+func (r *SupplierRepository) FindSupplierByID(supplierID string) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := r.db.Where("id = ?", supplierID).First(&supplier).Error; err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// FindSupplierByName - Busca un proveedor por su nombre sin distinguir mayusculas
+// --------------------------------------------------------------------
+func (r *SupplierRepository) FindSupplierByName(name string) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := r.db.Where("LOWER(name) = LOWER(?)", name).First(&supplier).Error; err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// GetSuppliers - Obtiene los proveedores, todos o solo los activos
+// --------------------------------------------------------------------
+func (r *SupplierRepository) GetSuppliers(activeOnly bool) ([]models.Supplier, error) {
+	var suppliers []models.Supplier
+	query := r.db.Order("name")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+// GetSupplierProducts - Obtiene los productos que sirve un proveedor
+// --------------------------------------------------------------------
+func (r *SupplierRepository) GetSupplierProducts(supplierID string) ([]models.Product, error) {
+	var products []models.Product
+	if err := r.db.Where("supplier_id = ?", supplierID).Order("category, name").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// AssignProducts - Asigna unos productos a un proveedor
+// --------------------------------------------------------------------
+func (r *SupplierRepository) AssignProducts(supplierID string, productIDs []string) error {
+	return r.db.Model(&models.Product{}).Where("id IN ?", productIDs).Update("supplier_id", supplierID).Error
+}
+
+// UnassignProduct - Quita un producto de un proveedor
+// --------------------------------------------------------------------
+func (r *SupplierRepository) UnassignProduct(supplierID, productID string) (int64, error) {
+	result := r.db.Model(&models.Product{}).
+		Where("id = ? AND supplier_id = ?", productID, supplierID).
+		Update("supplier_id", nil)
+	return result.RowsAffected, result.Error
+}
@@ -18,3 +18,20 @@ func NewTimelogRepository(db *gorm.DB) *TimelogRepository {
 func (r *TimelogRepository) CreateTimelog(timelog *models.Timelog) error {
 	return r.db.Create(timelog).Error
 }
+
+// GetTimelogs - Obtiene los registros horarios en [from, to) filtrando por tienda y trabajador (vacios = todos)
+// --------------------------------------------------------------------
+func (r *TimelogRepository) GetTimelogs(from, to string, storeID, workerID string) ([]models.Timelog, error) {
+	var timelogs []models.Timelog
+	query := r.db.Where("timelog >= ? AND timelog < ?", from, to)
+	if storeID != "" {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if workerID != "" {
+		query = query.Where("worker_id = ?", workerID)
+	}
+	if err := query.Order("timelog").Find(&timelogs).Error; err != nil {
+		return nil, err
+	}
+	return timelogs, nil
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/javimartzs/worker-hub-backend/models"
 	"github.com/javimartzs/worker-hub-backend/models/dtos"
 	"gorm.io/gorm"
@@ -43,6 +45,7 @@ func (r *UserRepository) GetAllUsers() ([]dtos.UserList, error) {
 	var users []dtos.UserList
 	err := r.db.Table("users").
 		Select("users.id, users.username, users.role").
+		Where("users.deleted_at IS NULL").
 		Find(&users).Error
 	if err != nil {
 		return nil, err
@@ -50,7 +53,7 @@ func (r *UserRepository) GetAllUsers() ([]dtos.UserList, error) {
 	return users, nil
 }
 
-// DeleteUser - Elimina un usuario
+// DeleteUser - Archiva un usuario
 // --------------------------------------------------------------------
 func (r *UserRepository) DeleteUser(tx *gorm.DB, userID string) error {
 	if tx != nil {
@@ -58,3 +61,76 @@ func (r *UserRepository) DeleteUser(tx *gorm.DB, userID string) error {
 	}
 	return r.db.Where("id = ?", userID).Delete(&models.User{}).Error
 }
+
+// GetUsersByRole - Obtiene los usuarios con un rol
+// --------------------------------------------------------------------
+func (r *UserRepository) GetUsersByRole(role string) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Where("role = ?", role).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// IsUsernameTaken - Indica si un nombre de usuario esta en uso, tambien por un usuario archivado
+// --------------------------------------------------------------------
+func (r *UserRepository) IsUsernameTaken(tx *gorm.DB, username string) (bool, error) {
+	var count int64
+	if tx == nil {
+		tx = r.db
+	}
+	if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetArchivedUsers - Obtiene los usuarios archivados
+// --------------------------------------------------------------------
+func (r *UserRepository) GetArchivedUsers() ([]dtos.UserList, error) {
+	var users []dtos.UserList
+	err := r.db.Table("users").
+		Select("users.id, users.username, users.role").
+		Where("users.deleted_at IS NOT NULL").
+		Order("users.deleted_at DESC").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindArchivedUser - Busca un usuario archivado por su ID
+// --------------------------------------------------------------------
+func (r *UserRepository) FindArchivedUser(userID string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RestoreUser - Saca un usuario del archivo
+// --------------------------------------------------------------------
+func (r *UserRepository) RestoreUser(tx *gorm.DB, userID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", nil).Error
+}
+
+// GetUsersArchivedBefore - Obtiene los usuarios archivados antes de una fecha
+// --------------------------------------------------------------------
+func (r *UserRepository) GetUsersArchivedBefore(before time.Time) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Unscoped().Where("deleted_at < ?", before).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// PurgeUser - Borra definitivamente un usuario archivado
+// --------------------------------------------------------------------
+func (r *UserRepository) PurgeUser(tx *gorm.DB, userID string) error {
+	return tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", userID).Delete(&models.User{}).Error
+}
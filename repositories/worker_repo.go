@@ -1,10 +1,15 @@
 package repositories
 
 import (
+	"database/sql"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type WorkerRepository struct {
@@ -24,11 +29,11 @@ func (r *WorkerRepository) CreateWorker(tx *gorm.DB, worker *models.Worker) erro
 	return r.db.Create(worker).Error
 }
 
-// FindWorkerByNie - Busca un trabajador por su NIE
+// FindWorkerByNie - Busca un trabajador por su NIE, tambien entre los archivados
 // --------------------------------------------------------------------
 func (r *WorkerRepository) FindWorkerByNie(nie string) (*models.Worker, error) {
 	var worker models.Worker
-	err := r.db.Where("nie = ?", nie).First(&worker).Error
+	err := r.db.Unscoped().Where("nie = ?", nie).First(&worker).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err // No se encontró el trabajador
@@ -52,7 +57,7 @@ func (r *WorkerRepository) FindWorkerByID(workerID string) (*models.Worker, erro
 	return &worker, nil
 }
 
-// DeleteWorker - Elimina un trabajador
+// DeleteWorker - Archiva un trabajador
 // --------------------------------------------------------------------
 func (r *WorkerRepository) DeleteWorker(tx *gorm.DB, workerID string) error {
 	if tx != nil {
@@ -71,8 +76,161 @@ func (r *WorkerRepository) GetAllWorkers() ([]models.Worker, error) {
 	return workers, nil
 }
 
+// Columnas por las que se puede ordenar el listado de trabajadores
+var workerSortColumns = map[string]string{
+	"name":      "workers.name",
+	"last_name": "workers.last_name",
+	"email":     "workers.email",
+	"nie":       "workers.nie",
+	"cargo":     "workers.cargo",
+	"status":    "workers.status",
+	"hire_date": "workers.hire_date",
+	"store":     "stores.name",
+}
+
+// IsWorkerSortField - Indica si se puede ordenar el listado de trabajadores por un campo
+func IsWorkerSortField(field string) bool {
+	_, ok := workerSortColumns[field]
+	return ok
+}
+
+// SearchWorkers - Obtiene una pagina de trabajadores con su tienda y el total que cumple los filtros
+// --------------------------------------------------------------------
+// La busqueda no distingue mayusculas y tambien encuentra "nombre apellidos".
+// El orden se desempata por nombre, apellidos e ID para que las paginas no
+// se solapen.
+func (r *WorkerRepository) SearchWorkers(filter dtos.WorkerQuery) ([]models.Worker, int64, error) {
+	query := r.db.Model(&models.Worker{}).
+		Joins("LEFT JOIN stores ON stores.id = workers.store_id")
+	if filter.Search != "" {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Search) + "%"
+		query = query.Where(`(workers.name ILIKE @p OR workers.last_name ILIKE @p OR workers.email ILIKE @p
+			OR workers.nie ILIKE @p OR workers.name || ' ' || workers.last_name ILIKE @p)`, sql.Named("p", pattern))
+	}
+	if filter.StoreID != "" {
+		query = query.Where("workers.store_id = ?", filter.StoreID)
+	}
+	if filter.Cargo != "" {
+		query = query.Where("workers.cargo = ?", filter.Cargo)
+	}
+	if filter.Status != "" {
+		query = query.Where("workers.status = ?", filter.Status)
+	}
+	if filter.Prueba != "" {
+		query = query.Where("workers.prueba = ?", filter.Prueba)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := workerSortColumns[filter.Sort]
+	if order == "" {
+		order = workerSortColumns["name"]
+	}
+	if filter.Order == "desc" {
+		order += " DESC NULLS LAST"
+	}
+	var workers []models.Worker
+	err := query.Preload("Store").
+		Order(order + ", workers.name, workers.last_name, workers.id").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&workers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return workers, total, nil
+}
+
 // UpdateWorker - Actualiza un trabajador
 // --------------------------------------------------------------------
 func (r *WorkerRepository) UpdateWorker(workerID string, worker *models.Worker) error {
 	return r.db.Model(&models.Worker{}).Where("id = ?", workerID).Updates(worker).Error
 }
+
+// FindWorkerByUserID - Busca un trabajador por el ID de su usuario
+// --------------------------------------------------------------------
+func (r *WorkerRepository) FindWorkerByUserID(userID string) (*models.Worker, error) {
+	var worker models.Worker
+	if err := r.db.Where("user_id = ?", userID).First(&worker).Error; err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
+
+// GetWorkersByStore - Obtiene los trabajadores asignados a una tienda
+// --------------------------------------------------------------------
+func (r *WorkerRepository) GetWorkersByStore(storeID string) ([]models.Worker, error) {
+	var workers []models.Worker
+	if err := r.db.Where("store_id = ?", storeID).Find(&workers).Error; err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// UpdateWorkerStatus - Guarda el estado, el periodo de prueba y la fecha de alta derivados de los contratos
+// --------------------------------------------------------------------
+func (r *WorkerRepository) UpdateWorkerStatus(tx *gorm.DB, workerID, status, prueba string, hireDate *string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&models.Worker{}).Where("id = ?", workerID).
+		Updates(map[string]interface{}{"status": status, "prueba": prueba, "hire_date": hireDate}).Error
+}
+
+// LockWorker - Obtiene un trabajador bloqueandolo hasta el final de la transaccion
+// --------------------------------------------------------------------
+func (r *WorkerRepository) LockWorker(tx *gorm.DB, workerID string) (*models.Worker, error) {
+	var worker models.Worker
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", workerID).First(&worker).Error; err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
+
+// GetArchivedWorkers - Obtiene los trabajadores archivados
+// --------------------------------------------------------------------
+func (r *WorkerRepository) GetArchivedWorkers() ([]models.Worker, error) {
+	var workers []models.Worker
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&workers).Error; err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// FindArchivedWorker - Busca un trabajador archivado por su ID
+// --------------------------------------------------------------------
+func (r *WorkerRepository) FindArchivedWorker(workerID string) (*models.Worker, error) {
+	var worker models.Worker
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", workerID).First(&worker).Error; err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
+
+// RestoreWorker - Saca un trabajador del archivo
+// --------------------------------------------------------------------
+func (r *WorkerRepository) RestoreWorker(tx *gorm.DB, workerID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Unscoped().Model(&models.Worker{}).Where("id = ?", workerID).Update("deleted_at", nil).Error
+}
+
+// GetWorkersArchivedBefore - Obtiene los trabajadores archivados antes de una fecha
+// --------------------------------------------------------------------
+func (r *WorkerRepository) GetWorkersArchivedBefore(before time.Time) ([]models.Worker, error) {
+	var workers []models.Worker
+	if err := r.db.Unscoped().Where("deleted_at < ?", before).Find(&workers).Error; err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// PurgeWorker - Borra definitivamente un trabajador archivado
+// --------------------------------------------------------------------
+func (r *WorkerRepository) PurgeWorker(tx *gorm.DB, workerID string) error {
+	return tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", workerID).Delete(&models.Worker{}).Error
+}
@@ -3,12 +3,26 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/javimartzs/worker-hub-backend/handlers"
+	"github.com/javimartzs/worker-hub-backend/middlewares"
 )
 
 func SetupRoutes(
 	router *gin.Engine,
 	adminHandler *handlers.AdminHandler,
 	authHandler *handlers.AuthHandler,
+	shiftHandler *handlers.ShiftHandler,
+	reportHandler *handlers.ReportHandler,
+	calendarHandler *handlers.CalendarHandler,
+	notificationHandler *handlers.NotificationHandler,
+	holidayHandler *handlers.HolidayHandler,
+	publicHolidayHandler *handlers.PublicHolidayHandler,
+	jobHandler *handlers.JobHandler,
+	orderHandler *handlers.OrderHandler,
+	productHandler *handlers.ProductHandler,
+	stockHandler *handlers.StockHandler,
+	supplierHandler *handlers.SupplierHandler,
+	contractHandler *handlers.ContractHandler,
+	assignmentHandler *handlers.AssignmentHandler,
 ) {
 	apiGroup := router.Group("/api") // Grupo de rutas para la API
 	{
@@ -16,8 +30,13 @@ func SetupRoutes(
 		authGroup := apiGroup.Group("/auth")
 		{
 			authGroup.POST("/admin", authHandler.LoginAdmin)
+			authGroup.POST("/store", authHandler.LoginStore)
+			authGroup.POST("/worker", authHandler.LoginWorker)
 		}
 
+		// Calendarios iCalendar, protegidos por el token secreto de la URL
+		apiGroup.GET("/calendar/:token", calendarHandler.GetFeed)
+
 		// Rutas para el administrador
 		adminGroup := apiGroup.Group("/admin")
 
@@ -35,7 +54,6 @@ func SetupRoutes(
 			// Rutas de vacaciones
 			adminGroup.POST("/holidays/create", adminHandler.CreateHoliday)
 			adminGroup.GET("/holidays", adminHandler.GetAllHolidays)
-			adminGroup.POST("/holidays/update/:id", adminHandler.UpdateHoliday)
 			adminGroup.POST("/holidays/delete/:id", adminHandler.DeleteHoliday)
 			adminGroup.GET("/holidays/workers", adminHandler.GetHolidaysWithWorker)
 			// Rutas de usuarios
@@ -45,5 +63,244 @@ func SetupRoutes(
 			// Rutas de registros horarios
 			adminGroup.POST("/timelog/create", adminHandler.CreateTimelog)
 		}
+
+		// Rutas del administrador que necesitan conocer al usuario autenticado
+		adminAuthGroup := adminGroup.Group("", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("admin"))
+		{
+			// Rutas de planificacion de turnos
+			adminAuthGroup.POST("/schedule-periods/create", shiftHandler.CreatePeriod)
+			adminAuthGroup.GET("/schedule-periods", shiftHandler.GetPeriods)
+			adminAuthGroup.GET("/schedule-periods/:id", shiftHandler.GetPeriod)
+			adminAuthGroup.GET("/schedule-periods/:id/changes", shiftHandler.GetPeriodChanges)
+			adminAuthGroup.POST("/schedule-periods/publish/:id", shiftHandler.PublishPeriod)
+			adminAuthGroup.POST("/shifts/create", shiftHandler.CreateShift)
+			adminAuthGroup.POST("/shifts/update/:id", shiftHandler.UpdateShift)
+			adminAuthGroup.POST("/shifts/delete/:id", shiftHandler.DeleteShift)
+			// Rutas de cambios de turno
+			adminAuthGroup.GET("/shift-swaps", shiftHandler.GetSwaps)
+			adminAuthGroup.POST("/shift-swaps/approve/:id", shiftHandler.ApproveSwap)
+			adminAuthGroup.POST("/shift-swaps/reject/:id", shiftHandler.RejectSwap)
+			// Rutas de turnos abiertos
+			adminAuthGroup.GET("/shift-claims", shiftHandler.GetClaims)
+			adminAuthGroup.POST("/shift-claims/approve/:id", shiftHandler.ApproveClaim)
+			adminAuthGroup.POST("/shift-claims/reject/:id", shiftHandler.RejectClaim)
+			// Rutas de contratos
+			adminAuthGroup.GET("/workers/:id/contracts", contractHandler.GetWorkerContracts)
+			adminAuthGroup.POST("/workers/:id/contracts/create", contractHandler.CreateContract)
+			adminAuthGroup.POST("/contracts/update/:id", contractHandler.UpdateContract)
+			adminAuthGroup.POST("/contracts/end/:id", contractHandler.EndContract)
+			adminAuthGroup.POST("/contracts/trial-outcome/:id", contractHandler.RecordTrialOutcome)
+			adminAuthGroup.GET("/trial-periods", contractHandler.GetUpcomingTrials)
+			adminAuthGroup.GET("/trial-period-rules", contractHandler.GetTrialRules)
+			adminAuthGroup.POST("/trial-period-rules", contractHandler.SaveTrialRule)
+			adminAuthGroup.POST("/trial-period-rules/delete/:id", contractHandler.DeleteTrialRule)
+			// Rutas de tiendas de los trabajadores
+			adminAuthGroup.POST("/workers/import", adminHandler.ImportWorkers)
+			adminAuthGroup.POST("/stores/import", adminHandler.ImportStores)
+			adminAuthGroup.GET("/workers/archived", adminHandler.GetArchivedWorkers)
+			adminAuthGroup.POST("/workers/restore/:id", adminHandler.RestoreWorker)
+			adminAuthGroup.GET("/stores/archived", adminHandler.GetArchivedStores)
+			adminAuthGroup.POST("/stores/restore/:id", adminHandler.RestoreStore)
+			adminAuthGroup.GET("/users/archived", adminHandler.GetArchivedUsers)
+			adminAuthGroup.POST("/users/restore/:id", adminHandler.RestoreUser)
+
+			adminAuthGroup.GET("/workers/:id/store-assignments", assignmentHandler.GetWorkerAssignments)
+			adminAuthGroup.POST("/workers/:id/transfer", assignmentHandler.TransferWorker)
+			adminAuthGroup.POST("/workers/:id/secondary-stores", assignmentHandler.AddSecondaryStore)
+			adminAuthGroup.POST("/store-assignments/end/:id", assignmentHandler.EndAssignment)
+			adminAuthGroup.POST("/store-assignments/cancel/:id", assignmentHandler.CancelAssignment)
+			// Rutas de solicitudes de vacaciones
+			adminAuthGroup.GET("/holiday-requests", holidayHandler.GetHolidayRequests)
+			adminAuthGroup.GET("/holiday-requests/:id/decisions", holidayHandler.GetHolidayDecisions)
+			adminAuthGroup.POST("/holiday-requests/approve/:id", holidayHandler.ApproveHoliday)
+			adminAuthGroup.POST("/holiday-requests/reject/:id", holidayHandler.RejectHoliday)
+			adminAuthGroup.POST("/holidays/update/:id", holidayHandler.UpdateHoliday)
+			adminAuthGroup.GET("/holiday-requests/:id/document", holidayHandler.GetDocument)
+			adminAuthGroup.GET("/holiday-requests/:id/timeline", holidayHandler.GetHolidayTimeline)
+			adminAuthGroup.POST("/holidays/check", holidayHandler.CheckHoliday)
+			adminAuthGroup.GET("/leave-timeline", holidayHandler.GetLeaveTimeline)
+			adminAuthGroup.POST("/holiday-requests/:id/document", holidayHandler.AttachDocument)
+			adminAuthGroup.GET("/workers/:id/holiday-balance", holidayHandler.GetHolidayBalance)
+			adminAuthGroup.POST("/workers/:id/holiday-entitlement", holidayHandler.SetHolidayEntitlement)
+			// Rutas de tipos de ausencia
+			adminAuthGroup.GET("/leave-types", holidayHandler.GetLeaveTypes)
+			adminAuthGroup.POST("/leave-types/create", holidayHandler.CreateLeaveType)
+			adminAuthGroup.POST("/leave-types/update/:code", holidayHandler.UpdateLeaveType)
+			// Rutas de reglas de cobertura
+			adminAuthGroup.GET("/stores/:id/leave-rules", holidayHandler.GetCoverageRules)
+			adminAuthGroup.POST("/stores/:id/leave-rules/create", holidayHandler.CreateLeaveRule)
+			adminAuthGroup.POST("/leave-rules/delete/:id", holidayHandler.DeleteLeaveRule)
+			adminAuthGroup.POST("/stores/:id/blackouts/create", holidayHandler.CreateBlackout)
+			adminAuthGroup.POST("/blackouts/delete/:id", holidayHandler.DeleteBlackout)
+			// Rutas de festivos
+			adminAuthGroup.POST("/public-holiday-calendars/create", publicHolidayHandler.CreateCalendar)
+			adminAuthGroup.GET("/public-holiday-calendars", publicHolidayHandler.GetCalendars)
+			adminAuthGroup.GET("/public-holiday-calendars/:id", publicHolidayHandler.GetCalendar)
+			adminAuthGroup.POST("/public-holiday-calendars/delete/:id", publicHolidayHandler.DeleteCalendar)
+			adminAuthGroup.POST("/public-holiday-calendars/:id/holidays/create", publicHolidayHandler.AddHoliday)
+			adminAuthGroup.POST("/public-holiday-calendars/:id/holidays/delete/:holiday_id", publicHolidayHandler.DeleteHoliday)
+			adminAuthGroup.POST("/public-holiday-calendars/:id/import", publicHolidayHandler.ImportHolidays)
+			adminAuthGroup.GET("/stores/:id/public-holiday-calendars", publicHolidayHandler.GetStoreCalendars)
+			adminAuthGroup.POST("/stores/:id/public-holiday-calendars/assign/:calendar_id", publicHolidayHandler.AssignCalendar)
+			adminAuthGroup.POST("/stores/:id/public-holiday-calendars/unassign/:calendar_id", publicHolidayHandler.UnassignCalendar)
+			adminAuthGroup.GET("/public-holidays", publicHolidayHandler.GetStorePublicHolidays)
+			// Rutas de informes
+			adminAuthGroup.GET("/reports/attendance", reportHandler.AttendanceReport)
+			// Rutas de calendarios
+			adminAuthGroup.GET("/calendar-feeds", calendarHandler.GetFeeds)
+			adminAuthGroup.POST("/calendar-feeds/create", calendarHandler.CreateFeed)
+			adminAuthGroup.POST("/calendar-feeds/revoke/:id", calendarHandler.RevokeFeed)
+			// Rutas de notificaciones
+			adminAuthGroup.GET("/notifications", notificationHandler.GetNotifications)
+			adminAuthGroup.POST("/notifications/read/:id", notificationHandler.MarkAsRead)
+			// Rutas del catalogo de productos
+			adminAuthGroup.GET("/products", productHandler.GetProducts)
+			adminAuthGroup.POST("/products/create", productHandler.CreateProduct)
+			adminAuthGroup.POST("/products/update/:id", productHandler.UpdateProduct)
+			adminAuthGroup.POST("/products/import", productHandler.ImportProducts)
+			adminAuthGroup.GET("/stores/:id/products", productHandler.GetStoreProducts)
+			adminAuthGroup.POST("/stores/:id/products", productHandler.SetStoreProducts)
+			adminAuthGroup.POST("/stores/:id/products/remove/:product_id", productHandler.RemoveStoreProduct)
+			adminAuthGroup.GET("/reorder-parameters", productHandler.GetReorderParameters)
+			adminAuthGroup.POST("/reorder-parameters", productHandler.SaveReorderParameter)
+			adminAuthGroup.POST("/reorder-parameters/delete/:product_id", productHandler.DeleteReorderParameter)
+			// Rutas de proveedores y pedidos a proveedor
+			adminAuthGroup.GET("/suppliers", supplierHandler.GetSuppliers)
+			adminAuthGroup.GET("/suppliers/:id", supplierHandler.GetSupplier)
+			adminAuthGroup.POST("/suppliers/create", supplierHandler.CreateSupplier)
+			adminAuthGroup.POST("/suppliers/update/:id", supplierHandler.UpdateSupplier)
+			adminAuthGroup.POST("/suppliers/:id/products", supplierHandler.AssignProducts)
+			adminAuthGroup.POST("/suppliers/:id/products/remove/:product_id", supplierHandler.UnassignProduct)
+			adminAuthGroup.POST("/purchase-orders/consolidate", orderHandler.ConsolidateOrders)
+			adminAuthGroup.GET("/purchase-orders", orderHandler.GetPurchaseOrders)
+			adminAuthGroup.GET("/purchase-orders/:id", orderHandler.GetPurchaseOrder)
+			adminAuthGroup.GET("/purchase-orders/:id/export", orderHandler.ExportPurchaseOrder)
+			adminAuthGroup.POST("/purchase-orders/send/:id", orderHandler.SendPurchaseOrder)
+			// Rutas de stock
+			adminAuthGroup.GET("/stores/:id/stock", stockHandler.GetStock)
+			adminAuthGroup.GET("/stores/:id/stock/history", stockHandler.GetStockHistory)
+			adminAuthGroup.GET("/stock/counts", stockHandler.GetCounts)
+			adminAuthGroup.GET("/stock/counts/:id", stockHandler.GetCount)
+			adminAuthGroup.GET("/reports/stock-variance", stockHandler.GetVarianceReport)
+			// Rutas de pedidos
+			adminAuthGroup.GET("/orders", orderHandler.GetOrders)
+			adminAuthGroup.GET("/orders/:id", orderHandler.GetOrder)
+			adminAuthGroup.GET("/orders/:id/history", orderHandler.GetOrderHistory)
+			adminAuthGroup.GET("/orders/:id/receipts", orderHandler.GetOrderReceipts)
+			adminAuthGroup.GET("/order-receipts/:id/photo", orderHandler.GetReceiptPhoto)
+			adminAuthGroup.GET("/reports/order-discrepancies", orderHandler.GetDiscrepancyReport)
+			adminAuthGroup.POST("/orders/approve/:id", orderHandler.ApproveOrder)
+			adminAuthGroup.POST("/orders/cancel/:id", orderHandler.CancelOrder)
+			adminAuthGroup.POST("/orders/status/:id", orderHandler.TransitionOrder)
+			adminAuthGroup.POST("/orders/receive/:id", orderHandler.ReceiveOrder)
+			adminAuthGroup.GET("/stores/:id/order-suggestions", orderHandler.GetReorderSuggestions)
+			adminAuthGroup.POST("/order-suggestions/drafts", orderHandler.CreateSuggestedDrafts)
+			// Rutas de tareas programadas
+			adminAuthGroup.GET("/jobs", jobHandler.GetJobs)
+			adminAuthGroup.GET("/job-runs", jobHandler.GetJobRuns)
+			adminAuthGroup.POST("/jobs/run/:name", jobHandler.RunJob)
+		}
+
+		// Rutas para las tiendas (encargados)
+		storeGroup := apiGroup.Group("/store", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("store"))
+		{
+			// Rutas de planificacion de turnos
+			storeGroup.POST("/schedule-periods/create", shiftHandler.CreatePeriod)
+			storeGroup.GET("/schedule-periods", shiftHandler.GetPeriods)
+			storeGroup.GET("/schedule-periods/:id", shiftHandler.GetPeriod)
+			storeGroup.GET("/schedule-periods/:id/changes", shiftHandler.GetPeriodChanges)
+			storeGroup.POST("/schedule-periods/publish/:id", shiftHandler.PublishPeriod)
+			storeGroup.POST("/shifts/create", shiftHandler.CreateShift)
+			storeGroup.POST("/shifts/update/:id", shiftHandler.UpdateShift)
+			storeGroup.POST("/shifts/delete/:id", shiftHandler.DeleteShift)
+			// Rutas de cambios de turno
+			storeGroup.GET("/shift-swaps", shiftHandler.GetSwaps)
+			storeGroup.POST("/shift-swaps/approve/:id", shiftHandler.ApproveSwap)
+			storeGroup.POST("/shift-swaps/reject/:id", shiftHandler.RejectSwap)
+			// Rutas de turnos abiertos
+			storeGroup.GET("/shift-claims", shiftHandler.GetClaims)
+			storeGroup.POST("/shift-claims/approve/:id", shiftHandler.ApproveClaim)
+			storeGroup.POST("/shift-claims/reject/:id", shiftHandler.RejectClaim)
+			// Rutas de solicitudes de vacaciones
+			storeGroup.GET("/holiday-requests", holidayHandler.GetHolidayRequests)
+			storeGroup.GET("/holiday-requests/:id/decisions", holidayHandler.GetHolidayDecisions)
+			storeGroup.POST("/holiday-requests/approve/:id", holidayHandler.ApproveHoliday)
+			storeGroup.POST("/holiday-requests/reject/:id", holidayHandler.RejectHoliday)
+			storeGroup.GET("/holiday-requests/:id/timeline", holidayHandler.GetHolidayTimeline)
+			storeGroup.GET("/leave-timeline", holidayHandler.GetLeaveTimeline)
+			// Rutas de festivos
+			storeGroup.GET("/public-holidays", publicHolidayHandler.GetStorePublicHolidays)
+			// Rutas de pedidos
+			storeGroup.GET("/products", productHandler.GetStoreProducts)
+			storeGroup.GET("/stock", stockHandler.GetStock)
+			storeGroup.GET("/stock/history", stockHandler.GetStockHistory)
+			storeGroup.GET("/stock/counts", stockHandler.GetCounts)
+			storeGroup.GET("/stock/counts/:id", stockHandler.GetCount)
+			storeGroup.POST("/stock/counts/create", stockHandler.SubmitCount)
+			storeGroup.POST("/stock/waste/create", stockHandler.RecordWaste)
+			storeGroup.GET("/reports/stock-variance", stockHandler.GetVarianceReport)
+			storeGroup.POST("/orders/create", orderHandler.CreateOrder)
+			storeGroup.GET("/orders", orderHandler.GetOrders)
+			storeGroup.GET("/orders/:id", orderHandler.GetOrder)
+			storeGroup.GET("/orders/:id/history", orderHandler.GetOrderHistory)
+			storeGroup.GET("/orders/:id/receipts", orderHandler.GetOrderReceipts)
+			storeGroup.GET("/order-receipts/:id/photo", orderHandler.GetReceiptPhoto)
+			storeGroup.POST("/orders/update/:id", orderHandler.UpdateDraft)
+			storeGroup.POST("/orders/submit/:id", orderHandler.SubmitOrder)
+			storeGroup.POST("/orders/cancel/:id", orderHandler.CancelOrder)
+			storeGroup.POST("/orders/status/:id", orderHandler.TransitionOrder)
+			storeGroup.POST("/orders/receive/:id", orderHandler.ReceiveOrder)
+			storeGroup.POST("/order-receipts/:id/photo", orderHandler.AttachReceiptPhoto)
+			storeGroup.GET("/order-suggestions", orderHandler.GetReorderSuggestions)
+			storeGroup.POST("/order-suggestions/drafts", orderHandler.CreateSuggestedDrafts)
+			// Rutas de periodos de prueba
+			storeGroup.GET("/trial-periods", contractHandler.GetUpcomingTrials)
+			storeGroup.POST("/contracts/trial-outcome/:id", contractHandler.RecordTrialOutcome)
+			// Rutas de informes
+			storeGroup.GET("/reports/attendance", reportHandler.AttendanceReport)
+			// Rutas de calendario
+			storeGroup.POST("/calendar-feed/create", calendarHandler.CreateOwnFeed)
+			storeGroup.POST("/calendar-feed/revoke", calendarHandler.RevokeOwnFeed)
+			// Rutas de notificaciones
+			storeGroup.GET("/notifications", notificationHandler.GetNotifications)
+			storeGroup.POST("/notifications/read/:id", notificationHandler.MarkAsRead)
+		}
+
+		// Rutas para los trabajadores
+		workerGroup := apiGroup.Group("/worker", middlewares.AuthMiddleware(), middlewares.RoleMiddleware("worker"))
+		{
+			// Rutas de horario
+			workerGroup.GET("/shifts", shiftHandler.GetWorkerShifts)
+			workerGroup.GET("/schedule-changes", shiftHandler.GetWorkerChanges)
+			// Rutas de cambios de turno
+			workerGroup.POST("/shift-swaps/create", shiftHandler.CreateSwap)
+			workerGroup.GET("/shift-swaps", shiftHandler.GetWorkerSwaps)
+			workerGroup.POST("/shift-swaps/accept/:id", shiftHandler.AcceptSwap)
+			workerGroup.POST("/shift-swaps/decline/:id", shiftHandler.DeclineSwap)
+			workerGroup.POST("/shift-swaps/cancel/:id", shiftHandler.CancelSwap)
+			// Rutas de turnos abiertos
+			workerGroup.GET("/open-shifts", shiftHandler.GetClaimableShifts)
+			workerGroup.POST("/open-shifts/claim/:id", shiftHandler.ClaimShift)
+			workerGroup.GET("/shift-claims", shiftHandler.GetWorkerClaims)
+			workerGroup.POST("/shift-claims/withdraw/:id", shiftHandler.WithdrawClaim)
+			// Rutas de vacaciones
+			workerGroup.POST("/holidays/request", holidayHandler.RequestHoliday)
+			workerGroup.POST("/holidays/check", holidayHandler.CheckHoliday)
+			workerGroup.GET("/holidays", holidayHandler.GetWorkerHolidays)
+			workerGroup.GET("/holidays/balance", holidayHandler.GetOwnHolidayBalance)
+			workerGroup.GET("/holidays/:id/decisions", holidayHandler.GetHolidayDecisions)
+			workerGroup.POST("/holidays/cancel/:id", holidayHandler.CancelHoliday)
+			workerGroup.GET("/holidays/:id/document", holidayHandler.GetDocument)
+			workerGroup.POST("/holidays/:id/document", holidayHandler.AttachDocument)
+			workerGroup.GET("/leave-types", holidayHandler.GetLeaveTypes)
+			// Rutas de contratos
+			workerGroup.GET("/contracts", contractHandler.GetOwnContracts)
+			// Rutas de calendario
+			workerGroup.POST("/calendar-feed/create", calendarHandler.CreateOwnFeed)
+			workerGroup.POST("/calendar-feed/revoke", calendarHandler.RevokeOwnFeed)
+			// Rutas de notificaciones
+			workerGroup.GET("/notifications", notificationHandler.GetNotifications)
+			workerGroup.POST("/notifications/read/:id", notificationHandler.MarkAsRead)
+		}
 	}
 }
@@ -0,0 +1,243 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/logger"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"go.uber.org/zap"
+)
+
+// Cada cuanto se comprueba si hay tareas pendientes
+const tickInterval = 30 * time.Second
+
+// Tiempo maximo que una instancia mantiene una tarea bloqueada. Si el
+// servidor se para a mitad de una ejecucion, otra instancia puede lanzarla
+// pasado este tiempo.
+const lockTTL = 30 * time.Minute
+
+// Ejecuciones que se devuelven en el historial
+const runHistoryLimit = 100
+
+// JobFunc - Trabajo de una tarea; devuelve un resumen de lo que hizo
+type JobFunc func() (string, error)
+
+type job struct {
+	name     string
+	schedule *utils.CronSchedule
+	run      JobFunc
+	next     time.Time
+}
+
+// Scheduler - Lanza las tareas programadas segun su expresion cron
+type Scheduler struct {
+	jobRepo  *repositories.JobRepository
+	instance string
+
+	mu   sync.Mutex
+	jobs map[string]*job
+	stop chan struct{}
+}
+
+func New(jobRepo *repositories.JobRepository) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		jobRepo:  jobRepo,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		jobs:     make(map[string]*job),
+	}
+}
+
+// Register - Registra una tarea con su expresion cron
+// -------------------------------------------------------------------
+func (s *Scheduler) Register(name, spec string, run JobFunc) error {
+	schedule, err := utils.ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("tarea %s: %w", name, err)
+	}
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("tarea %s: la expresion cron nunca se cumple", name)
+	}
+	if err := s.jobRepo.SaveJob(&models.ScheduledJob{Name: name, Schedule: spec}); err != nil {
+		return fmt.Errorf("tarea %s: error al registrarla", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[name] = &job{
+		name:     name,
+		schedule: schedule,
+		run:      run,
+		next:     next,
+	}
+	return nil
+}
+
+// Start - Empieza a lanzar las tareas en segundo plano
+// -------------------------------------------------------------------
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	stop, count := s.stop, len(s.jobs)
+	s.mu.Unlock()
+
+	if err := s.jobRepo.CloseStaleRuns(time.Now().Add(-lockTTL)); err != nil {
+		logger.Logger.Error("Scheduler: failed to close stale runs", zap.Error(err))
+	}
+
+	go func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				s.tick(now)
+			}
+		}
+	}()
+	logger.Logger.Info("Scheduler started", zap.String("instance", s.instance), zap.Int("jobs", count))
+}
+
+// Stop - Deja de lanzar tareas; las que estan en curso terminan
+// -------------------------------------------------------------------
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// tick - Lanza las tareas cuya siguiente ejecucion ya ha llegado
+// -------------------------------------------------------------------
+func (s *Scheduler) tick(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.next.IsZero() || now.Before(j.next) {
+			continue
+		}
+		slot := j.next
+		j.next = j.schedule.Next(now)
+		go s.execute(j, &slot)
+	}
+}
+
+// execute - Ejecuta una tarea si consigue su bloqueo y guarda el resultado
+// -------------------------------------------------------------------
+// Con slot se trata de una ejecucion programada, que solo se lanza una vez
+// aunque haya varias instancias del servidor.
+func (s *Scheduler) execute(j *job, slot *time.Time) (*models.JobRun, error) {
+	locked, err := s.jobRepo.AcquireLock(j.name, s.instance, time.Now().Add(lockTTL), slot)
+	if err != nil {
+		logger.Logger.Error("Scheduler: failed to lock job", zap.String("job", j.name), zap.Error(err))
+		return nil, errors.New("error al bloquear la tarea")
+	}
+	if !locked {
+		return nil, errors.New("la tarea ya se esta ejecutando")
+	}
+	defer func() {
+		if err := s.jobRepo.ReleaseLock(j.name, s.instance); err != nil {
+			logger.Logger.Error("Scheduler: failed to release job", zap.String("job", j.name), zap.Error(err))
+		}
+	}()
+
+	run := &models.JobRun{
+		JobName:   j.name,
+		Instance:  s.instance,
+		Manual:    slot == nil,
+		Status:    models.JobRunRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.jobRepo.CreateRun(run); err != nil {
+		logger.Logger.Error("Scheduler: failed to save run", zap.String("job", j.name), zap.Error(err))
+		return nil, errors.New("error al registrar la ejecucion")
+	}
+
+	message, err := safeRun(j.run)
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = models.JobRunSuccess
+	run.Message = message
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Message = err.Error()
+		logger.Logger.Error("Scheduler: job failed", zap.String("job", j.name), zap.Error(err))
+	} else {
+		logger.Logger.Info("Scheduler: job finished", zap.String("job", j.name), zap.String("result", message))
+	}
+
+	if err := s.jobRepo.FinishRun(run); err != nil {
+		logger.Logger.Error("Scheduler: failed to save run result", zap.String("job", j.name), zap.Error(err))
+	}
+	return run, nil
+}
+
+// safeRun - Ejecuta el trabajo convirtiendo un panic en error
+func safeRun(run JobFunc) (message string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run()
+}
+
+// RunNow - Ejecuta una tarea en el momento, fuera de su programacion
+// -------------------------------------------------------------------
+func (s *Scheduler) RunNow(name string) (*models.JobRun, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.New("la tarea no existe")
+	}
+	return s.execute(j, nil)
+}
+
+// Jobs - Obtiene las tareas registradas con su proxima ejecucion
+// -------------------------------------------------------------------
+func (s *Scheduler) Jobs() ([]models.ScheduledJob, error) {
+	stored, err := s.jobRepo.GetJobs()
+	if err != nil {
+		return nil, errors.New("error al obtener las tareas")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]models.ScheduledJob, 0, len(s.jobs))
+	for _, job := range stored {
+		registered, ok := s.jobs[job.Name]
+		if !ok {
+			continue // Tareas que ya no existen en esta version del servidor
+		}
+		next := registered.next
+		job.NextRunAt = &next
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Runs - Obtiene las ultimas ejecuciones, de una tarea o de todas
+// -------------------------------------------------------------------
+func (s *Scheduler) Runs(name string) ([]models.JobRun, error) {
+	runs, err := s.jobRepo.GetRuns(name, runHistoryLimit)
+	if err != nil {
+		return nil, errors.New("error al obtener el historial de ejecuciones")
+	}
+	return runs, nil
+}
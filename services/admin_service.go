@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/javimartzs/worker-hub-backend/config"
@@ -20,6 +21,10 @@ type AdminService struct {
 	storeRepo    *repositories.StoreRepository
 	holidaysRepo *repositories.HolidaysRepository
 	timelogRepo  *repositories.TimelogRepository
+	calendarRepo *repositories.CalendarRepository
+	contractRepo *repositories.ContractRepository
+	assignRepo   *repositories.AssignmentRepository
+	ledger       *holidayLedger
 
 	db *gorm.DB
 }
@@ -30,6 +35,10 @@ func NewAdminService(
 	storeRepo *repositories.StoreRepository,
 	holidaysRepo *repositories.HolidaysRepository,
 	timelogRepo *repositories.TimelogRepository,
+	calendarRepo *repositories.CalendarRepository,
+	publicHolidayRepo *repositories.PublicHolidayRepository,
+	contractRepo *repositories.ContractRepository,
+	assignRepo *repositories.AssignmentRepository,
 	db *gorm.DB) *AdminService {
 	return &AdminService{
 		userRepo:     userRepo,
@@ -37,6 +46,10 @@ func NewAdminService(
 		storeRepo:    storeRepo,
 		holidaysRepo: holidaysRepo,
 		timelogRepo:  timelogRepo,
+		calendarRepo: calendarRepo,
+		contractRepo: contractRepo,
+		assignRepo:   assignRepo,
+		ledger:       &holidayLedger{holidaysRepo, publicHolidayRepo, storeRepo},
 		db:           db,
 	}
 }
@@ -49,12 +62,12 @@ func generateUniqueUsername(tx *gorm.DB, userRepo *repositories.UserRepository,
 	counter := 1
 
 	for {
-		// Verificar la existencia del usuario por su username
-		existingUser, err := userRepo.FindUserByUsername(tx, username)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		// Verificar la existencia del usuario por su username, incluidos los archivados
+		taken, err := userRepo.IsUsernameTaken(tx, username)
+		if err != nil {
 			return "", fmt.Errorf("error al verificar el nombre de usuario: %w", err)
 		}
-		if existingUser == nil {
+		if !taken {
 			break
 		}
 		username = fmt.Sprintf("%s%d", baseUsername, counter)
@@ -71,6 +84,12 @@ func (s *AdminService) CreateWorker(worker *models.Worker) error {
 	if err := utils.ValidateWorkerFields(worker); err != nil {
 		return err
 	}
+	if worker.Status == "" {
+		worker.Status = "Baja" // Pasa a Alta al registrar su contrato
+	}
+	if worker.Prueba == "" {
+		worker.Prueba = "No"
+	}
 
 	// Comprobamos que el trabajador no exista ya en la base de datos
 	existingWorker, err := s.workerRepo.FindWorkerByNie(worker.Nie)
@@ -82,6 +101,9 @@ func (s *AdminService) CreateWorker(worker *models.Worker) error {
 		}
 	}
 	if existingWorker != nil && existingWorker.ID != "" {
+		if existingWorker.DeletedAt.Valid {
+			return errors.New("el trabajador esta archivado, restauralo en lugar de crearlo de nuevo")
+		}
 		return errors.New("el trabajador ya existe")
 	}
 
@@ -97,19 +119,35 @@ func (s *AdminService) CreateWorker(worker *models.Worker) error {
 		}
 	}()
 
+	if _, err := s.provisionWorker(tx, worker); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+
+	return nil
+}
+
+// provisionWorker - Crea el usuario, el trabajador y su asignacion dentro de una transaccion
+// -------------------------------------------------------------------
+// El usuario se genera con el nombre y la contraseña (PIN) son los cuatro
+// primeros caracteres del NIE. Devuelve el nombre de usuario.
+func (s *AdminService) provisionWorker(tx *gorm.DB, worker *models.Worker) (string, error) {
 	// Generamos un nombre de usuario unico para el trabajador
 	username, err := generateUniqueUsername(tx, s.userRepo, worker.Name)
 	if err != nil {
-		tx.Rollback()
-		return errors.New("error al generar el nombre de usuario")
+		return "", errors.New("error al generar el nombre de usuario")
 	}
 
 	// Generamos la contraseña (PIN) del trabajador
 	password := strings.ToLower(worker.Nie[:4])
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		tx.Rollback()
-		return errors.New("error al generar la contraseña")
+		return "", errors.New("error al generar la contraseña")
 	}
 
 	// Creamos el usuario del trabajador
@@ -122,8 +160,7 @@ func (s *AdminService) CreateWorker(worker *models.Worker) error {
 
 	// Guardamos el usuario en la tabla de usuarios
 	if err := s.userRepo.CreateUser(tx, user); err != nil {
-		tx.Rollback()
-		return errors.New("error al guardar el usuario en la tabla")
+		return "", errors.New("error al guardar el usuario en la tabla")
 	}
 
 	// Generamos el ID del trabajador
@@ -132,20 +169,32 @@ func (s *AdminService) CreateWorker(worker *models.Worker) error {
 
 	// Guardamos el trabajador en la tabla de trabajadores
 	if err := s.workerRepo.CreateWorker(tx, worker); err != nil {
-		tx.Rollback()
-		return errors.New("error al guardar el trabajador en la tabla")
+		return "", errors.New("error al guardar el trabajador en la tabla")
 	}
 
-	// Confirmamos la transaccion
-	if err := tx.Commit().Error; err != nil {
-		return errors.New("error al confirmar la transaccion")
+	// Abrimos su asignacion a la tienda principal
+	if worker.StoreID != nil && *worker.StoreID != "" {
+		start := time.Now().Format("2006-01-02")
+		if worker.HireDate != nil {
+			start = (*worker.HireDate)[:10]
+		}
+		assignment := &models.StoreAssignment{
+			WorkerID:  worker.ID,
+			StoreID:   *worker.StoreID,
+			Kind:      models.AssignmentPrimary,
+			StartDate: start,
+		}
+		if err := saveAssignment(tx, s.assignRepo, assignment); err != nil {
+			return "", err
+		}
 	}
 
-	return nil
+	return username, nil
 }
 
-// DeleteWorker - Elimina un trabajador y su usuario asociado
+// DeleteWorker - Archiva un trabajador y su usuario asociado
 // --------------------------------------------------------------------
+// Se conserva su historial y se puede restaurar hasta que se purgue.
 func (s *AdminService) DeleteWorker(workerID string) error {
 
 	// Iniciamos la transaccion
@@ -167,16 +216,16 @@ func (s *AdminService) DeleteWorker(workerID string) error {
 		return errors.New("el trabajador no existe")
 	}
 
-	// Eliminamos el trabajador
+	// Archivamos el trabajador
 	if err := s.workerRepo.DeleteWorker(tx, workerID); err != nil {
 		tx.Rollback()
-		return errors.New("error al eliminar el trabajador")
+		return errors.New("error al archivar el trabajador")
 	}
 
-	// Eliminamos el usuario asociado al trabajador
+	// Archivamos el usuario asociado al trabajador
 	if err := s.userRepo.DeleteUser(tx, worker.UserID); err != nil {
 		tx.Rollback()
-		return errors.New("error al eliminar el usuario")
+		return errors.New("error al archivar el usuario")
 	}
 
 	// Confirmamos la transaccion
@@ -187,10 +236,43 @@ func (s *AdminService) DeleteWorker(workerID string) error {
 	return nil
 }
 
-// GetAllWorkers - Obtiene todos los trabajadores
+// Tamaño de pagina por defecto y maximo del listado de trabajadores
+const (
+	defaultWorkerPageSize = 50
+	maxWorkerPageSize     = 200
+)
+
+// SearchWorkers - Busca, filtra, ordena y pagina los trabajadores
 // --------------------------------------------------------------------
-func (s *AdminService) GetAllWorkers() ([]models.Worker, error) {
-	return s.workerRepo.GetAllWorkers()
+func (s *AdminService) SearchWorkers(filter dtos.WorkerQuery) (*dtos.WorkerPage, error) {
+	filter.Search = strings.TrimSpace(filter.Search)
+	if filter.Status != "" && filter.Status != "Alta" && filter.Status != "Baja" {
+		return nil, &utils.FieldError{Field: "status", Code: utils.CodeInvalidValue, Message: "el estado debe ser Alta o Baja"}
+	}
+	if filter.Prueba != "" && filter.Prueba != "Si" && filter.Prueba != "No" {
+		return nil, &utils.FieldError{Field: "prueba", Code: utils.CodeInvalidValue, Message: "la prueba debe ser Si o No"}
+	}
+	if filter.Sort != "" && !repositories.IsWorkerSortField(filter.Sort) {
+		return nil, &utils.FieldError{Field: "sort", Code: utils.CodeInvalidValue, Message: "el orden debe ser name, last_name, email, nie, cargo, status, hire_date o store"}
+	}
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		return nil, &utils.FieldError{Field: "order", Code: utils.CodeInvalidValue, Message: "el sentido del orden debe ser asc o desc"}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultWorkerPageSize
+	}
+	if filter.Limit < 0 || filter.Limit > maxWorkerPageSize {
+		return nil, &utils.FieldError{Field: "limit", Code: utils.CodeInvalidValue, Message: fmt.Sprintf("el limite debe estar entre 1 y %d", maxWorkerPageSize)}
+	}
+	if filter.Offset < 0 {
+		return nil, &utils.FieldError{Field: "offset", Code: utils.CodeInvalidValue, Message: "el desplazamiento no puede ser negativo"}
+	}
+
+	workers, total, err := s.workerRepo.SearchWorkers(filter)
+	if err != nil {
+		return nil, errors.New("error al obtener los trabajadores")
+	}
+	return &dtos.WorkerPage{Workers: workers, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
 }
 
 // UpdateWorker - Actualiza un trabajador
@@ -202,8 +284,42 @@ func (s *AdminService) UpdateWorker(workerID string, worker *models.Worker) erro
 		return err
 	}
 
+	// Con contratos registrados el estado, la prueba y la fecha de alta salen de ellos
+	hasContracts, err := s.contractRepo.HasContracts(nil, workerID)
+	if err != nil {
+		return errors.New("error al comprobar los contratos del trabajador")
+	}
+	if hasContracts {
+		worker.Status = ""
+		worker.Prueba = ""
+		worker.HireDate = nil
+	}
+
+	// Con asignaciones la tienda solo cambia con un traslado
+	hasAssignments, err := s.assignRepo.HasAssignments(nil, workerID)
+	if err != nil {
+		return errors.New("error al comprobar las tiendas del trabajador")
+	}
+	if hasAssignments || (worker.StoreID != nil && *worker.StoreID == "") {
+		worker.StoreID = nil
+	}
+
 	// Llamamos al repositorio para actualizar el trabajador
-	return s.workerRepo.UpdateWorker(workerID, worker)
+	if err := s.workerRepo.UpdateWorker(workerID, worker); err != nil {
+		return err
+	}
+
+	// La primera tienda abre su asignacion principal desde hoy
+	if worker.StoreID != nil {
+		assignment := &models.StoreAssignment{
+			WorkerID:  workerID,
+			StoreID:   *worker.StoreID,
+			Kind:      models.AssignmentPrimary,
+			StartDate: time.Now().Format("2006-01-02"),
+		}
+		return saveAssignment(nil, s.assignRepo, assignment)
+	}
+	return nil
 }
 
 // CreateStore - Crea una nueva tienda y su usuario asociado
@@ -225,6 +341,9 @@ func (s *AdminService) CreateStore(store *models.Store) error {
 		}
 	}
 	if existingStore != nil && existingStore.ID != "" {
+		if existingStore.DeletedAt.Valid {
+			return errors.New("la tienda esta archivada, restaurala en lugar de crearla de nuevo")
+		}
 		return errors.New("la tienda ya existe")
 	}
 
@@ -240,19 +359,34 @@ func (s *AdminService) CreateStore(store *models.Store) error {
 		}
 	}()
 
+	if _, err := s.provisionStore(tx, store); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+
+	return nil
+}
+
+// provisionStore - Crea el usuario y la tienda dentro de una transaccion
+// --------------------------------------------------------------------
+// Devuelve el nombre de usuario de la tienda.
+func (s *AdminService) provisionStore(tx *gorm.DB, store *models.Store) (string, error) {
 	// Generamos un nombre de usuario unico para la tienda
 	username, err := generateUniqueUsername(tx, s.userRepo, store.Name)
 	if err != nil {
-		tx.Rollback()
-		return errors.New("error al generar el nombre de usuario")
+		return "", errors.New("error al generar el nombre de usuario")
 	}
 
 	// Generamos la contraseña de la tienda
 	password := config.Env.StorePass
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		tx.Rollback()
-		return errors.New("error al generar la contraseña")
+		return "", errors.New("error al generar la contraseña")
 	}
 
 	// Creamos el usuario de la tienda
@@ -265,8 +399,7 @@ func (s *AdminService) CreateStore(store *models.Store) error {
 
 	// Guardamos el usuario en la tabla de usuarios
 	if err := s.userRepo.CreateUser(tx, user); err != nil {
-		tx.Rollback()
-		return errors.New("error al guardar el trabajador en la tablaa")
+		return "", errors.New("error al guardar el trabajador en la tablaa")
 	}
 
 	// Generamos el ID de la tienda
@@ -275,19 +408,13 @@ func (s *AdminService) CreateStore(store *models.Store) error {
 
 	// Guardamos la tienda en la tabla de tiendas
 	if err := s.storeRepo.CreateStore(tx, store); err != nil {
-		tx.Rollback()
-		return errors.New("error al guardar la tienda en la tabla")
+		return "", errors.New("error al guardar la tienda en la tabla")
 	}
 
-	// Confirmamos la transaccion
-	if err := tx.Commit().Error; err != nil {
-		return errors.New("error al confirmar la transaccion")
-	}
-
-	return nil
+	return username, nil
 }
 
-// DeleteStore - Elimina una tienda y su usuario asociado
+// DeleteStore - Archiva una tienda y su usuario asociado
 // --------------------------------------------------------------------
 func (s *AdminService) DeleteStore(storeID string) error {
 
@@ -314,16 +441,16 @@ func (s *AdminService) DeleteStore(storeID string) error {
 		return errors.New("la tienda no existe")
 	}
 
-	// Eliminamos la tienda
+	// Archivamos la tienda
 	if err := s.storeRepo.DeleteStore(tx, storeID); err != nil {
 		tx.Rollback()
-		return errors.New("error al eliminar la tienda")
+		return errors.New("error al archivar la tienda")
 	}
 
-	// Eliminamos el usuario asociado a la tienda
+	// Archivamos el usuario asociado a la tienda
 	if err := s.userRepo.DeleteUser(tx, store.UserID); err != nil {
 		tx.Rollback()
-		return errors.New("error al eliminar el usuario")
+		return errors.New("error al archivar el usuario")
 	}
 
 	// Confirmamos la transaccion
@@ -356,15 +483,37 @@ func (s *AdminService) UpdateStore(storeID string, store *models.Store) error {
 
 // CreateHoliday - Crea una nueva vacacion
 // --------------------------------------------------------------------
-func (s *AdminService) CreateHoliday(holiday *models.Holiday) error {
+// Las vacaciones aprobadas no pueden superar el saldo del trabajador salvo
+// que el admin lo fuerce con override.
+func (s *AdminService) CreateHoliday(holiday *models.Holiday, override bool) error {
+
+	// Las vacaciones que registra el admin directamente ya estan aprobadas
+	if holiday.Status == "" {
+		holiday.Status = models.HolidayStatusApproved
+	}
 
 	// Validaciones de los campos
 	if err := utils.ValidateHolidaysFields(holiday); err != nil {
 		return err
 	}
 
+	leaveType, err := s.holidaysRepo.FindLeaveType(holiday.LeaveType)
+	if err != nil {
+		return errors.New("el tipo de ausencia no existe")
+	}
+
+	if isApprovedHoliday(holiday) && leaveType.CountsAsHoliday && !override {
+		worker, err := s.workerRepo.FindWorkerByID(holiday.WorkerID)
+		if err != nil {
+			return errors.New("el trabajador no existe")
+		}
+		if err := s.ledger.check(worker, holiday, false); err != nil {
+			return err
+		}
+	}
+
 	// Llamamos al repositorio para crear las vacaciones de un trabajador
-	if err := s.holidaysRepo.CreateHoliday(holiday); err != nil {
+	if err := s.holidaysRepo.CreateHoliday(nil, holiday); err != nil {
 		return errors.New("error al crear las vacaciones")
 	}
 
@@ -385,21 +534,55 @@ func (s *AdminService) GetHolidaysWithWorker() ([]dtos.HolidayWithWorkerName, er
 
 // DeleteHoliday - Elimina una vacacion
 // --------------------------------------------------------------------
-func (s *AdminService) DeleteHoliday(holidayID string) error {
-	return s.holidaysRepo.DeleteHoliday(holidayID)
-}
+func (s *AdminService) DeleteHoliday(holidayID int) error {
 
-// UpdateHoliday - Actualiza una vacacion
-// --------------------------------------------------------------------
-func (s *AdminService) UpdateHoliday(holidayID string, holiday *models.Holiday) error {
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
 
-	// Validaciones de los campos
-	if err := utils.ValidateHolidaysFields(holiday); err != nil {
-		return err
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Buscamos la vacacion para poder cancelarla en los calendarios
+	holiday, err := s.holidaysRepo.LockHolidayByID(tx, holidayID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la vacacion no existe")
+	}
+
+	// El historial de decisiones apunta a la vacacion y se elimina con ella
+	if err := s.holidaysRepo.DeleteDecisions(tx, holiday.ID); err != nil {
+		tx.Rollback()
+		return errors.New("error al eliminar el historial de la vacacion")
+	}
+	if err := s.holidaysRepo.DeleteHoliday(tx, holiday.ID); err != nil {
+		tx.Rollback()
+		return errors.New("error al eliminar la vacacion")
+	}
+
+	// Publicamos la cancelacion en el calendario del trabajador y de su tienda
+	var storeID *string
+	if worker, err := s.workerRepo.FindWorkerByID(holiday.WorkerID); err == nil {
+		storeID = worker.StoreID
+	}
+	// Con fechas invalidas nunca llego a publicarse
+	if cancellation, err := newHolidayCancellation(holiday, &holiday.WorkerID, storeID); err == nil {
+		if err := s.calendarRepo.CreateCancellation(tx, cancellation); err != nil {
+			tx.Rollback()
+			return errors.New("error al actualizar el calendario del trabajador")
+		}
 	}
 
-	// Llamamos al repositorio para actualizar la vacacion
-	return s.holidaysRepo.UpdateHoliday(holidayID, holiday)
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
 }
 
 // CreateUser - Crea un nuevo usuario
@@ -411,16 +594,12 @@ func (s *AdminService) CreateUser(user *models.User) error {
 		return err
 	}
 
-	// Comprobamos que el usuario no exista ya en la base de datos
-	existingUser, err := s.userRepo.FindUserByUsername(nil, user.Username)
+	// Comprobamos que el usuario no exista ya en la base de datos, aunque este archivado
+	taken, err := s.userRepo.IsUsernameTaken(nil, user.Username)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// No existe el usuario, esto está bien
-		} else {
-			return errors.New("error al verificar la existencia del usuario")
-		}
+		return errors.New("error al verificar la existencia del usuario")
 	}
-	if existingUser != nil && existingUser.ID != "" {
+	if taken {
 		return errors.New("el usuario ya existe")
 	}
 
@@ -448,7 +627,7 @@ func (s *AdminService) GetAllUsers() ([]dtos.UserList, error) {
 	return s.userRepo.GetAllUsers()
 }
 
-// DeleteUser - Elimina un usuario
+// DeleteUser - Archiva un usuario
 // --------------------------------------------------------------------
 func (s *AdminService) DeleteUser(userID string) error {
 	return s.userRepo.DeleteUser(nil, userID)
@@ -481,6 +660,11 @@ func (s *AdminService) CreateTimelog(timelog *models.Timelog) error {
 		return errors.New("la tienda no existe")
 	}
 
+	// Con asignaciones solo puede fichar en sus tiendas principal y secundarias
+	if err := s.checkClockInStore(timelog); err != nil {
+		return err
+	}
+
 	// Generamos el ID del registro horario
 	timelog.ID = uuid.New().String()
 
@@ -491,3 +675,28 @@ func (s *AdminService) CreateTimelog(timelog *models.Timelog) error {
 
 	return nil
 }
+
+// checkClockInStore - Comprueba que el trabajador este asignado a la tienda del fichaje ese dia
+// --------------------------------------------------------------------
+func (s *AdminService) checkClockInStore(timelog *models.Timelog) error {
+	hasAssignments, err := s.assignRepo.HasAssignments(nil, timelog.WorkerID)
+	if err != nil {
+		return errors.New("error al comprobar las tiendas del trabajador")
+	}
+	if !hasAssignments {
+		return nil
+	}
+	at, err := utils.ParseTimestamp(timelog.Timelog)
+	if err != nil {
+		return err
+	}
+	date := at.Format("2006-01-02")
+	assigned, err := s.assignRepo.IsAssignedOn(timelog.WorkerID, timelog.StoreID, date)
+	if err != nil {
+		return errors.New("error al comprobar las tiendas del trabajador")
+	}
+	if !assigned {
+		return fmt.Errorf("el trabajador no esta asignado a esa tienda el %s", date)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/config"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"gorm.io/gorm"
+)
+
+// Dias por defecto que se conservan los registros archivados (4 años)
+const defaultArchiveRetentionDays = 1461
+
+// archiveRetentionDays - Plazo de conservacion de los archivados segun la configuracion
+func archiveRetentionDays() int {
+	if days, err := strconv.Atoi(config.Env.ArchiveRetentionDays); err == nil && days > 0 {
+		return days
+	}
+	return defaultArchiveRetentionDays
+}
+
+// GetArchivedWorkers - Obtiene los trabajadores archivados
+// --------------------------------------------------------------------
+func (s *AdminService) GetArchivedWorkers() ([]models.Worker, error) {
+	workers, err := s.workerRepo.GetArchivedWorkers()
+	if err != nil {
+		return nil, errors.New("error al obtener los trabajadores archivados")
+	}
+	return workers, nil
+}
+
+// GetArchivedStores - Obtiene las tiendas archivadas
+// --------------------------------------------------------------------
+func (s *AdminService) GetArchivedStores() ([]models.Store, error) {
+	stores, err := s.storeRepo.GetArchivedStores()
+	if err != nil {
+		return nil, errors.New("error al obtener las tiendas archivadas")
+	}
+	return stores, nil
+}
+
+// GetArchivedUsers - Obtiene los usuarios archivados
+// --------------------------------------------------------------------
+func (s *AdminService) GetArchivedUsers() ([]dtos.UserList, error) {
+	users, err := s.userRepo.GetArchivedUsers()
+	if err != nil {
+		return nil, errors.New("error al obtener los usuarios archivados")
+	}
+	return users, nil
+}
+
+// RestoreWorker - Restaura un trabajador archivado y su usuario
+// --------------------------------------------------------------------
+// La tienda principal se vuelve a tomar de su historial de asignaciones.
+func (s *AdminService) RestoreWorker(workerID string) error {
+	worker, err := s.workerRepo.FindArchivedWorker(workerID)
+	if err != nil {
+		return errors.New("el trabajador no existe o no esta archivado")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := s.workerRepo.RestoreWorker(tx, workerID); err != nil {
+		tx.Rollback()
+		return errors.New("error al restaurar el trabajador")
+	}
+	if err := s.userRepo.RestoreUser(tx, worker.UserID); err != nil {
+		tx.Rollback()
+		return errors.New("error al restaurar el usuario")
+	}
+	if _, err := s.assignRepo.SyncWorkerStores(tx, workerID, time.Now().Format("2006-01-02")); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar la tienda del trabajador")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// RestoreStore - Restaura una tienda archivada y su usuario
+// --------------------------------------------------------------------
+func (s *AdminService) RestoreStore(storeID string) error {
+	store, err := s.storeRepo.FindArchivedStore(storeID)
+	if err != nil {
+		return errors.New("la tienda no existe o no esta archivada")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := s.storeRepo.RestoreStore(tx, storeID); err != nil {
+		tx.Rollback()
+		return errors.New("error al restaurar la tienda")
+	}
+	if err := s.userRepo.RestoreUser(tx, store.UserID); err != nil {
+		tx.Rollback()
+		return errors.New("error al restaurar el usuario")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// RestoreUser - Restaura un usuario archivado
+// --------------------------------------------------------------------
+// Los usuarios de trabajadores y tiendas vuelven al restaurar su ficha.
+func (s *AdminService) RestoreUser(userID string) error {
+	user, err := s.userRepo.FindArchivedUser(userID)
+	if err != nil {
+		return errors.New("el usuario no existe o no esta archivado")
+	}
+	if user.Role == "worker" || user.Role == "store" {
+		return errors.New("el usuario se restaura junto a su trabajador o tienda")
+	}
+	if err := s.userRepo.RestoreUser(nil, userID); err != nil {
+		return errors.New("error al restaurar el usuario")
+	}
+	return nil
+}
+
+// purgeArchived - Borra uno a uno los archivados dentro de la transaccion
+// --------------------------------------------------------------------
+// Cada borrado va en su propio savepoint: si el registro sigue enlazado a
+// fichajes, vacaciones, pedidos... se deshace solo ese borrado y se conserva.
+func purgeArchived(tx *gorm.DB, ids []string, purge func(tx *gorm.DB, id string) error) (purged, kept int, err error) {
+	for _, id := range ids {
+		if err := tx.SavePoint("purga").Error; err != nil {
+			return purged, kept, err
+		}
+		if err := purge(tx, id); err != nil {
+			if err := tx.RollbackTo("purga").Error; err != nil {
+				return purged, kept, err
+			}
+			kept++
+			continue
+		}
+		purged++
+	}
+	return purged, kept, nil
+}
+
+// PurgeArchived - Borra definitivamente los archivados con mas antiguedad que el plazo de conservacion
+// --------------------------------------------------------------------
+// Tarea programada. Primero los trabajadores y las tiendas y despues los
+// usuarios, que quedan libres al borrar su ficha.
+func (s *AdminService) PurgeArchived() (string, error) {
+	before := time.Now().AddDate(0, 0, -archiveRetentionDays())
+
+	workers, err := s.workerRepo.GetWorkersArchivedBefore(before)
+	if err != nil {
+		return "", errors.New("error al obtener los trabajadores archivados")
+	}
+	stores, err := s.storeRepo.GetStoresArchivedBefore(before)
+	if err != nil {
+		return "", errors.New("error al obtener las tiendas archivadas")
+	}
+	workerIDs := make([]string, len(workers))
+	for i, worker := range workers {
+		workerIDs[i] = worker.ID
+	}
+	storeIDs := make([]string, len(stores))
+	for i, store := range stores {
+		storeIDs[i] = store.ID
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return "", errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	purgedWorkers, keptWorkers, err := purgeArchived(tx, workerIDs, s.workerRepo.PurgeWorker)
+	if err != nil {
+		tx.Rollback()
+		return "", errors.New("error al purgar los trabajadores archivados")
+	}
+	purgedStores, keptStores, err := purgeArchived(tx, storeIDs, s.storeRepo.PurgeStore)
+	if err != nil {
+		tx.Rollback()
+		return "", errors.New("error al purgar las tiendas archivadas")
+	}
+
+	users, err := s.userRepo.GetUsersArchivedBefore(before)
+	if err != nil {
+		tx.Rollback()
+		return "", errors.New("error al obtener los usuarios archivados")
+	}
+	userIDs := make([]string, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	purgedUsers, keptUsers, err := purgeArchived(tx, userIDs, s.userRepo.PurgeUser)
+	if err != nil {
+		tx.Rollback()
+		return "", errors.New("error al purgar los usuarios archivados")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return "", errors.New("error al confirmar la transaccion")
+	}
+	return fmt.Sprintf("%d trabajadores, %d tiendas y %d usuarios purgados; %d conservados por tener historial",
+		purgedWorkers, purgedStores, purgedUsers, keptWorkers+keptStores+keptUsers), nil
+}
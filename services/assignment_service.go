@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+type AssignmentService struct {
+	assignmentRepo *repositories.AssignmentRepository
+	workerRepo     *repositories.WorkerRepository
+	storeRepo      *repositories.StoreRepository
+
+	db *gorm.DB
+}
+
+func NewAssignmentService(
+	assignmentRepo *repositories.AssignmentRepository,
+	workerRepo *repositories.WorkerRepository,
+	storeRepo *repositories.StoreRepository,
+	db *gorm.DB) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepo: assignmentRepo,
+		workerRepo:     workerRepo,
+		storeRepo:      storeRepo,
+		db:             db,
+	}
+}
+
+// trimAssignmentDates - Deja las fechas de las asignaciones en formato YYYY-MM-DD
+func trimAssignmentDates(assignments []models.StoreAssignment) {
+	for i := range assignments {
+		assignments[i].StartDate = assignments[i].StartDate[:10]
+		assignments[i].EndDate = trimDate(assignments[i].EndDate)
+	}
+}
+
+// saveAssignment - Crea una asignacion comprobando que no se solape y actualiza la tienda del trabajador
+// -------------------------------------------------------------------
+func saveAssignment(tx *gorm.DB, assignmentRepo *repositories.AssignmentRepository, assignment *models.StoreAssignment) error {
+	other, err := assignmentRepo.FindOverlappingAssignment(tx, assignment, 0)
+	if err == nil {
+		return fmt.Errorf("se solapa con la asignacion %s desde el %s", other.Kind, other.StartDate[:10])
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("error al comprobar las asignaciones del trabajador")
+	}
+	if err := assignmentRepo.CreateAssignment(tx, assignment); err != nil {
+		return errors.New("error al guardar la asignacion")
+	}
+	if _, err := assignmentRepo.SyncWorkerStores(tx, assignment.WorkerID, time.Now().Format("2006-01-02")); err != nil {
+		return errors.New("error al actualizar la tienda del trabajador")
+	}
+	return nil
+}
+
+// GetWorkerAssignments - Obtiene el historial de tiendas de un trabajador
+// -------------------------------------------------------------------
+func (s *AssignmentService) GetWorkerAssignments(workerID string) ([]models.StoreAssignment, error) {
+	if _, err := s.workerRepo.FindWorkerByID(workerID); err != nil {
+		return nil, errors.New("el trabajador no existe")
+	}
+	assignments, err := s.assignmentRepo.GetWorkerAssignments(nil, workerID, "")
+	if err != nil {
+		return nil, errors.New("error al obtener las asignaciones del trabajador")
+	}
+	trimAssignmentDates(assignments)
+	return assignments, nil
+}
+
+// TransferWorker - Traslada a un trabajador a otra tienda principal desde una fecha
+// -------------------------------------------------------------------
+// La asignacion principal anterior termina el dia antes. Si la fecha es
+// futura el traslado queda programado y lo aplica la tarea diaria.
+func (s *AssignmentService) TransferWorker(userID, workerID string, assignment *models.StoreAssignment) error {
+	date, err := time.Parse("2006-01-02", assignment.StartDate)
+	if err != nil {
+		return errors.New("la fecha del traslado no tiene el formato YYYY-MM-DD")
+	}
+	if _, err := s.storeRepo.FindStoreByID(assignment.StoreID); err != nil {
+		return errors.New("la tienda no existe")
+	}
+	assignment.ID = 0
+	assignment.WorkerID = workerID
+	assignment.Kind = models.AssignmentPrimary
+	assignment.EndDate = nil
+	assignment.CreatedByID = userID
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := s.workerRepo.LockWorker(tx, workerID); err != nil {
+		tx.Rollback()
+		return errors.New("el trabajador no existe")
+	}
+	primaries, err := s.assignmentRepo.GetWorkerAssignments(tx, workerID, models.AssignmentPrimary)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al obtener las asignaciones del trabajador")
+	}
+	trimAssignmentDates(primaries)
+
+	// Cerramos la asignacion principal que sigue abierta el dia del traslado
+	for _, primary := range primaries {
+		if primary.StartDate >= assignment.StartDate {
+			tx.Rollback()
+			return fmt.Errorf("ya hay una asignacion a %s desde el %s; cancelala antes", primary.StoreName, primary.StartDate)
+		}
+		if primary.EndDate == nil || *primary.EndDate >= assignment.StartDate {
+			if primary.StoreID == assignment.StoreID {
+				tx.Rollback()
+				return fmt.Errorf("el trabajador ya esta asignado a %s", primary.StoreName)
+			}
+			dayBefore := date.AddDate(0, 0, -1).Format("2006-01-02")
+			if err := s.assignmentRepo.SetAssignmentEnd(tx, primary.ID, &dayBefore); err != nil {
+				tx.Rollback()
+				return errors.New("error al cerrar la asignacion anterior")
+			}
+		}
+	}
+
+	if err := saveAssignment(tx, s.assignmentRepo, assignment); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// AddSecondaryStore - Permite a un trabajador fichar tambien en otra tienda
+// -------------------------------------------------------------------
+func (s *AssignmentService) AddSecondaryStore(userID, workerID string, assignment *models.StoreAssignment) error {
+	if _, err := s.workerRepo.FindWorkerByID(workerID); err != nil {
+		return errors.New("el trabajador no existe")
+	}
+	if _, err := s.storeRepo.FindStoreByID(assignment.StoreID); err != nil {
+		return errors.New("la tienda no existe")
+	}
+	assignment.ID = 0
+	assignment.WorkerID = workerID
+	assignment.Kind = models.AssignmentSecondary
+	assignment.CreatedByID = userID
+	if err := utils.ValidateAssignmentFields(assignment); err != nil {
+		return err
+	}
+	if err := saveAssignment(nil, s.assignmentRepo, assignment); err != nil {
+		return err
+	}
+	return nil
+}
+
+// EndAssignment - Pone fecha de fin a una asignacion
+// -------------------------------------------------------------------
+// Si se termina la principal el trabajador se queda sin tienda desde el dia siguiente.
+func (s *AssignmentService) EndAssignment(assignmentID int, endDate string) error {
+	assignment, err := s.assignmentRepo.FindAssignmentByID(assignmentID)
+	if err != nil {
+		return errors.New("la asignacion no existe")
+	}
+	assignments := []models.StoreAssignment{*assignment}
+	trimAssignmentDates(assignments)
+	*assignment = assignments[0]
+	if assignment.EndDate != nil && *assignment.EndDate < endDate {
+		return fmt.Errorf("la asignacion ya termina el %s", *assignment.EndDate)
+	}
+	assignment.EndDate = &endDate
+	if err := utils.ValidateAssignmentFields(assignment); err != nil {
+		return err
+	}
+
+	if err := s.assignmentRepo.SetAssignmentEnd(nil, assignment.ID, assignment.EndDate); err != nil {
+		return errors.New("error al actualizar la asignacion")
+	}
+	if _, err := s.assignmentRepo.SyncWorkerStores(nil, assignment.WorkerID, time.Now().Format("2006-01-02")); err != nil {
+		return errors.New("error al actualizar la tienda del trabajador")
+	}
+	return nil
+}
+
+// CancelAssignment - Anula una asignacion que aun no ha empezado
+// -------------------------------------------------------------------
+// Al anular un traslado programado la asignacion principal anterior vuelve
+// a quedar abierta.
+func (s *AssignmentService) CancelAssignment(assignmentID int) error {
+	assignment, err := s.assignmentRepo.FindAssignmentByID(assignmentID)
+	if err != nil {
+		return errors.New("la asignacion no existe")
+	}
+	start := assignment.StartDate[:10]
+	if start <= time.Now().Format("2006-01-02") {
+		return errors.New("solo se pueden anular asignaciones que aun no han empezado")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := s.workerRepo.LockWorker(tx, assignment.WorkerID); err != nil {
+		tx.Rollback()
+		return errors.New("el trabajador no existe")
+	}
+	if err := s.assignmentRepo.DeleteAssignment(tx, assignment.ID); err != nil {
+		tx.Rollback()
+		return errors.New("error al anular la asignacion")
+	}
+
+	// Reabrimos la principal que terminaba justo antes del traslado
+	if assignment.Kind == models.AssignmentPrimary {
+		startDate, _ := utils.ParseDate(start)
+		dayBefore := startDate.AddDate(0, 0, -1).Format("2006-01-02")
+		primaries, err := s.assignmentRepo.GetWorkerAssignments(tx, assignment.WorkerID, models.AssignmentPrimary)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("error al obtener las asignaciones del trabajador")
+		}
+		trimAssignmentDates(primaries)
+		for _, primary := range primaries {
+			if primary.EndDate != nil && *primary.EndDate == dayBefore {
+				if err := s.assignmentRepo.SetAssignmentEnd(tx, primary.ID, nil); err != nil {
+					tx.Rollback()
+					return errors.New("error al reabrir la asignacion anterior")
+				}
+			}
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// ApplyScheduledTransfers - Aplica los traslados de tienda que empiezan hoy
+// -------------------------------------------------------------------
+// Tarea diaria: pone a cada trabajador con asignaciones en su tienda principal en vigor.
+func (s *AssignmentService) ApplyScheduledTransfers() (string, error) {
+	changed, err := s.assignmentRepo.SyncWorkerStores(nil, "", time.Now().Format("2006-01-02"))
+	if err != nil {
+		return "", errors.New("error al aplicar los traslados de tienda")
+	}
+	return fmt.Sprintf("%d trabajadores cambiados de tienda", changed), nil
+}
@@ -44,12 +44,12 @@ func (s *AuthService) LoginAdmin(username, password string) (string, error) {
 
 // Login - Panel de tiendas
 // --------------------------------------------------------------------
-func (s *AdminService) LoginStore(username, password string) (string, error) {
+func (s *AuthService) LoginStore(username, password string) (string, error) {
 
 	// Buscamos el usuario por el username
 	user, err := s.userRepo.FindUserByUsername(nil, username)
-	if err != nil || user.Role != "store" {
-		return "", errors.New("credenciales del usuario invalidas ")
+	if err != nil || user == nil || user.Role != "store" {
+		return "", errors.New("credenciales del usuario invalidas")
 	}
 
 	// Comprobamos que el password sea correcto
@@ -66,6 +66,30 @@ func (s *AdminService) LoginStore(username, password string) (string, error) {
 	return token, nil
 }
 
+// Login - App de trabajadores
+// --------------------------------------------------------------------
+func (s *AuthService) LoginWorker(username, password string) (string, error) {
+
+	// Buscamos el usuario por el username
+	user, err := s.userRepo.FindUserByUsername(nil, username)
+	if err != nil || user == nil || user.Role != "worker" {
+		return "", errors.New("credenciales del trabajador invalidas")
+	}
+
+	// Comprobamos que el PIN sea correcto
+	if !utils.CheckPassword(user.Password, password) {
+		return "", errors.New("credenciales del trabajador invalidas")
+	}
+
+	// Generamos el token JWT
+	token, err := utils.GenerateJWT(user.ID, user.Role)
+	if err != nil {
+		return "", errors.New("error al generar el token de auth")
+	}
+
+	return token, nil
+}
+
 // Logout
 // --------------------------------------------------------------------
 func (s *AuthService) LogoutAdmin(token string) error {
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+)
+
+// Rango de fechas que se publica en los calendarios
+const (
+	calendarPastDays   = 60
+	calendarFutureDays = 365
+)
+
+type CalendarService struct {
+	calendarRepo *repositories.CalendarRepository
+	shiftRepo    *repositories.ShiftRepository
+	holidaysRepo *repositories.HolidaysRepository
+	workerRepo   *repositories.WorkerRepository
+	storeRepo    *repositories.StoreRepository
+}
+
+func NewCalendarService(
+	calendarRepo *repositories.CalendarRepository,
+	shiftRepo *repositories.ShiftRepository,
+	holidaysRepo *repositories.HolidaysRepository,
+	workerRepo *repositories.WorkerRepository,
+	storeRepo *repositories.StoreRepository) *CalendarService {
+	return &CalendarService{
+		calendarRepo: calendarRepo,
+		shiftRepo:    shiftRepo,
+		holidaysRepo: holidaysRepo,
+		workerRepo:   workerRepo,
+		storeRepo:    storeRepo,
+	}
+}
+
+// shiftEventUID - UID estable del evento de un turno
+func shiftEventUID(shiftID int) string {
+	return fmt.Sprintf("shift-%d@worker-hub", shiftID)
+}
+
+// holidayEventUID - UID estable del evento de unas vacaciones
+func holidayEventUID(holidayID int) string {
+	return fmt.Sprintf("holiday-%d@worker-hub", holidayID)
+}
+
+// isApprovedHoliday - Indica si unas vacaciones estan aprobadas o disfrutadas
+func isApprovedHoliday(holiday *models.Holiday) bool {
+	return holiday.Status == models.HolidayStatusApproved || holiday.Status == models.HolidayStatusTaken
+}
+
+// holidaySummary - Titulo del evento de una ausencia
+// -------------------------------------------------------------------
+// El resto de ausencias se publican sin detalle porque pueden revelar datos medicos.
+func holidaySummary(holiday *models.Holiday) string {
+	if holiday.LeaveType == "" || holiday.LeaveType == models.LeaveTypeVacation {
+		return "Vacaciones"
+	}
+	return "Ausencia"
+}
+
+// newShiftCancellation - Prepara la cancelacion del evento de un turno en un calendario
+// -------------------------------------------------------------------
+func newShiftCancellation(shift *models.WorkShift, workerID, storeID *string) (*models.CalendarCancellation, error) {
+	start, end, err := shiftWindow(shift)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CalendarCancellation{
+		UID:      shiftEventUID(shift.ID),
+		WorkerID: workerID,
+		StoreID:  storeID,
+		Summary:  "Turno",
+		StartsAt: start,
+		EndsAt:   end,
+	}, nil
+}
+
+// newHolidayCancellation - Prepara la cancelacion del evento de unas vacaciones en un calendario
+// -------------------------------------------------------------------
+func newHolidayCancellation(holiday *models.Holiday, workerID, storeID *string) (*models.CalendarCancellation, error) {
+	start, err := utils.ParseDate(holiday.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := utils.ParseDate(holiday.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CalendarCancellation{
+		UID:      holidayEventUID(holiday.ID),
+		WorkerID: workerID,
+		StoreID:  storeID,
+		Summary:  holidaySummary(holiday),
+		StartsAt: start,
+		EndsAt:   end.AddDate(0, 0, 1),
+		AllDay:   true,
+	}, nil
+}
+
+// newFeedToken - Genera un token aleatorio para la URL de suscripcion
+// -------------------------------------------------------------------
+func newFeedToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createFeed - Crea una suscripcion revocando las anteriores del mismo propietario
+// -------------------------------------------------------------------
+func (s *CalendarService) createFeed(workerID, storeID *string) (*models.CalendarFeed, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, errors.New("error al generar el token del calendario")
+	}
+
+	if err := s.calendarRepo.RevokeOwnerFeeds(workerID, storeID); err != nil {
+		return nil, errors.New("error al revocar las suscripciones anteriores")
+	}
+
+	feed := &models.CalendarFeed{
+		ID:       uuid.New().String(),
+		Token:    token,
+		WorkerID: workerID,
+		StoreID:  storeID,
+	}
+	if err := s.calendarRepo.CreateFeed(feed); err != nil {
+		return nil, errors.New("error al crear la suscripcion del calendario")
+	}
+	return feed, nil
+}
+
+// CreateOwnFeed - Crea (o renueva) la suscripcion del trabajador o la tienda autenticada
+// -------------------------------------------------------------------
+func (s *CalendarService) CreateOwnFeed(userID, role string) (*models.CalendarFeed, error) {
+	if role == "store" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		return s.createFeed(nil, &store.ID)
+	}
+
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+	return s.createFeed(&worker.ID, nil)
+}
+
+// RevokeOwnFeed - Revoca la suscripcion del trabajador o la tienda autenticada
+// -------------------------------------------------------------------
+func (s *CalendarService) RevokeOwnFeed(userID, role string) error {
+	if role == "store" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return errors.New("no se encontro la tienda del usuario")
+		}
+		return s.calendarRepo.RevokeOwnerFeeds(nil, &store.ID)
+	}
+
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+	return s.calendarRepo.RevokeOwnerFeeds(&worker.ID, nil)
+}
+
+// CreateFeed - Crea la suscripcion de un trabajador o una tienda desde el panel de admin
+// -------------------------------------------------------------------
+func (s *CalendarService) CreateFeed(workerID, storeID string) (*models.CalendarFeed, error) {
+	if (workerID == "") == (storeID == "") {
+		return nil, errors.New("indica un trabajador o una tienda")
+	}
+	if workerID != "" {
+		if _, err := s.workerRepo.FindWorkerByID(workerID); err != nil {
+			return nil, errors.New("el trabajador no existe")
+		}
+		return s.createFeed(&workerID, nil)
+	}
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return nil, errors.New("la tienda no existe")
+	}
+	return s.createFeed(nil, &storeID)
+}
+
+// GetFeeds - Obtiene las suscripciones filtrando por trabajador o tienda
+// -------------------------------------------------------------------
+func (s *CalendarService) GetFeeds(workerID, storeID string) ([]models.CalendarFeed, error) {
+	return s.calendarRepo.GetFeeds(workerID, storeID)
+}
+
+// RevokeFeed - Revoca una suscripcion por su ID
+// -------------------------------------------------------------------
+func (s *CalendarService) RevokeFeed(feedID string) error {
+	return s.calendarRepo.RevokeFeed(feedID)
+}
+
+// RenderFeed - Genera el calendario iCalendar de una suscripcion
+// -------------------------------------------------------------------
+// Devuelve el nombre del calendario y su contenido.
+func (s *CalendarService) RenderFeed(token string) (string, string, error) {
+	feed, err := s.calendarRepo.FindActiveFeedByToken(token)
+	if err != nil {
+		return "", "", errors.New("calendario no encontrado")
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -calendarPastDays)
+	from := since.Format("2006-01-02")
+	to := today.AddDate(0, 0, calendarFutureDays).Format("2006-01-02")
+
+	// Nombres de tiendas y trabajadores para los textos de los eventos
+	stores, err := s.storeRepo.GetAllStores()
+	if err != nil {
+		return "", "", errors.New("error al obtener las tiendas")
+	}
+	storeNames := make(map[string]models.Store, len(stores))
+	for _, store := range stores {
+		storeNames[store.ID] = store
+	}
+
+	var name string
+	var workers []models.Worker
+	var shifts []models.WorkShift
+	var cancellations []models.CalendarCancellation
+
+	if feed.WorkerID != nil {
+		worker, err := s.workerRepo.FindWorkerByID(*feed.WorkerID)
+		if err != nil {
+			return "", "", errors.New("el trabajador no existe")
+		}
+		name = fmt.Sprintf("Turnos de %s %s", worker.Name, worker.LastName)
+		workers = []models.Worker{*worker}
+		shifts, err = s.shiftRepo.GetShifts(from, to, "", worker.ID)
+		if err != nil {
+			return "", "", errors.New("error al obtener los turnos")
+		}
+		cancellations, err = s.calendarRepo.GetCancellations(worker.ID, "", since)
+		if err != nil {
+			return "", "", errors.New("error al obtener los eventos cancelados")
+		}
+	} else {
+		store, ok := storeNames[*feed.StoreID]
+		if !ok {
+			return "", "", errors.New("la tienda no existe")
+		}
+		name = "Turnos de " + store.Name
+		workers, err = s.workerRepo.GetWorkersByStore(store.ID)
+		if err != nil {
+			return "", "", errors.New("error al obtener los trabajadores")
+		}
+		shifts, err = s.shiftRepo.GetShifts(from, to, store.ID, "")
+		if err != nil {
+			return "", "", errors.New("error al obtener los turnos")
+		}
+		cancellations, err = s.calendarRepo.GetCancellations("", store.ID, since)
+		if err != nil {
+			return "", "", errors.New("error al obtener los eventos cancelados")
+		}
+	}
+
+	workerNames := make(map[string]string, len(workers))
+	workerIDs := make([]string, 0, len(workers))
+	for _, worker := range workers {
+		workerNames[worker.ID] = worker.Name + " " + worker.LastName
+		workerIDs = append(workerIDs, worker.ID)
+	}
+
+	events := []utils.ICalEvent{}
+	active := make(map[string]bool)
+
+	// Turnos
+	for i := range shifts {
+		start, end, err := shiftWindow(&shifts[i])
+		if err != nil {
+			return "", "", err
+		}
+		store := storeNames[shifts[i].Store]
+		event := utils.ICalEvent{
+			UID:      shiftEventUID(shifts[i].ID),
+			Summary:  "Turno - " + store.Name,
+			Location: store.Name,
+			Start:    start,
+			End:      end,
+		}
+		if store.City != "" {
+			event.Location = store.Name + ", " + store.City
+		}
+		if feed.StoreID != nil {
+			event.Summary = "Turno abierto"
+			if workerID := assignedWorker(&shifts[i]); workerID != "" {
+				event.Summary = "Turno - " + workerNames[workerID]
+			}
+		}
+		events = append(events, event)
+		active[event.UID] = true
+	}
+
+	// Vacaciones
+	if len(workerIDs) > 0 {
+		holidays, err := s.holidaysRepo.GetHolidaysForWorkers(workerIDs, from, to)
+		if err != nil {
+			return "", "", errors.New("error al obtener las vacaciones")
+		}
+		for i := range holidays {
+			if !isApprovedHoliday(&holidays[i]) {
+				continue
+			}
+			start, err := utils.ParseDate(holidays[i].StartDate)
+			if err != nil {
+				return "", "", err
+			}
+			end, err := utils.ParseDate(holidays[i].EndDate)
+			if err != nil {
+				return "", "", err
+			}
+			event := utils.ICalEvent{
+				UID:     holidayEventUID(holidays[i].ID),
+				Summary: holidaySummary(&holidays[i]),
+				Start:   start,
+				End:     end.AddDate(0, 0, 1),
+				AllDay:  true,
+			}
+			if feed.StoreID != nil {
+				event.Summary = holidaySummary(&holidays[i]) + " - " + workerNames[holidays[i].WorkerID]
+			}
+			events = append(events, event)
+			active[event.UID] = true
+		}
+	}
+
+	// Eventos cancelados que ya no estan activos en este calendario
+	for _, cancellation := range cancellations {
+		if active[cancellation.UID] {
+			continue
+		}
+		events = append(events, utils.ICalEvent{
+			UID:       cancellation.UID,
+			Summary:   cancellation.Summary,
+			Start:     cancellation.StartsAt,
+			End:       cancellation.EndsAt,
+			AllDay:    cancellation.AllDay,
+			Cancelled: true,
+		})
+		active[cancellation.UID] = true
+	}
+
+	return name, utils.BuildICalendar(name, events), nil
+}
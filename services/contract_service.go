@@ -0,0 +1,558 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/config"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Dias de antelacion por defecto del aviso de fin del periodo de prueba
+const defaultTrialAlertDays = 15
+
+type ContractService struct {
+	contractRepo *repositories.ContractRepository
+	workerRepo   *repositories.WorkerRepository
+	storeRepo    *repositories.StoreRepository
+	userRepo     *repositories.UserRepository
+	notifyRepo   *repositories.NotificationRepository
+
+	db *gorm.DB
+}
+
+func NewContractService(
+	contractRepo *repositories.ContractRepository,
+	workerRepo *repositories.WorkerRepository,
+	storeRepo *repositories.StoreRepository,
+	userRepo *repositories.UserRepository,
+	notifyRepo *repositories.NotificationRepository,
+	db *gorm.DB) *ContractService {
+	return &ContractService{
+		contractRepo: contractRepo,
+		workerRepo:   workerRepo,
+		storeRepo:    storeRepo,
+		userRepo:     userRepo,
+		notifyRepo:   notifyRepo,
+		db:           db,
+	}
+}
+
+// trimDate - Deja una fecha opcional en formato YYYY-MM-DD
+func trimDate(date *string) *string {
+	if date == nil {
+		return nil
+	}
+	trimmed := (*date)[:10]
+	return &trimmed
+}
+
+// trimContractDates - Deja las fechas de los contratos en formato YYYY-MM-DD
+func trimContractDates(contracts []models.Contract) {
+	for i := range contracts {
+		contracts[i].StartDate = contracts[i].StartDate[:10]
+		contracts[i].EndDate = trimDate(contracts[i].EndDate)
+		contracts[i].TrialStartDate = trimDate(contracts[i].TrialStartDate)
+		contracts[i].TrialEndDate = trimDate(contracts[i].TrialEndDate)
+		contracts[i].TrialOutcomeDate = trimDate(contracts[i].TrialOutcomeDate)
+	}
+}
+
+// workerStatusFromContracts - Estado, prueba y fecha de alta de un trabajador segun sus contratos
+// -------------------------------------------------------------------
+// Esta de Alta si algun contrato cubre el dia de hoy, y a prueba si ese
+// contrato tiene un periodo de prueba en curso sin resultado. La fecha de alta es el
+// inicio de la cadena de contratos sin huecos (las renovaciones) que acaba en
+// el contrato en vigor, o en el ultimo que empezo si no hay ninguno. Los
+// contratos deben venir ordenados por fecha de inicio.
+func workerStatusFromContracts(contracts []models.Contract, today string) (string, string, *string) {
+	status, prueba := "Baja", "No"
+	current := 0
+	for i := range contracts {
+		if contracts[i].StartDate > today {
+			break
+		}
+		current = i
+		if contracts[i].Covers(today) {
+			status = "Alta"
+			if contracts[i].InTrial(today) {
+				prueba = "Si"
+			}
+			break
+		}
+	}
+
+	for current > 0 {
+		previous := contracts[current-1]
+		if previous.EndDate == nil {
+			break
+		}
+		previousEnd, _ := utils.ParseDate(*previous.EndDate)
+		if previousEnd.AddDate(0, 0, 1).Format("2006-01-02") < contracts[current].StartDate {
+			break
+		}
+		current--
+	}
+	hireDate := contracts[current].StartDate
+	return status, prueba, &hireDate
+}
+
+// syncWorkerStatus - Recalcula el estado, la prueba y la fecha de alta de un trabajador con contratos
+// -------------------------------------------------------------------
+// Los trabajadores sin contratos registrados conservan el estado manual.
+func syncWorkerStatus(tx *gorm.DB, contractRepo *repositories.ContractRepository, workerRepo *repositories.WorkerRepository, workerID string) error {
+	contracts, err := contractRepo.GetWorkerContracts(tx, workerID)
+	if err != nil {
+		return errors.New("error al obtener los contratos del trabajador")
+	}
+	if len(contracts) == 0 {
+		return nil
+	}
+	trimContractDates(contracts)
+
+	status, prueba, hireDate := workerStatusFromContracts(contracts, time.Now().Format("2006-01-02"))
+	if err := workerRepo.UpdateWorkerStatus(tx, workerID, status, prueba, hireDate); err != nil {
+		return errors.New("error al actualizar el estado del trabajador")
+	}
+	return nil
+}
+
+// CreateContract - Registra un contrato nuevo de un trabajador
+// -------------------------------------------------------------------
+// Las renovaciones se registran como contratos nuevos para conservar el historial.
+func (s *ContractService) CreateContract(workerID string, contract *models.Contract) error {
+	if _, err := s.workerRepo.FindWorkerByID(workerID); err != nil {
+		return errors.New("el trabajador no existe")
+	}
+	contract.ID = uuid.New().String()
+	contract.WorkerID = workerID
+	contract.TrialEndDate = nil
+	contract.TrialOutcome = ""
+	contract.TrialOutcomeDate = nil
+	contract.TrialNotes = ""
+	return s.saveContract(contract, true)
+}
+
+// UpdateContract - Corrige los datos de un contrato
+// -------------------------------------------------------------------
+func (s *ContractService) UpdateContract(contractID string, contract *models.Contract) error {
+	current, err := s.contractRepo.FindContractByID(contractID)
+	if err != nil {
+		return errors.New("el contrato no existe")
+	}
+	contract.ID = current.ID
+	contract.WorkerID = current.WorkerID
+	contract.CreatedAt = current.CreatedAt
+
+	// El resultado de la prueba solo se registra con RecordTrialOutcome
+	contract.TrialEndDate = current.TrialEndDate
+	contract.TrialOutcome = current.TrialOutcome
+	contract.TrialOutcomeDate = current.TrialOutcomeDate
+	contract.TrialNotes = current.TrialNotes
+	contract.TrialAlertedAt = current.TrialAlertedAt
+	return s.saveContract(contract, false)
+}
+
+// EndContract - Pone fecha de fin a un contrato
+// -------------------------------------------------------------------
+func (s *ContractService) EndContract(contractID, endDate string) error {
+	contract, err := s.contractRepo.FindContractByID(contractID)
+	if err != nil {
+		return errors.New("el contrato no existe")
+	}
+	if endDate == "" {
+		return errors.New("la fecha de fin es obligatoria")
+	}
+	contracts := []models.Contract{*contract}
+	trimContractDates(contracts)
+	*contract = contracts[0]
+	contract.EndDate = &endDate
+	return s.saveContract(contract, false)
+}
+
+// applyTrialPeriod - Calcula el fin del periodo de prueba de un contrato
+// -------------------------------------------------------------------
+// Sin duracion indicada se toma la de la regla mas concreta para la
+// modalidad y la categoria; sin regla no hay periodo de prueba. Empieza con
+// el contrato salvo que se indique otra fecha y no pasa del fin del contrato.
+func (s *ContractService) applyTrialPeriod(contract *models.Contract) error {
+	if contract.TrialDays == nil {
+		days := 0
+		rule, err := s.contractRepo.FindTrialRule(contract.Type, contract.Category)
+		if err == nil {
+			days = rule.Days
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("error al obtener las reglas del periodo de prueba")
+		}
+		contract.TrialDays = &days
+	}
+	if *contract.TrialDays == 0 {
+		contract.TrialStartDate = nil
+		contract.TrialEndDate = nil
+		return nil
+	}
+
+	if contract.TrialStartDate == nil {
+		start := contract.StartDate
+		contract.TrialStartDate = &start
+	}
+	start, err := utils.ParseDate(*contract.TrialStartDate)
+	if err != nil {
+		return err
+	}
+	end := start.AddDate(0, 0, *contract.TrialDays-1).Format("2006-01-02")
+	if contract.EndDate != nil && *contract.EndDate < end {
+		end = *contract.EndDate
+	}
+	contract.TrialEndDate = &end
+	return nil
+}
+
+// saveContract - Valida y guarda un contrato y recalcula el estado del trabajador
+// -------------------------------------------------------------------
+// Los contratos de un mismo trabajador no pueden solaparse.
+func (s *ContractService) saveContract(contract *models.Contract, create bool) error {
+	if err := utils.ValidateContractFields(contract); err != nil {
+		return err
+	}
+	previousTrialEnd := contract.TrialEndDate
+	if err := s.applyTrialPeriod(contract); err != nil {
+		return err
+	}
+	if previousTrialEnd == nil || contract.TrialEndDate == nil || (*previousTrialEnd)[:10] != *contract.TrialEndDate {
+		contract.TrialAlertedAt = nil // Se vuelve a avisar con la nueva fecha
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloqueamos al trabajador para que dos altas simultaneas no se solapen
+	if _, err := s.workerRepo.LockWorker(tx, contract.WorkerID); err != nil {
+		tx.Rollback()
+		return errors.New("el trabajador no existe")
+	}
+
+	other, err := s.contractRepo.FindOverlappingContract(tx, contract.WorkerID, contract.StartDate, contract.EndDate, contract.ID)
+	if err == nil {
+		tx.Rollback()
+		end := "sin fecha de fin"
+		if other.EndDate != nil {
+			end = "hasta el " + (*other.EndDate)[:10]
+		}
+		return fmt.Errorf("se solapa con el contrato %s desde el %s %s", other.Type, other.StartDate[:10], end)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		tx.Rollback()
+		return errors.New("error al comprobar los contratos del trabajador")
+	}
+
+	if create {
+		err = s.contractRepo.CreateContract(tx, contract)
+	} else {
+		err = s.contractRepo.UpdateContract(tx, contract)
+	}
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al guardar el contrato")
+	}
+
+	if err := syncWorkerStatus(tx, s.contractRepo, s.workerRepo, contract.WorkerID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetWorkerContracts - Obtiene el historial de contratos de un trabajador
+// -------------------------------------------------------------------
+func (s *ContractService) GetWorkerContracts(workerID string) ([]models.Contract, error) {
+	if _, err := s.workerRepo.FindWorkerByID(workerID); err != nil {
+		return nil, errors.New("el trabajador no existe")
+	}
+	contracts, err := s.contractRepo.GetWorkerContracts(nil, workerID)
+	if err != nil {
+		return nil, errors.New("error al obtener los contratos")
+	}
+	trimContractDates(contracts)
+	return contracts, nil
+}
+
+// GetOwnContracts - Obtiene el historial de contratos del trabajador autenticado
+// -------------------------------------------------------------------
+func (s *ContractService) GetOwnContracts(userID string) ([]models.Contract, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+	return s.GetWorkerContracts(worker.ID)
+}
+
+// RefreshWorkerStatuses - Recalcula el estado de los trabajadores con contratos
+// -------------------------------------------------------------------
+// Tarea diaria: da de alta a quien empieza contrato hoy y de baja a quien lo
+// termino ayer.
+func (s *ContractService) RefreshWorkerStatuses() (string, error) {
+	workerIDs, err := s.contractRepo.GetContractedWorkerIDs()
+	if err != nil {
+		return "", errors.New("error al obtener los trabajadores con contrato")
+	}
+	for _, workerID := range workerIDs {
+		if err := syncWorkerStatus(nil, s.contractRepo, s.workerRepo, workerID); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%d trabajadores con contrato revisados", len(workerIDs)), nil
+}
+
+// trialAlertDays - Dias de antelacion del aviso de fin de prueba segun la configuracion
+func trialAlertDays() int {
+	if days, err := strconv.Atoi(config.Env.TrialAlertDays); err == nil && days >= 0 {
+		return days
+	}
+	return defaultTrialAlertDays
+}
+
+// RecordTrialOutcome - Registra si el trabajador supera el periodo de prueba
+// -------------------------------------------------------------------
+// Los encargados solo pueden decidir sobre los trabajadores de su tienda.
+// Si no lo supera el contrato termina en la fecha de la decision y el
+// trabajador pasa a Baja; en ambos casos deja de estar a prueba.
+func (s *ContractService) RecordTrialOutcome(userID, role, contractID string, request *dtos.TrialOutcomeRequest) error {
+	if request.Outcome != models.TrialOutcomePassed && request.Outcome != models.TrialOutcomeFailed {
+		return errors.New("el resultado debe ser superado o no superado")
+	}
+	date := request.Date
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return errors.New("la fecha no tiene el formato YYYY-MM-DD")
+	}
+
+	contract, err := s.contractRepo.FindContractByID(contractID)
+	if err != nil {
+		return errors.New("el contrato no existe")
+	}
+	contracts := []models.Contract{*contract}
+	trimContractDates(contracts)
+	*contract = contracts[0]
+
+	worker, err := s.workerRepo.FindWorkerByID(contract.WorkerID)
+	if err != nil {
+		return errors.New("el trabajador no existe")
+	}
+	if role == "store" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return errors.New("no se encontro la tienda del usuario")
+		}
+		if worker.StoreID == nil || *worker.StoreID != store.ID {
+			return errors.New("el trabajador no pertenece a tu tienda")
+		}
+	}
+
+	if contract.TrialEndDate == nil {
+		return errors.New("el contrato no tiene periodo de prueba")
+	}
+	if contract.TrialOutcome != "" {
+		return fmt.Errorf("el periodo de prueba ya se registro como %s", contract.TrialOutcome)
+	}
+	if date < *contract.TrialStartDate {
+		return errors.New("la fecha no puede ser anterior al inicio del periodo de prueba")
+	}
+	if request.Outcome == models.TrialOutcomeFailed {
+		if date > *contract.TrialEndDate {
+			return fmt.Errorf("el periodo de prueba termino el %s", *contract.TrialEndDate)
+		}
+		contract.EndDate = &date
+		contract.TrialEndDate = &date
+	}
+	contract.TrialOutcome = request.Outcome
+	contract.TrialOutcomeDate = &date
+	contract.TrialNotes = request.Notes
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := s.workerRepo.LockWorker(tx, contract.WorkerID); err != nil {
+		tx.Rollback()
+		return errors.New("el trabajador no existe")
+	}
+	if err := s.contractRepo.UpdateContract(tx, contract); err != nil {
+		tx.Rollback()
+		return errors.New("error al guardar el contrato")
+	}
+	if err := syncWorkerStatus(tx, s.contractRepo, s.workerRepo, contract.WorkerID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Avisamos a los administradores de la decision del encargado
+	if role == "store" {
+		title := fmt.Sprintf("%s %s: periodo de prueba %s", worker.Name, worker.LastName, request.Outcome)
+		if err := notifyAdmins(tx, s.userRepo, s.notifyRepo, "resultado_periodo_prueba", title, request.Notes); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetUpcomingTrials - Obtiene los periodos de prueba sin decidir que terminan en los proximos dias
+// -------------------------------------------------------------------
+// Sin dias se usa la antelacion del aviso. Los encargados solo ven su tienda.
+func (s *ContractService) GetUpcomingTrials(userID, role string, days int) ([]dtos.TrialPeriodAlert, error) {
+	if days <= 0 {
+		days = trialAlertDays()
+	}
+	storeID := ""
+	if role == "store" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+
+	today, _ := utils.ParseDate(time.Now().Format("2006-01-02"))
+	trials, err := s.contractRepo.GetPendingTrials(today.Format("2006-01-02"), today.AddDate(0, 0, days).Format("2006-01-02"), storeID, false)
+	if err != nil {
+		return nil, errors.New("error al obtener los periodos de prueba")
+	}
+	for i := range trials {
+		trials[i].TrialStartDate = trials[i].TrialStartDate[:10]
+		trials[i].TrialEndDate = trials[i].TrialEndDate[:10]
+		end, _ := utils.ParseDate(trials[i].TrialEndDate)
+		trials[i].DaysLeft = int(end.Sub(today).Hours() / 24)
+	}
+	return trials, nil
+}
+
+// SendTrialAlerts - Avisa de los periodos de prueba que terminan pronto
+// -------------------------------------------------------------------
+// Tarea diaria: avisa una sola vez a los administradores y a la tienda del
+// trabajador de cada periodo sin decidir que termina dentro de la antelacion.
+func (s *ContractService) SendTrialAlerts() (string, error) {
+	today := time.Now()
+	trials, err := s.contractRepo.GetPendingTrials(today.Format("2006-01-02"),
+		today.AddDate(0, 0, trialAlertDays()).Format("2006-01-02"), "", true)
+	if err != nil {
+		return "", errors.New("error al obtener los periodos de prueba")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return "", errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, trial := range trials {
+		title := fmt.Sprintf("El periodo de prueba de %s %s termina el %s", trial.WorkerName, trial.WorkerLastName, trial.TrialEndDate[:10])
+		body := "Registra si supera o no el periodo de prueba antes de esa fecha"
+		if err := notifyAdmins(tx, s.userRepo, s.notifyRepo, "fin_periodo_prueba", title, body); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+		if trial.StoreID != "" {
+			if store, err := s.storeRepo.FindStoreByID(trial.StoreID); err == nil {
+				notification := &models.Notification{
+					UserID: store.UserID,
+					Type:   "fin_periodo_prueba",
+					Title:  title,
+					Body:   body,
+				}
+				if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+					tx.Rollback()
+					return "", errors.New("error al notificar a la tienda")
+				}
+			}
+		}
+		if err := s.contractRepo.MarkTrialAlerted(tx, trial.ContractID); err != nil {
+			tx.Rollback()
+			return "", errors.New("error al marcar el aviso como enviado")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return "", errors.New("error al confirmar la transaccion")
+	}
+	return fmt.Sprintf("%d avisos de fin de periodo de prueba", len(trials)), nil
+}
+
+// GetTrialRules - Obtiene las reglas de duracion del periodo de prueba
+// -------------------------------------------------------------------
+func (s *ContractService) GetTrialRules() ([]models.TrialPeriodRule, error) {
+	rules, err := s.contractRepo.GetTrialRules()
+	if err != nil {
+		return nil, errors.New("error al obtener las reglas del periodo de prueba")
+	}
+	return rules, nil
+}
+
+// SaveTrialRule - Crea o sustituye la regla de una modalidad y categoria
+// -------------------------------------------------------------------
+// Solo afecta a los contratos que se guarden a partir de ahora.
+func (s *ContractService) SaveTrialRule(rule *models.TrialPeriodRule) error {
+	if err := utils.ValidateTrialRuleFields(rule); err != nil {
+		return err
+	}
+	if err := s.contractRepo.SaveTrialRule(rule); err != nil {
+		return errors.New("error al guardar la regla del periodo de prueba")
+	}
+	return nil
+}
+
+// DeleteTrialRule - Elimina una regla del periodo de prueba
+// -------------------------------------------------------------------
+func (s *ContractService) DeleteTrialRule(ruleID int) error {
+	deleted, err := s.contractRepo.DeleteTrialRule(ruleID)
+	if err != nil {
+		return errors.New("error al eliminar la regla del periodo de prueba")
+	}
+	if deleted == 0 {
+		return errors.New("la regla del periodo de prueba no existe")
+	}
+	return nil
+}
@@ -0,0 +1,446 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/utils"
+)
+
+// Dias que se muestran antes y despues de una solicitud en su linea de tiempo
+const timelineMarginDays = 7
+
+// Rango maximo de una linea de tiempo de ausencias
+const maxTimelineDays = 92
+
+// CoverageError - Las vacaciones incumplen las reglas de cobertura de la tienda
+type CoverageError struct {
+	Conflicts []dtos.CoverageConflict
+}
+
+func (e *CoverageError) Error() string {
+	messages := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		messages = append(messages, conflict.Message)
+	}
+	return "las vacaciones incumplen las reglas de cobertura de la tienda: " + strings.Join(messages, "; ")
+}
+
+// storeLeaves - Plantilla activa de una tienda y sus ausencias en un rango
+// -------------------------------------------------------------------
+func (s *HolidayService) storeLeaves(storeID, from, to string) (map[string]*models.Worker, []models.Holiday, error) {
+	workers, err := s.workerRepo.GetWorkersByStore(storeID)
+	if err != nil {
+		return nil, nil, errors.New("error al obtener los trabajadores de la tienda")
+	}
+	staff := make(map[string]*models.Worker, len(workers))
+	ids := make([]string, 0, len(workers))
+	for i := range workers {
+		if workers[i].Status != "Alta" {
+			continue
+		}
+		staff[workers[i].ID] = &workers[i]
+		ids = append(ids, workers[i].ID)
+	}
+	if len(ids) == 0 {
+		return staff, nil, nil
+	}
+
+	holidays, err := s.holidaysRepo.GetHolidaysForWorkers(ids, from, to)
+	if err != nil {
+		return nil, nil, errors.New("error al obtener las ausencias de la tienda")
+	}
+	return staff, holidays, nil
+}
+
+// holidayCovers - Indica si unas vacaciones incluyen un dia
+func holidayCovers(holiday *models.Holiday, day string) bool {
+	return holiday.StartDate[:10] <= day && day <= holiday.EndDate[:10]
+}
+
+// coverageConflicts - Comprueba unas vacaciones contra los bloqueos y limites de su tienda
+// -------------------------------------------------------------------
+// Solo cuentan como ausentes las ausencias aprobadas o disfrutadas. Los dias
+// seguidos que incumplen la misma regla se agrupan en un unico conflicto.
+func (s *HolidayService) coverageConflicts(worker *models.Worker, holiday *models.Holiday) ([]dtos.CoverageConflict, error) {
+	conflicts := []dtos.CoverageConflict{}
+	if worker.StoreID == nil {
+		return conflicts, nil
+	}
+	storeID := *worker.StoreID
+	from, to := holiday.StartDate[:10], holiday.EndDate[:10]
+
+	// Periodos bloqueados
+	blackouts, err := s.coverageRepo.GetBlackoutsBetween(storeID, from, to)
+	if err != nil {
+		return nil, errors.New("error al obtener los periodos bloqueados de la tienda")
+	}
+	for _, blackout := range blackouts {
+		conflict := dtos.CoverageConflict{
+			Type: dtos.CoverageBlackout,
+			From: max(from, blackout.StartDate[:10]),
+			To:   min(to, blackout.EndDate[:10]),
+		}
+		conflict.Message = fmt.Sprintf("del %s al %s no se conceden vacaciones", conflict.From, conflict.To)
+		if blackout.Reason != "" {
+			conflict.Message += " (" + blackout.Reason + ")"
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	// Limites de ausentes a la vez
+	rules, err := s.coverageRepo.GetStoreRules(storeID)
+	if err != nil {
+		return nil, errors.New("error al obtener las reglas de cobertura de la tienda")
+	}
+	if len(rules) == 0 {
+		return conflicts, nil
+	}
+	staff, holidays, err := s.storeLeaves(storeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := utils.ParseDate(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := utils.ParseDate(to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		if rule.Cargo != "" && rule.Cargo != worker.Cargo {
+			continue
+		}
+
+		// Plantilla a la que se aplica la regla, incluido el propio trabajador
+		headcount := 0
+		for id, member := range staff {
+			if id != worker.ID && (rule.Cargo == "" || member.Cargo == rule.Cargo) {
+				headcount++
+			}
+		}
+		headcount++
+
+		var open *dtos.CoverageConflict
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			absentees := make(map[string]bool)
+			for i := range holidays {
+				other := &holidays[i]
+				member, ok := staff[other.WorkerID]
+				if other.ID == holiday.ID || other.WorkerID == worker.ID || !ok || !isApprovedHoliday(other) ||
+					!holidayCovers(other, date) || (rule.Cargo != "" && member.Cargo != rule.Cargo) {
+					continue
+				}
+				absentees[other.WorkerID] = true
+			}
+			absent := len(absentees) + 1
+
+			conflictType, limit := "", 0.0
+			if rule.MaxAbsent != nil && absent > *rule.MaxAbsent {
+				conflictType, limit = dtos.CoverageMaxAbsent, float64(*rule.MaxAbsent)
+			} else if rule.MaxPercent != nil && float64(absent)*100/float64(headcount) > *rule.MaxPercent {
+				conflictType, limit = dtos.CoverageMaxPercent, *rule.MaxPercent
+			}
+			if conflictType == "" {
+				open = nil
+				continue
+			}
+
+			// Alargamos el conflicto abierto si el dia anterior incumplia lo mismo
+			if open != nil && open.Type == conflictType {
+				open.To = date
+				open.Absent = max(open.Absent, absent)
+				continue
+			}
+			conflicts = append(conflicts, dtos.CoverageConflict{
+				Type:   conflictType,
+				From:   date,
+				To:     date,
+				Cargo:  rule.Cargo,
+				Limit:  limit,
+				Absent: absent,
+				Staff:  headcount,
+			})
+			open = &conflicts[len(conflicts)-1]
+		}
+	}
+
+	for i := range conflicts {
+		conflict := &conflicts[i]
+		if conflict.Type == dtos.CoverageBlackout {
+			continue
+		}
+		group := "trabajadores"
+		if conflict.Cargo != "" {
+			group = "trabajadores de " + conflict.Cargo
+		}
+		limit := fmt.Sprintf("%.0f", conflict.Limit)
+		if conflict.Type == dtos.CoverageMaxPercent {
+			limit = fmt.Sprintf("%.0f%%", conflict.Limit)
+		}
+		conflict.Message = fmt.Sprintf("del %s al %s habria %d de %d %s ausentes y el maximo es %s",
+			conflict.From, conflict.To, conflict.Absent, conflict.Staff, group, limit)
+	}
+	return conflicts, nil
+}
+
+// CheckHoliday - Comprueba unas vacaciones contra las reglas de cobertura sin guardarlas
+// -------------------------------------------------------------------
+// El trabajador solo puede comprobar las suyas.
+func (s *HolidayService) CheckHoliday(userID, role string, holiday *models.Holiday) ([]dtos.CoverageConflict, error) {
+	var worker *models.Worker
+	var err error
+	if role == "worker" {
+		worker, err = s.workerRepo.FindWorkerByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro el trabajador del usuario")
+		}
+		holiday.WorkerID = worker.ID
+	} else {
+		worker, err = s.workerRepo.FindWorkerByID(holiday.WorkerID)
+		if err != nil {
+			return nil, errors.New("el trabajador no existe")
+		}
+	}
+
+	holiday.ID = 0
+	holiday.Status = models.HolidayStatusRequested
+	if err := utils.ValidateHolidaysFields(holiday); err != nil {
+		return nil, err
+	}
+	return s.coverageConflicts(worker, holiday)
+}
+
+// buildLeaveTimeline - Construye la linea de tiempo de ausencias de una tienda
+// -------------------------------------------------------------------
+func (s *HolidayService) buildLeaveTimeline(storeID string, start, end time.Time) (*dtos.LeaveTimeline, error) {
+	if end.Before(start) {
+		return nil, errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
+	}
+	if end.Sub(start).Hours()/24 >= maxTimelineDays {
+		return nil, fmt.Errorf("la linea de tiempo no puede superar %d dias", maxTimelineDays)
+	}
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	staff, holidays, err := s.storeLeaves(storeID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	blackouts, err := s.coverageRepo.GetBlackoutsBetween(storeID, from, to)
+	if err != nil {
+		return nil, errors.New("error al obtener los periodos bloqueados de la tienda")
+	}
+
+	timeline := &dtos.LeaveTimeline{
+		StoreID:   storeID,
+		From:      from,
+		To:        to,
+		Staff:     len(staff),
+		Days:      []dtos.LeaveTimelineDay{},
+		Conflicts: []dtos.CoverageConflict{},
+	}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		entry := dtos.LeaveTimelineDay{
+			Date:    day.Format("2006-01-02"),
+			Absent:  []dtos.LeaveTimelineEntry{},
+			Pending: []dtos.LeaveTimelineEntry{},
+		}
+		for _, blackout := range blackouts {
+			if blackout.StartDate[:10] <= entry.Date && entry.Date <= blackout.EndDate[:10] {
+				entry.Blackout = blackout.Reason
+				if entry.Blackout == "" {
+					entry.Blackout = "Periodo bloqueado"
+				}
+			}
+		}
+		for i := range holidays {
+			holiday := &holidays[i]
+			if !holidayCovers(holiday, entry.Date) {
+				continue
+			}
+			member := staff[holiday.WorkerID]
+			item := dtos.LeaveTimelineEntry{
+				HolidayID:  holiday.ID,
+				WorkerID:   holiday.WorkerID,
+				WorkerName: member.Name + " " + member.LastName,
+				Cargo:      member.Cargo,
+				LeaveType:  holiday.LeaveType,
+				Status:     holiday.Status,
+			}
+			switch holiday.Status {
+			case models.HolidayStatusApproved, models.HolidayStatusTaken:
+				entry.Absent = append(entry.Absent, item)
+			case models.HolidayStatusRequested, models.HolidayStatusPreApproved:
+				entry.Pending = append(entry.Pending, item)
+			}
+		}
+		timeline.Days = append(timeline.Days, entry)
+	}
+	return timeline, nil
+}
+
+// GetLeaveTimeline - Linea de tiempo de ausencias de una tienda entre dos fechas
+// -------------------------------------------------------------------
+// Los encargados solo ven su tienda.
+func (s *HolidayService) GetLeaveTimeline(userID, role, storeID, from, to string) (*dtos.LeaveTimeline, error) {
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return nil, errors.New("la tienda no existe")
+	}
+
+	start, err := utils.ParseDate(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := utils.ParseDate(to)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildLeaveTimeline(storeID, start, end)
+}
+
+// GetHolidayTimeline - Linea de tiempo de la tienda alrededor de una solicitud, con sus conflictos
+// -------------------------------------------------------------------
+func (s *HolidayService) GetHolidayTimeline(userID, role string, holidayID int) (*dtos.LeaveTimeline, error) {
+	holiday, err := s.holidaysRepo.GetHolidayByID(holidayID)
+	if err != nil {
+		return nil, errors.New("la vacacion no existe")
+	}
+
+	var worker *models.Worker
+	if role == "admin" {
+		worker, err = s.workerRepo.FindWorkerByID(holiday.WorkerID)
+		if err != nil {
+			return nil, errors.New("el trabajador no existe")
+		}
+	} else if worker, err = s.storeOfHoliday(userID, holiday); err != nil {
+		return nil, err
+	}
+	if worker.StoreID == nil {
+		return nil, errors.New("el trabajador no tiene tienda asignada")
+	}
+
+	start, err := utils.ParseDate(holiday.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := utils.ParseDate(holiday.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	start, end = start.AddDate(0, 0, -timelineMarginDays), end.AddDate(0, 0, timelineMarginDays)
+	if limit := start.AddDate(0, 0, maxTimelineDays-1); end.After(limit) {
+		end = limit
+	}
+
+	timeline, err := s.buildLeaveTimeline(*worker.StoreID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if timeline.Conflicts, err = s.coverageConflicts(worker, holiday); err != nil {
+		return nil, err
+	}
+	return timeline, nil
+}
+
+// CreateLeaveRule - Crea una regla de cobertura para una tienda
+// -------------------------------------------------------------------
+func (s *HolidayService) CreateLeaveRule(storeID string, rule *models.StoreLeaveRule) error {
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return errors.New("la tienda no existe")
+	}
+	rule.ID = 0
+	rule.StoreID = storeID
+	if rule.MaxAbsent == nil && rule.MaxPercent == nil {
+		return errors.New("la regla necesita un maximo de ausentes o un porcentaje")
+	}
+	if rule.MaxAbsent != nil && *rule.MaxAbsent < 0 {
+		return errors.New("el maximo de ausentes no puede ser negativo")
+	}
+	if rule.MaxPercent != nil && (*rule.MaxPercent <= 0 || *rule.MaxPercent > 100) {
+		return errors.New("el porcentaje de ausentes debe estar entre 0 y 100")
+	}
+	if err := s.coverageRepo.CreateRule(rule); err != nil {
+		return errors.New("error al crear la regla de cobertura")
+	}
+	return nil
+}
+
+// CreateBlackout - Crea un periodo bloqueado para una tienda
+// -------------------------------------------------------------------
+func (s *HolidayService) CreateBlackout(storeID string, blackout *models.StoreBlackout) error {
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return errors.New("la tienda no existe")
+	}
+	blackout.ID = 0
+	blackout.StoreID = storeID
+	start, err := utils.ParseDate(blackout.StartDate)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseDate(blackout.EndDate)
+	if err != nil {
+		return err
+	}
+	if end.Before(start) {
+		return errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
+	}
+	if err := s.coverageRepo.CreateBlackout(blackout); err != nil {
+		return errors.New("error al crear el periodo bloqueado")
+	}
+	return nil
+}
+
+// GetCoverageRules - Obtiene las reglas de cobertura y los periodos bloqueados de una tienda
+// -------------------------------------------------------------------
+func (s *HolidayService) GetCoverageRules(storeID string) ([]models.StoreLeaveRule, []models.StoreBlackout, error) {
+	rules, err := s.coverageRepo.GetStoreRules(storeID)
+	if err != nil {
+		return nil, nil, errors.New("error al obtener las reglas de cobertura")
+	}
+	blackouts, err := s.coverageRepo.GetStoreBlackouts(storeID)
+	if err != nil {
+		return nil, nil, errors.New("error al obtener los periodos bloqueados")
+	}
+	return rules, blackouts, nil
+}
+
+// DeleteLeaveRule - Elimina una regla de cobertura
+// -------------------------------------------------------------------
+func (s *HolidayService) DeleteLeaveRule(ruleID int) error {
+	deleted, err := s.coverageRepo.DeleteRule(ruleID)
+	if err != nil {
+		return errors.New("error al eliminar la regla de cobertura")
+	}
+	if deleted == 0 {
+		return errors.New("la regla de cobertura no existe")
+	}
+	return nil
+}
+
+// DeleteBlackout - Elimina un periodo bloqueado
+// -------------------------------------------------------------------
+func (s *HolidayService) DeleteBlackout(blackoutID int) error {
+	deleted, err := s.coverageRepo.DeleteBlackout(blackoutID)
+	if err != nil {
+		return errors.New("error al eliminar el periodo bloqueado")
+	}
+	if deleted == 0 {
+		return errors.New("el periodo bloqueado no existe")
+	}
+	return nil
+}
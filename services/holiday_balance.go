@@ -0,0 +1,321 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/config"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Derecho anual por defecto segun el tipo de computo (art. 38 ET)
+const (
+	defaultCalendarHolidayDays = 30
+	defaultWorkingHolidayDays  = 22
+)
+
+// holidayLedger - Calcula saldos de vacaciones; lo comparten los servicios que crean o aprueban vacaciones
+type holidayLedger struct {
+	holidaysRepo      *repositories.HolidaysRepository
+	publicHolidayRepo *repositories.PublicHolidayRepository
+	storeRepo         *repositories.StoreRepository
+}
+
+// holidayPolicy - Reglas de vacaciones que se aplican a un trabajador en un año
+type holidayPolicy struct {
+	DayType        string
+	Days           float64
+	CarryOverMax   float64
+	CarryOverUntil string // MM-DD; vacio = todo el año
+	CarryOverFixed *float64
+}
+
+// defaultHolidayPolicy - Lee de la configuracion las reglas por defecto
+// -------------------------------------------------------------------
+func defaultHolidayPolicy() holidayPolicy {
+	policy := holidayPolicy{
+		DayType:        models.HolidayDayTypeCalendar,
+		Days:           defaultCalendarHolidayDays,
+		CarryOverUntil: config.Env.HolidayCarryOverUntil,
+	}
+	if config.Env.HolidayDayType == models.HolidayDayTypeWorking {
+		policy.DayType = models.HolidayDayTypeWorking
+		policy.Days = defaultWorkingHolidayDays
+	}
+	if days, err := strconv.ParseFloat(config.Env.HolidayDays, 64); err == nil && days >= 0 {
+		policy.Days = days
+	}
+	if days, err := strconv.ParseFloat(config.Env.HolidayCarryOverDays, 64); err == nil && days >= 0 {
+		policy.CarryOverMax = days
+	}
+	return policy
+}
+
+// policyFor - Aplica sobre los valores por defecto el derecho fijado para el trabajador
+// -------------------------------------------------------------------
+func (l *holidayLedger) policyFor(workerID string, year int) (holidayPolicy, error) {
+	policy := defaultHolidayPolicy()
+	entitlement, err := l.holidaysRepo.FindEntitlement(workerID, year)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return policy, nil
+		}
+		return policy, errors.New("error al obtener el derecho a vacaciones del trabajador")
+	}
+	policy.DayType = entitlement.DayType
+	policy.Days = entitlement.Days
+	policy.CarryOverFixed = entitlement.CarryOverDays
+	return policy, nil
+}
+
+// roundHalfDay - Redondea al medio dia mas cercano
+func roundHalfDay(days float64) float64 {
+	return math.Round(days*2) / 2
+}
+
+// countHolidayDays - Cuenta los dias computables entre dos fechas, ambas incluidas
+// -------------------------------------------------------------------
+// En dias laborables no cuentan los fines de semana ni los festivos.
+func countHolidayDays(from, to time.Time, dayType string, publicHolidays map[string]string) float64 {
+	var days float64
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if dayType == models.HolidayDayTypeWorking && !isWorkingDay(day, publicHolidays) {
+			continue
+		}
+		days++
+	}
+	return days
+}
+
+// holidayDaysIn - Cuenta los dias de unas vacaciones que caen dentro de un rango
+// -------------------------------------------------------------------
+func holidayDaysIn(holiday *models.Holiday, from, to time.Time, dayType string, publicHolidays map[string]string) (float64, error) {
+	start, err := utils.ParseDate(holiday.StartDate)
+	if err != nil {
+		return 0, err
+	}
+	end, err := utils.ParseDate(holiday.EndDate)
+	if err != nil {
+		return 0, err
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	return countHolidayDays(start, end, dayType, publicHolidays), nil
+}
+
+// entitledDays - Dias que corresponden en un año en proporcion al tiempo contratado
+// -------------------------------------------------------------------
+// Sin fecha de inicio de contrato se considera el año completo. Con until
+// se calcula lo devengado hasta esa fecha.
+func entitledDays(policy holidayPolicy, worker *models.Worker, year int, until time.Time) float64 {
+	yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	daysInYear := yearEnd.Sub(yearStart).Hours()/24 + 1
+
+	from := yearStart
+	if worker.HireDate != nil {
+		if hire, err := utils.ParseDate(*worker.HireDate); err == nil && hire.After(from) {
+			from = hire
+		}
+	}
+	if until.After(yearEnd) {
+		until = yearEnd
+	}
+	if until.Before(from) {
+		return 0
+	}
+	return roundHalfDay(policy.Days * (until.Sub(from).Hours()/24 + 1) / daysInYear)
+}
+
+// balance - Calcula el saldo de vacaciones de un trabajador en un año
+// -------------------------------------------------------------------
+// Los dias usados salen de las vacaciones aprobadas o disfrutadas y los
+// pendientes de las solicitadas o preaprobadas. Las vacaciones excludeID no
+// se cuentan, para poder comprobar una solicitud contra el resto.
+func (l *holidayLedger) balance(worker *models.Worker, year int, excludeID int) (*dtos.HolidayBalance, error) {
+	policy, err := l.policyFor(worker.ID, year)
+	if err != nil {
+		return nil, err
+	}
+	prevPolicy, err := l.policyFor(worker.ID, year-1)
+	if err != nil {
+		return nil, err
+	}
+
+	yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	prevStart := yearStart.AddDate(-1, 0, 0)
+	prevEnd := yearStart.AddDate(0, 0, -1)
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Fecha limite para disfrutar los dias arrastrados
+	carryDeadline := yearEnd
+	if policy.CarryOverUntil != "" {
+		if deadline, err := utils.ParseDate(fmt.Sprintf("%d-%s", year, policy.CarryOverUntil)); err == nil {
+			carryDeadline = deadline
+		}
+	}
+
+	holidays, err := l.holidaysRepo.GetWorkerHolidaysBetween(nil, worker.ID, prevStart.Format("2006-01-02"), yearEnd.Format("2006-01-02"))
+	if err != nil {
+		return nil, errors.New("error al obtener las vacaciones del trabajador")
+	}
+	storeID := ""
+	if worker.StoreID != nil {
+		storeID = *worker.StoreID
+	}
+	publicHolidays, err := storePublicHolidays(l.publicHolidayRepo, l.storeRepo, storeID, prevStart, yearEnd)
+	if err != nil {
+		return nil, err
+	}
+	counted, err := l.countedLeaveTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &dtos.HolidayBalance{
+		WorkerID:   worker.ID,
+		Year:       year,
+		DayType:    policy.DayType,
+		AnnualDays: policy.Days,
+		Entitled:   entitledDays(policy, worker, year, yearEnd),
+		Accrued:    entitledDays(policy, worker, year, today),
+	}
+	if worker.HireDate != nil {
+		balance.HireDate = (*worker.HireDate)[:10]
+	}
+
+	var prevUsed, usedBeforeDeadline float64
+	for i := range holidays {
+		holiday := &holidays[i]
+		if holiday.ID == excludeID || !counted[holiday.LeaveType] {
+			continue
+		}
+		approved := isApprovedHoliday(holiday)
+		pending := holiday.Status == models.HolidayStatusRequested || holiday.Status == models.HolidayStatusPreApproved
+		if !approved && !pending {
+			continue
+		}
+
+		days, err := holidayDaysIn(holiday, yearStart, yearEnd, policy.DayType, publicHolidays)
+		if err != nil {
+			continue
+		}
+		if pending {
+			balance.Pending += days
+			continue
+		}
+		balance.Used += days
+
+		if before, err := holidayDaysIn(holiday, yearStart, carryDeadline, policy.DayType, publicHolidays); err == nil {
+			usedBeforeDeadline += before
+		}
+		if prev, err := holidayDaysIn(holiday, prevStart, prevEnd, prevPolicy.DayType, publicHolidays); err == nil {
+			prevUsed += prev
+		}
+	}
+
+	// Arrastre del año anterior, salvo que el admin lo haya fijado a mano.
+	// Los dias arrastrados no vuelven a arrastrarse al año siguiente.
+	if policy.CarryOverFixed != nil {
+		balance.CarriedOver = *policy.CarryOverFixed
+	} else if policy.CarryOverMax > 0 {
+		prevLeft := entitledDays(prevPolicy, worker, year-1, prevEnd) - prevUsed
+		balance.CarriedOver = math.Max(0, math.Min(prevLeft, policy.CarryOverMax))
+	}
+
+	// Pasada la fecha limite solo cuenta lo arrastrado que se llego a disfrutar
+	carried := balance.CarriedOver
+	if today.After(carryDeadline) && usedBeforeDeadline < carried {
+		carried = usedBeforeDeadline
+		balance.CarryOverExpired = balance.CarriedOver - carried
+	}
+
+	balance.Remaining = balance.Entitled + carried - balance.Used
+	balance.Available = balance.Remaining - balance.Pending
+	return balance, nil
+}
+
+// check - Comprueba que unas vacaciones no superen el saldo del trabajador
+// -------------------------------------------------------------------
+// Con countPending las solicitudes en curso tambien consumen saldo, para que
+// un trabajador no pueda pedir mas dias de los que le quedan.
+func (l *holidayLedger) check(worker *models.Worker, holiday *models.Holiday, countPending bool) error {
+	start, err := utils.ParseDate(holiday.StartDate)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseDate(holiday.EndDate)
+	if err != nil {
+		return err
+	}
+
+	// Las vacaciones que cruzan de año se descuentan de cada año por separado
+	for year := start.Year(); year <= end.Year(); year++ {
+		balance, err := l.balance(worker, year, holiday.ID)
+		if err != nil {
+			return err
+		}
+		requested, err := l.requestedDays(worker, holiday, year, balance.DayType)
+		if err != nil {
+			return err
+		}
+
+		left := balance.Remaining
+		if countPending {
+			left = balance.Available
+		}
+		if requested > left {
+			return fmt.Errorf("las vacaciones superan el saldo de %d: quedan %.1f dias %s y se piden %.1f",
+				year, math.Max(0, left), balance.DayType, requested)
+		}
+	}
+	return nil
+}
+
+// requestedDays - Dias que consumen unas vacaciones dentro de un año
+// -------------------------------------------------------------------
+func (l *holidayLedger) requestedDays(worker *models.Worker, holiday *models.Holiday, year int, dayType string) (float64, error) {
+	yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	var publicHolidays map[string]string
+	if dayType == models.HolidayDayTypeWorking {
+		storeID := ""
+		if worker.StoreID != nil {
+			storeID = *worker.StoreID
+		}
+		var err error
+		publicHolidays, err = storePublicHolidays(l.publicHolidayRepo, l.storeRepo, storeID, yearStart, yearEnd)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return holidayDaysIn(holiday, yearStart, yearEnd, dayType, publicHolidays)
+}
+
+// countedLeaveTypes - Codigos de los tipos de ausencia que descuentan del saldo de vacaciones
+// -------------------------------------------------------------------
+func (l *holidayLedger) countedLeaveTypes() (map[string]bool, error) {
+	leaveTypes, err := l.holidaysRepo.GetLeaveTypes(false)
+	if err != nil {
+		return nil, errors.New("error al obtener los tipos de ausencia")
+	}
+	counted := map[string]bool{"": true} // Registros sin tipo, anteriores a los tipos de ausencia
+	for _, leaveType := range leaveTypes {
+		counted[leaveType.Code] = leaveType.CountsAsHoliday
+	}
+	return counted, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+)
+
+func mustDate(value string) time.Time {
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return day
+}
+
+func TestCountHolidayDays(t *testing.T) {
+	holidays := map[string]string{
+		"2025-03-05": "Festivo local",
+		"2025-03-08": "Festivo en sabado",
+		"2026-01-01": "Año Nuevo",
+		"2026-01-06": "Reyes",
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		dayType  string
+		holidays map[string]string
+		want     float64
+	}{
+		{"semana natural", "2025-03-03", "2025-03-09", models.HolidayDayTypeCalendar, nil, 7},
+		{"semana laborable", "2025-03-03", "2025-03-09", models.HolidayDayTypeWorking, nil, 5},
+		{"festivo entre semana no cuenta", "2025-03-03", "2025-03-09", models.HolidayDayTypeWorking, holidays, 4},
+		{"festivos cuentan en naturales", "2025-03-03", "2025-03-09", models.HolidayDayTypeCalendar, holidays, 7},
+		{"solo fin de semana", "2025-03-08", "2025-03-09", models.HolidayDayTypeWorking, nil, 0},
+		{"un dia laborable", "2025-03-04", "2025-03-04", models.HolidayDayTypeWorking, holidays, 1},
+		{"cambio de año con festivos", "2025-12-29", "2026-01-06", models.HolidayDayTypeWorking, holidays, 5},
+		{"cambio de año natural", "2025-12-29", "2026-01-06", models.HolidayDayTypeCalendar, holidays, 9},
+		{"fin anterior al inicio", "2025-03-09", "2025-03-03", models.HolidayDayTypeCalendar, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := countHolidayDays(mustDate(tt.from), mustDate(tt.to), tt.dayType, tt.holidays)
+			if got != tt.want {
+				t.Errorf("countHolidayDays(%s, %s, %s) = %v, want %v", tt.from, tt.to, tt.dayType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntitledDays(t *testing.T) {
+	calendar := holidayPolicy{DayType: models.HolidayDayTypeCalendar, Days: 30}
+	working := holidayPolicy{DayType: models.HolidayDayTypeWorking, Days: 22}
+
+	tests := []struct {
+		name     string
+		policy   holidayPolicy
+		hireDate string
+		year     int
+		until    string
+		want     float64
+	}{
+		{"año completo sin fecha de alta", calendar, "", 2025, "2025-12-31", 30},
+		{"alta en un año anterior", calendar, "2023-05-10", 2025, "2025-12-31", 30},
+		{"alta a mitad de año", calendar, "2025-07-01", 2025, "2025-12-31", 15},
+		{"alta en marzo", calendar, "2025-03-15", 2025, "2025-12-31", 24},
+		{"laborables en año bisiesto", working, "2024-04-01", 2024, "2024-12-31", 16.5},
+		{"devengado hasta junio", calendar, "", 2025, "2025-06-30", 15},
+		{"until posterior al año", calendar, "2025-07-01", 2025, "2026-03-01", 15},
+		{"alta posterior a until", calendar, "2025-09-01", 2025, "2025-06-30", 0},
+		{"alta en un año posterior", calendar, "2026-02-01", 2025, "2025-12-31", 0},
+		{"fecha de alta con hora", calendar, "2025-07-01T00:00:00Z", 2025, "2025-12-31", 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker := &models.Worker{}
+			if tt.hireDate != "" {
+				worker.HireDate = &tt.hireDate
+			}
+			got := entitledDays(tt.policy, worker, tt.year, mustDate(tt.until))
+			if got != tt.want {
+				t.Errorf("entitledDays(%v, alta %q, %d, %s) = %v, want %v", tt.policy.Days, tt.hireDate, tt.year, tt.until, got, tt.want)
+			}
+		})
+	}
+}
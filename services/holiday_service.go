@@ -0,0 +1,894 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+type HolidayService struct {
+	holidaysRepo *repositories.HolidaysRepository
+	workerRepo   *repositories.WorkerRepository
+	storeRepo    *repositories.StoreRepository
+	userRepo     *repositories.UserRepository
+	calendarRepo *repositories.CalendarRepository
+	notifyRepo   *repositories.NotificationRepository
+	coverageRepo *repositories.CoverageRepository
+	ledger       *holidayLedger
+
+	db *gorm.DB
+}
+
+func NewHolidayService(
+	holidaysRepo *repositories.HolidaysRepository,
+	workerRepo *repositories.WorkerRepository,
+	storeRepo *repositories.StoreRepository,
+	userRepo *repositories.UserRepository,
+	calendarRepo *repositories.CalendarRepository,
+	notifyRepo *repositories.NotificationRepository,
+	publicHolidayRepo *repositories.PublicHolidayRepository,
+	coverageRepo *repositories.CoverageRepository,
+	db *gorm.DB) *HolidayService {
+	return &HolidayService{
+		holidaysRepo: holidaysRepo,
+		workerRepo:   workerRepo,
+		storeRepo:    storeRepo,
+		userRepo:     userRepo,
+		calendarRepo: calendarRepo,
+		notifyRepo:   notifyRepo,
+		coverageRepo: coverageRepo,
+		ledger:       &holidayLedger{holidaysRepo, publicHolidayRepo, storeRepo},
+		db:           db,
+	}
+}
+
+// describeHoliday - Resume las fechas de unas vacaciones para las notificaciones
+// -------------------------------------------------------------------
+func describeHoliday(holiday *models.Holiday) string {
+	description := fmt.Sprintf("Del %s al %s", holiday.StartDate[:10], holiday.EndDate[:10])
+	if holiday.LeaveType != "" && holiday.LeaveType != models.LeaveTypeVacation {
+		description += " (" + holiday.LeaveType + ")"
+	}
+	return description
+}
+
+// activeLeaveType - Busca un tipo de ausencia que se pueda solicitar
+// -------------------------------------------------------------------
+func (s *HolidayService) activeLeaveType(code string) (*models.LeaveType, error) {
+	leaveType, err := s.holidaysRepo.FindLeaveType(code)
+	if err != nil || !leaveType.Active {
+		return nil, errors.New("el tipo de ausencia no existe")
+	}
+	return leaveType, nil
+}
+
+// checkLeaveLength - Comprueba que una ausencia no supere el maximo de dias de su tipo
+// -------------------------------------------------------------------
+func (s *HolidayService) checkLeaveLength(worker *models.Worker, holiday *models.Holiday, leaveType *models.LeaveType) error {
+	if leaveType.MaxDays == nil {
+		return nil
+	}
+	start, err := utils.ParseDate(holiday.StartDate)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseDate(holiday.EndDate)
+	if err != nil {
+		return err
+	}
+
+	storeID := ""
+	if worker.StoreID != nil {
+		storeID = *worker.StoreID
+	}
+	publicHolidays, err := storePublicHolidays(s.ledger.publicHolidayRepo, s.storeRepo, storeID, start, end)
+	if err != nil {
+		return err
+	}
+	if days := countHolidayDays(start, end, leaveType.DayType, publicHolidays); days > *leaveType.MaxDays {
+		return fmt.Errorf("%s permite como maximo %.0f dias %s por solicitud", leaveType.Name, *leaveType.MaxDays, leaveType.DayType)
+	}
+	return nil
+}
+
+// RequestHoliday - El trabajador solicita unas vacaciones u otra ausencia
+// -------------------------------------------------------------------
+// Si el trabajador tiene tienda la solicitud la aprueba primero su encargado;
+// si no, pasa directamente a los administradores. Sin leave_type se piden
+// vacaciones.
+func (s *HolidayService) RequestHoliday(userID string, holiday *models.Holiday) error {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	holiday.ID = 0
+	holiday.WorkerID = worker.ID
+	holiday.Status = models.HolidayStatusRequested
+	holiday.Document = ""
+	if err := utils.ValidateHolidaysFields(holiday); err != nil {
+		return err
+	}
+	leaveType, err := s.activeLeaveType(holiday.LeaveType)
+	if err != nil {
+		return err
+	}
+
+	// Las bajas y permisos pueden comunicarse una vez empezados; las vacaciones no
+	start, _ := utils.ParseDate(holiday.StartDate)
+	if leaveType.CountsAsHoliday && !start.After(time.Now()) {
+		return errors.New("solo se pueden solicitar vacaciones a partir de mañana")
+	}
+	if err := s.checkLeaveLength(worker, holiday, leaveType); err != nil {
+		return err
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Con el trabajador bloqueado dos solicitudes a la vez no pueden pasar
+	// las comprobaciones de solape y saldo sin ver la otra
+	worker, err = s.workerRepo.LockWorker(tx, worker.ID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	overlaps, err := s.holidaysRepo.CountActiveHolidays(tx, worker.ID, holiday.StartDate, holiday.EndDate, 0)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al comprobar las vacaciones del trabajador")
+	}
+	if overlaps > 0 {
+		tx.Rollback()
+		return errors.New("ya tienes vacaciones o ausencias solicitadas o aprobadas en esas fechas")
+	}
+	if leaveType.CountsAsHoliday {
+		if err := s.ledger.check(worker, holiday, true); err != nil {
+			tx.Rollback()
+			return err
+		}
+		conflicts, err := s.coverageConflicts(worker, holiday)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if len(conflicts) > 0 {
+			tx.Rollback()
+			return &CoverageError{Conflicts: conflicts}
+		}
+	}
+
+	if err := s.holidaysRepo.CreateHoliday(tx, holiday); err != nil {
+		tx.Rollback()
+		return errors.New("error al crear la solicitud de vacaciones")
+	}
+
+	// Avisamos a quien tiene que aprobarlas
+	title := worker.Name + " " + worker.LastName + " ha solicitado " + strings.ToLower(leaveType.Name)
+	if worker.StoreID != nil {
+		store, err := s.storeRepo.FindStoreByID(*worker.StoreID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("la tienda del trabajador no existe")
+		}
+		notification := &models.Notification{
+			UserID: store.UserID,
+			Type:   "solicitud_vacaciones",
+			Title:  title,
+			Body:   describeHoliday(holiday),
+		}
+		if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+			tx.Rollback()
+			return errors.New("error al notificar al encargado")
+		}
+	} else if err := notifyAdmins(tx, s.userRepo, s.notifyRepo, "solicitud_vacaciones", title, describeHoliday(holiday)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetWorkerHolidays - Obtiene las vacaciones del trabajador autenticado
+// -------------------------------------------------------------------
+func (s *HolidayService) GetWorkerHolidays(userID string) ([]models.Holiday, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+	return s.holidaysRepo.GetHolidaysByWorker(worker.ID)
+}
+
+// CancelHoliday - El trabajador cancela unas vacaciones que aun no ha empezado a disfrutar
+// -------------------------------------------------------------------
+func (s *HolidayService) CancelHoliday(userID string, holidayID int, comment string) error {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	holiday, err := s.holidaysRepo.LockHolidayByID(tx, holidayID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la vacacion no existe")
+	}
+	if holiday.WorkerID != worker.ID {
+		tx.Rollback()
+		return errors.New("la vacacion no es tuya")
+	}
+
+	fromStatus := holiday.Status
+	switch fromStatus {
+	case models.HolidayStatusRequested, models.HolidayStatusPreApproved, models.HolidayStatusApproved:
+	default:
+		tx.Rollback()
+		return errors.New("solo se pueden cancelar vacaciones solicitadas o aprobadas")
+	}
+
+	start, err := utils.ParseDate(holiday.StartDate)
+	if err != nil || !start.After(time.Now()) {
+		tx.Rollback()
+		return errors.New("no se pueden cancelar vacaciones que ya han empezado")
+	}
+
+	if err := s.holidaysRepo.UpdateHolidayStatus(tx, holiday.ID, models.HolidayStatusCancelled); err != nil {
+		tx.Rollback()
+		return errors.New("error al cancelar las vacaciones")
+	}
+
+	decision := &models.HolidayDecision{
+		HolidayID:   holiday.ID,
+		Level:       models.DecisionLevelWorker,
+		FromStatus:  fromStatus,
+		ToStatus:    models.HolidayStatusCancelled,
+		Comment:     comment,
+		DecidedByID: userID,
+	}
+	if err := s.holidaysRepo.CreateDecision(tx, decision); err != nil {
+		tx.Rollback()
+		return errors.New("error al registrar la cancelacion")
+	}
+
+	// Si ya estaban aprobadas aparecian en los calendarios
+	if fromStatus == models.HolidayStatusApproved {
+		cancellation, err := newHolidayCancellation(holiday, &holiday.WorkerID, worker.StoreID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := s.calendarRepo.CreateCancellation(tx, cancellation); err != nil {
+			tx.Rollback()
+			return errors.New("error al actualizar el calendario del trabajador")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetHolidayRequests - Obtiene las vacaciones de la tienda del encargado o de todas para el admin
+// -------------------------------------------------------------------
+func (s *HolidayService) GetHolidayRequests(userID, role, storeID, status string) ([]dtos.HolidayWithWorkerName, error) {
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+	return s.holidaysRepo.GetHolidayRequests(storeID, status)
+}
+
+// GetHolidayDecisions - Obtiene el historial de decisiones de unas vacaciones
+// -------------------------------------------------------------------
+func (s *HolidayService) GetHolidayDecisions(userID, role string, holidayID int) ([]models.HolidayDecision, error) {
+	holiday, err := s.holidaysRepo.GetHolidayByID(holidayID)
+	if err != nil {
+		return nil, errors.New("la vacacion no existe")
+	}
+
+	switch role {
+	case "admin":
+	case "worker":
+		worker, err := s.workerRepo.FindWorkerByUserID(userID)
+		if err != nil || worker.ID != holiday.WorkerID {
+			return nil, errors.New("la vacacion no es tuya")
+		}
+	default:
+		if _, err := s.storeOfHoliday(userID, holiday); err != nil {
+			return nil, err
+		}
+	}
+	return s.holidaysRepo.GetDecisions(holiday.ID)
+}
+
+// storeOfHoliday - Comprueba que las vacaciones sean de un trabajador de la tienda del encargado
+// -------------------------------------------------------------------
+func (s *HolidayService) storeOfHoliday(userID string, holiday *models.Holiday) (*models.Worker, error) {
+	store, err := s.storeRepo.FindStoreByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro la tienda del usuario")
+	}
+	worker, err := s.workerRepo.FindWorkerByID(holiday.WorkerID)
+	if err != nil {
+		return nil, errors.New("el trabajador no existe")
+	}
+	if worker.StoreID == nil || *worker.StoreID != store.ID {
+		return nil, errors.New("las vacaciones no son de un trabajador de tu tienda")
+	}
+	return worker, nil
+}
+
+// ApproveHoliday - El encargado preaprueba o el admin aprueba unas vacaciones
+// -------------------------------------------------------------------
+// Con override el admin aprueba aunque se supere el saldo del trabajador.
+func (s *HolidayService) ApproveHoliday(userID, role string, holidayID int, comment string, override bool) error {
+	return s.decideHoliday(userID, role, holidayID, comment, true, override)
+}
+
+// RejectHoliday - El encargado o el admin rechaza unas vacaciones
+// -------------------------------------------------------------------
+func (s *HolidayService) RejectHoliday(userID, role string, holidayID int, comment string) error {
+	return s.decideHoliday(userID, role, holidayID, comment, false, false)
+}
+
+// checkApproval - Comprobaciones de unas vacaciones antes de aprobarlas o preaprobarlas
+// -------------------------------------------------------------------
+// El admin exige el justificante y vuelve a mirar el saldo, porque entre la
+// solicitud y la aprobacion se han podido aprobar otras. La cobertura se
+// comprueba en cada aprobacion con la tienda bloqueada. Solo el admin puede
+// saltarse el saldo y la cobertura con override; devuelve si lo ha hecho.
+// El trabajador tiene que estar ya bloqueado en la transaccion.
+func (s *HolidayService) checkApproval(tx *gorm.DB, worker *models.Worker, holiday *models.Holiday, admin, override bool) (bool, error) {
+	leaveType, err := s.holidaysRepo.FindLeaveType(holiday.LeaveType)
+	if err != nil {
+		return false, errors.New("el tipo de ausencia no existe")
+	}
+	if admin && leaveType.RequiresDocument && holiday.Document == "" {
+		return false, errors.New(leaveType.Name + " necesita un justificante antes de aprobarse")
+	}
+	if !leaveType.CountsAsHoliday {
+		return false, nil
+	}
+
+	overridden := false
+	if admin {
+		if err := s.ledger.check(worker, holiday, false); err != nil {
+			if !override {
+				return false, err
+			}
+			overridden = true
+		}
+	}
+
+	if worker.StoreID != nil {
+		if _, err := s.storeRepo.LockStore(tx, *worker.StoreID); err != nil {
+			return false, errors.New("la tienda del trabajador no existe")
+		}
+	}
+	conflicts, err := s.coverageConflicts(worker, holiday)
+	if err != nil {
+		return false, err
+	}
+	if len(conflicts) > 0 {
+		if !admin || !override {
+			return false, &CoverageError{Conflicts: conflicts}
+		}
+		overridden = true
+	}
+	return overridden, nil
+}
+
+// decideHoliday - Aplica la decision del encargado o del admin sobre unas vacaciones
+// -------------------------------------------------------------------
+// El encargado decide sobre las solicitudes de su tienda y, si las aprueba,
+// pasan al admin. El admin decide sobre las preaprobadas y sobre las de
+// trabajadores sin tienda, que no tienen encargado que las revise.
+func (s *HolidayService) decideHoliday(userID, role string, holidayID int, comment string, approve, override bool) error {
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	holiday, err := s.holidaysRepo.LockHolidayByID(tx, holidayID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la vacacion no existe")
+	}
+
+	var worker *models.Worker
+	decision := &models.HolidayDecision{
+		HolidayID:   holiday.ID,
+		FromStatus:  holiday.Status,
+		Comment:     comment,
+		DecidedByID: userID,
+	}
+
+	// Se bloquea la vacacion, despues el trabajador y despues su tienda, siempre
+	// en ese orden; asi el saldo y la cobertura se leen con las demas
+	// decisiones y solicitudes ya confirmadas
+	if role == "admin" {
+		worker, err = s.workerRepo.LockWorker(tx, holiday.WorkerID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("el trabajador no existe")
+		}
+		if holiday.Status != models.HolidayStatusPreApproved &&
+			!(holiday.Status == models.HolidayStatusRequested && (worker.StoreID == nil || !approve)) {
+			tx.Rollback()
+			return errors.New("las vacaciones no estan pendientes de aprobacion del admin")
+		}
+		decision.Level = models.DecisionLevelAdmin
+		decision.ToStatus = models.HolidayStatusApproved
+	} else {
+		worker, err = s.storeOfHoliday(userID, holiday)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if worker, err = s.workerRepo.LockWorker(tx, worker.ID); err != nil {
+			tx.Rollback()
+			return errors.New("el trabajador no existe")
+		}
+		if holiday.Status != models.HolidayStatusRequested {
+			tx.Rollback()
+			return errors.New("las vacaciones no estan pendientes de aprobacion del encargado")
+		}
+		decision.Level = models.DecisionLevelStore
+		decision.ToStatus = models.HolidayStatusPreApproved
+	}
+
+	if approve {
+		overridden, err := s.checkApproval(tx, worker, holiday, role == "admin", override)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		decision.Override = overridden
+	}
+	if !approve {
+		decision.ToStatus = models.HolidayStatusRejected
+	}
+
+	if err := s.holidaysRepo.UpdateHolidayStatus(tx, holiday.ID, decision.ToStatus); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar las vacaciones")
+	}
+	if err := s.holidaysRepo.CreateDecision(tx, decision); err != nil {
+		tx.Rollback()
+		return errors.New("error al registrar la decision")
+	}
+
+	// Las preaprobadas pasan al admin; las decisiones finales se comunican al trabajador
+	if decision.ToStatus == models.HolidayStatusPreApproved {
+		title := "Vacaciones de " + worker.Name + " " + worker.LastName + " pendientes de aprobacion"
+		if err := notifyAdmins(tx, s.userRepo, s.notifyRepo, "solicitud_vacaciones", title, describeHoliday(holiday)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	} else {
+		title := "Tus vacaciones han sido aprobadas"
+		if !approve {
+			title = "Tus vacaciones han sido rechazadas"
+		}
+		notification := &models.Notification{
+			UserID: worker.UserID,
+			Type:   "decision_vacaciones",
+			Title:  title,
+			Body:   describeHoliday(holiday),
+		}
+		if comment != "" {
+			notification.Body += "\n" + comment
+		}
+		if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+			tx.Rollback()
+			return errors.New("error al notificar al trabajador")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// UpdateHoliday - El admin edita unas vacaciones
+// -------------------------------------------------------------------
+// Los campos que no se envian conservan su valor. Las fechas y el tipo solo
+// se cambian mientras estan pendientes de aprobacion. Los cambios de estado
+// siguen el flujo de las decisiones: aprobar pasa por checkApproval (override
+// salta saldo y cobertura), cancelar unas aprobadas las quita de los
+// calendarios y todo cambio queda en el historial.
+func (s *HolidayService) UpdateHoliday(userID string, holidayID int, changes *models.Holiday, override bool) error {
+	if userID == "" {
+		return errors.New("usuario no autenticado")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	holiday, err := s.holidaysRepo.LockHolidayByID(tx, holidayID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la vacacion no existe")
+	}
+	worker, err := s.workerRepo.LockWorker(tx, holiday.WorkerID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("el trabajador no existe")
+	}
+	if changes.WorkerID != "" && changes.WorkerID != holiday.WorkerID {
+		tx.Rollback()
+		return errors.New("no se puede cambiar el trabajador de unas vacaciones")
+	}
+
+	updated := *holiday
+	updated.StartDate, updated.EndDate = holiday.StartDate[:10], holiday.EndDate[:10]
+	if changes.StartDate != "" {
+		updated.StartDate = changes.StartDate
+	}
+	if changes.EndDate != "" {
+		updated.EndDate = changes.EndDate
+	}
+	if changes.LeaveType != "" {
+		updated.LeaveType = changes.LeaveType
+	}
+	if changes.Status != "" {
+		updated.Status = changes.Status
+	}
+	if changes.Comment != "" {
+		updated.Comment = changes.Comment
+	}
+	if err := utils.ValidateHolidaysFields(&updated); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	pending := holiday.Status == models.HolidayStatusRequested || holiday.Status == models.HolidayStatusPreApproved
+	if updated.StartDate != holiday.StartDate[:10] || updated.EndDate != holiday.EndDate[:10] || updated.LeaveType != holiday.LeaveType {
+		if !pending {
+			tx.Rollback()
+			return errors.New("solo se pueden cambiar las fechas o el tipo de unas vacaciones pendientes de aprobacion")
+		}
+		leaveType, err := s.holidaysRepo.FindLeaveType(updated.LeaveType)
+		if updated.LeaveType != holiday.LeaveType {
+			leaveType, err = s.activeLeaveType(updated.LeaveType)
+		}
+		if err != nil {
+			tx.Rollback()
+			return errors.New("el tipo de ausencia no existe")
+		}
+		if err := s.checkLeaveLength(worker, &updated, leaveType); err != nil {
+			tx.Rollback()
+			return err
+		}
+		overlaps, err := s.holidaysRepo.CountActiveHolidays(tx, worker.ID, updated.StartDate, updated.EndDate, holiday.ID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("error al comprobar las vacaciones del trabajador")
+		}
+		if overlaps > 0 {
+			tx.Rollback()
+			return errors.New("el trabajador ya tiene vacaciones o ausencias solicitadas o aprobadas en esas fechas")
+		}
+		// Si siguen pendientes consumen saldo como una solicitud nueva
+		if leaveType.CountsAsHoliday && !override &&
+			(updated.Status == models.HolidayStatusRequested || updated.Status == models.HolidayStatusPreApproved) {
+			if err := s.ledger.check(worker, &updated, true); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	var decision *models.HolidayDecision
+	if updated.Status != holiday.Status {
+		decision = &models.HolidayDecision{
+			HolidayID:   holiday.ID,
+			Level:       models.DecisionLevelAdmin,
+			FromStatus:  holiday.Status,
+			ToStatus:    updated.Status,
+			DecidedByID: userID,
+		}
+		switch {
+		case updated.Status == models.HolidayStatusApproved:
+			// Como en decideHoliday: las de trabajadores con tienda las preaprueba antes el encargado
+			if holiday.Status != models.HolidayStatusPreApproved &&
+				!(holiday.Status == models.HolidayStatusRequested && worker.StoreID == nil) {
+				tx.Rollback()
+				return errors.New("las vacaciones no estan pendientes de aprobacion del admin")
+			}
+			if decision.Override, err = s.checkApproval(tx, worker, &updated, true, override); err != nil {
+				tx.Rollback()
+				return err
+			}
+		case updated.Status == models.HolidayStatusRejected && pending:
+		case updated.Status == models.HolidayStatusCancelled && (pending || holiday.Status == models.HolidayStatusApproved):
+		default:
+			tx.Rollback()
+			return fmt.Errorf("las vacaciones no pueden pasar de %s a %s", holiday.Status, updated.Status)
+		}
+	}
+
+	if err := s.holidaysRepo.UpdateHoliday(tx, &updated); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar las vacaciones")
+	}
+	if decision != nil {
+		if err := s.holidaysRepo.CreateDecision(tx, decision); err != nil {
+			tx.Rollback()
+			return errors.New("error al registrar la decision")
+		}
+
+		// Si ya estaban aprobadas aparecian en los calendarios
+		if decision.ToStatus == models.HolidayStatusCancelled && decision.FromStatus == models.HolidayStatusApproved {
+			cancellation, err := newHolidayCancellation(holiday, &holiday.WorkerID, worker.StoreID)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := s.calendarRepo.CreateCancellation(tx, cancellation); err != nil {
+				tx.Rollback()
+				return errors.New("error al actualizar el calendario del trabajador")
+			}
+		}
+
+		notification := &models.Notification{
+			UserID: worker.UserID,
+			Type:   "decision_vacaciones",
+			Title:  "Tus vacaciones han pasado a " + strings.ToLower(decision.ToStatus),
+			Body:   describeHoliday(&updated),
+		}
+		if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+			tx.Rollback()
+			return errors.New("error al notificar al trabajador")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	*changes = updated
+	return nil
+}
+
+// FinishPastHolidays - Pasa a disfrutadas las vacaciones aprobadas que ya terminaron
+// -------------------------------------------------------------------
+// La ejecuta el planificador una vez al dia. Cada cambio queda registrado en
+// el historial de decisiones de las vacaciones.
+func (s *HolidayService) FinishPastHolidays() (string, error) {
+	today := time.Now().Format("2006-01-02")
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return "", errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	holidays, err := s.holidaysRepo.LockFinishedHolidays(tx, models.HolidayStatusApproved, today)
+	if err != nil {
+		tx.Rollback()
+		return "", errors.New("error al obtener las vacaciones terminadas")
+	}
+
+	for _, holiday := range holidays {
+		if err := s.holidaysRepo.UpdateHolidayStatus(tx, holiday.ID, models.HolidayStatusTaken); err != nil {
+			tx.Rollback()
+			return "", errors.New("error al actualizar las vacaciones")
+		}
+		decision := &models.HolidayDecision{
+			HolidayID:   holiday.ID,
+			Level:       models.DecisionLevelSystem,
+			FromStatus:  holiday.Status,
+			ToStatus:    models.HolidayStatusTaken,
+			Comment:     "Terminadas el " + holiday.EndDate[:10],
+			DecidedByID: models.DecisionLevelSystem,
+		}
+		if err := s.holidaysRepo.CreateDecision(tx, decision); err != nil {
+			tx.Rollback()
+			return "", errors.New("error al registrar la decision")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return "", errors.New("error al confirmar la transaccion")
+	}
+	return fmt.Sprintf("%d vacaciones pasadas a %s", len(holidays), models.HolidayStatusTaken), nil
+}
+
+// GetHolidayBalance - Obtiene el saldo de vacaciones de un trabajador en un año
+// -------------------------------------------------------------------
+func (s *HolidayService) GetHolidayBalance(workerID string, year int) (*dtos.HolidayBalance, error) {
+	worker, err := s.workerRepo.FindWorkerByID(workerID)
+	if err != nil {
+		return nil, errors.New("el trabajador no existe")
+	}
+	return s.ledger.balance(worker, year, 0)
+}
+
+// GetOwnHolidayBalance - Obtiene el saldo de vacaciones del trabajador autenticado
+// -------------------------------------------------------------------
+func (s *HolidayService) GetOwnHolidayBalance(userID string, year int) (*dtos.HolidayBalance, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+	return s.ledger.balance(worker, year, 0)
+}
+
+// SetHolidayEntitlement - Fija el derecho a vacaciones de un trabajador en un año
+// -------------------------------------------------------------------
+func (s *HolidayService) SetHolidayEntitlement(workerID string, entitlement *models.HolidayEntitlement) error {
+	if _, err := s.workerRepo.FindWorkerByID(workerID); err != nil {
+		return errors.New("el trabajador no existe")
+	}
+
+	entitlement.ID = 0
+	entitlement.WorkerID = workerID
+	if entitlement.Year < 2000 || entitlement.Year > 2100 {
+		return errors.New("el año del derecho a vacaciones no es valido")
+	}
+	if entitlement.DayType != models.HolidayDayTypeCalendar && entitlement.DayType != models.HolidayDayTypeWorking {
+		return errors.New("el tipo de dias debe ser naturales o laborables")
+	}
+	if entitlement.Days < 0 || (entitlement.CarryOverDays != nil && *entitlement.CarryOverDays < 0) {
+		return errors.New("los dias de vacaciones no pueden ser negativos")
+	}
+
+	if err := s.holidaysRepo.SaveEntitlement(entitlement); err != nil {
+		return errors.New("error al guardar el derecho a vacaciones")
+	}
+	return nil
+}
+
+// GetLeaveTypes - Obtiene los tipos de ausencia (solo los activos para los trabajadores)
+// -------------------------------------------------------------------
+func (s *HolidayService) GetLeaveTypes(activeOnly bool) ([]models.LeaveType, error) {
+	return s.holidaysRepo.GetLeaveTypes(activeOnly)
+}
+
+// CreateLeaveType - Crea un tipo de ausencia
+// -------------------------------------------------------------------
+func (s *HolidayService) CreateLeaveType(leaveType *models.LeaveType) error {
+	if err := utils.ValidateLeaveTypeFields(leaveType); err != nil {
+		return err
+	}
+	if _, err := s.holidaysRepo.FindLeaveType(leaveType.Code); err == nil {
+		return errors.New("ya existe un tipo de ausencia con ese codigo")
+	}
+	if err := s.holidaysRepo.CreateLeaveType(leaveType); err != nil {
+		return errors.New("error al crear el tipo de ausencia")
+	}
+	return nil
+}
+
+// UpdateLeaveType - Actualiza un tipo de ausencia
+// -------------------------------------------------------------------
+// Las vacaciones siempre descuentan del saldo y no se pueden desactivar,
+// porque los endpoints de vacaciones dependen de ellas.
+func (s *HolidayService) UpdateLeaveType(code string, leaveType *models.LeaveType) error {
+	if _, err := s.holidaysRepo.FindLeaveType(code); err != nil {
+		return errors.New("el tipo de ausencia no existe")
+	}
+	leaveType.Code = code
+	if err := utils.ValidateLeaveTypeFields(leaveType); err != nil {
+		return err
+	}
+	if code == models.LeaveTypeVacation && (!leaveType.CountsAsHoliday || !leaveType.Active) {
+		return errors.New("las vacaciones deben seguir activas y descontando del saldo")
+	}
+	if err := s.holidaysRepo.UpdateLeaveType(leaveType); err != nil {
+		return errors.New("error al actualizar el tipo de ausencia")
+	}
+	return nil
+}
+
+// holidayForDocument - Comprueba que el usuario pueda ver o adjuntar el justificante de una ausencia
+// -------------------------------------------------------------------
+// Los justificantes pueden tener datos medicos: solo los ven el trabajador y los admins.
+func (s *HolidayService) holidayForDocument(userID, role string, holidayID int) (*models.Holiday, error) {
+	holiday, err := s.holidaysRepo.GetHolidayByID(holidayID)
+	if err != nil {
+		return nil, errors.New("la vacacion no existe")
+	}
+	if role != "admin" {
+		worker, err := s.workerRepo.FindWorkerByUserID(userID)
+		if err != nil || worker.ID != holiday.WorkerID {
+			return nil, errors.New("la vacacion no es tuya")
+		}
+	}
+	return holiday, nil
+}
+
+// AttachDocument - Guarda el justificante de una ausencia
+// -------------------------------------------------------------------
+// Devuelve la ruta del justificante anterior para que se pueda borrar.
+func (s *HolidayService) AttachDocument(userID, role string, holidayID int, document string) (string, error) {
+	holiday, err := s.holidayForDocument(userID, role, holidayID)
+	if err != nil {
+		return "", err
+	}
+	switch holiday.Status {
+	case models.HolidayStatusRejected, models.HolidayStatusCancelled:
+		return "", errors.New("no se pueden adjuntar justificantes a ausencias rechazadas o canceladas")
+	}
+	if err := s.holidaysRepo.UpdateHolidayDocument(holiday.ID, document); err != nil {
+		return "", errors.New("error al guardar el justificante")
+	}
+	return holiday.Document, nil
+}
+
+// GetDocument - Obtiene la ruta del justificante de una ausencia
+// -------------------------------------------------------------------
+func (s *HolidayService) GetDocument(userID, role string, holidayID int) (string, error) {
+	holiday, err := s.holidayForDocument(userID, role, holidayID)
+	if err != nil {
+		return "", err
+	}
+	if holiday.Document == "" {
+		return "", errors.New("la ausencia no tiene justificante")
+	}
+	return holiday.Document, nil
+}
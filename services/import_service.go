@@ -0,0 +1,324 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Maximo de filas de una importacion de trabajadores o tiendas
+const maxPeopleImportRows = 1000
+
+// Nombres de columna admitidos al importar trabajadores
+var workerColumns = map[string]string{
+	"name":       "name",
+	"nombre":     "name",
+	"last_name":  "last_name",
+	"apellidos":  "last_name",
+	"email":      "email",
+	"nie":        "nie",
+	"dni":        "nie",
+	"cargo":      "cargo",
+	"store":      "store",
+	"tienda":     "store",
+	"hire_date":  "hire_date",
+	"fecha_alta": "hire_date",
+	"nss":        "nss",
+	"naf":        "nss",
+}
+
+// Nombres de columna admitidos al importar tiendas
+var storeColumns = map[string]string{
+	"name":        "name",
+	"nombre":      "name",
+	"city":        "city",
+	"ciudad":      "city",
+	"phone":       "phone",
+	"telefono":    "phone",
+	"status":      "status",
+	"estado":      "status",
+	"claim_mode":  "claim_mode",
+	"modo_turnos": "claim_mode",
+}
+
+// importRow - Fila con datos de un fichero importado
+type importRow struct {
+	line   int
+	values map[string]string
+}
+
+// readImportRows - Lee un CSV o XLSX y devuelve sus filas por nombre de campo
+// -------------------------------------------------------------------
+// La primera fila es la cabecera; las columnas desconocidas se ignoran y
+// todas las de required deben estar. Las filas vacias se saltan.
+func readImportRows(data []byte, known map[string]string, required ...string) ([]importRow, error) {
+	records, err := utils.ReadSpreadsheet(data, maxPeopleImportRows)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("el fichero esta vacio")
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		if field, ok := known[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		}
+	}
+	for _, field := range required {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("el fichero debe tener una columna %s", field)
+		}
+	}
+
+	var rows []importRow
+	for i, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == maxPeopleImportRows {
+			return nil, fmt.Errorf("el fichero no puede tener mas de %d filas", maxPeopleImportRows)
+		}
+		row := importRow{line: i + 2, values: make(map[string]string, len(columns))}
+		for field, index := range columns {
+			row.values[field] = strings.TrimSpace(cell(record, index))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("el fichero no contiene filas")
+	}
+	return rows, nil
+}
+
+// importError - Error de una fila, con el campo y el codigo si los tiene
+func importError(line int, err error) dtos.ImportError {
+	rowError := dtos.ImportError{Line: line, Error: err.Error()}
+	var fieldErr *utils.FieldError
+	if errors.As(err, &fieldErr) {
+		rowError.Field = fieldErr.Field
+		rowError.Code = fieldErr.Code
+	}
+	return rowError
+}
+
+// ImportWorkers - Da de alta trabajadores desde un CSV o XLSX
+// -------------------------------------------------------------------
+// Cada fila se valida como en el formulario; la tienda se indica por nombre
+// o por ID. Si alguna fila tiene errores no se importa nada y se devuelven
+// todos. Los usuarios se crean igual que en CreateWorker y todo va en una
+// transaccion; con dryRun se deshace al final y solo se devuelve el informe.
+func (s *AdminService) ImportWorkers(data []byte, dryRun bool) (*dtos.ImportResult, error) {
+	rows, err := readImportRows(data, workerColumns, "name", "last_name", "email", "nie", "cargo")
+	if err != nil {
+		return nil, err
+	}
+
+	stores, err := s.storeRepo.GetAllStores()
+	if err != nil {
+		return nil, errors.New("error al obtener las tiendas")
+	}
+	storeIDs := make(map[string]string, 2*len(stores))
+	for _, store := range stores {
+		storeIDs[store.ID] = store.ID
+		storeIDs[strings.ToLower(store.Name)] = store.ID
+	}
+
+	result := &dtos.ImportResult{DryRun: dryRun, Errors: []dtos.ImportError{}}
+	seen := make(map[string]int)
+	workers := make([]models.Worker, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		worker := models.Worker{
+			Name:     row.values["name"],
+			LastName: row.values["last_name"],
+			Email:    row.values["email"],
+			Nie:      row.values["nie"],
+			Nss:      row.values["nss"],
+			Cargo:    row.values["cargo"],
+		}
+		if date := utils.ExcelDate(row.values["hire_date"]); date != "" {
+			worker.HireDate = &date
+		}
+		if name := row.values["store"]; name != "" {
+			storeID, ok := storeIDs[name]
+			if !ok {
+				storeID, ok = storeIDs[strings.ToLower(name)]
+			}
+			if !ok {
+				result.Errors = append(result.Errors, dtos.ImportError{Line: row.line, Error: fmt.Sprintf("la tienda %s no existe", name)})
+				continue
+			}
+			worker.StoreID = &storeID
+		}
+		if err := utils.ValidateWorkerFields(&worker); err != nil {
+			result.Errors = append(result.Errors, importError(row.line, err))
+			continue
+		}
+		if first, ok := seen[worker.Nie]; ok {
+			result.Errors = append(result.Errors, dtos.ImportError{Line: row.line, Error: fmt.Sprintf("NIE repetido, ya aparece en la linea %d", first)})
+			continue
+		}
+		seen[worker.Nie] = row.line
+
+		existing, err := s.workerRepo.FindWorkerByNie(worker.Nie)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("error al verificar la existencia del trabajador")
+		}
+		if existing != nil && existing.ID != "" {
+			message := "el trabajador ya existe"
+			if existing.DeletedAt.Valid {
+				message = "el trabajador esta archivado, restauralo en lugar de importarlo"
+			}
+			result.Errors = append(result.Errors, dtos.ImportError{Line: row.line, Error: message})
+			continue
+		}
+
+		worker.Status = "Baja" // Pasa a Alta al registrar su contrato
+		worker.Prueba = "No"
+		workers = append(workers, worker)
+		lines = append(lines, row.line)
+	}
+	if len(result.Errors) > 0 {
+		if dryRun {
+			return result, nil
+		}
+		return result, errors.New("el fichero tiene errores y no se ha importado")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i := range workers {
+		username, err := s.provisionWorker(tx, &workers[i])
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("linea %d: %s", lines[i], err.Error())
+		}
+		result.Preview = append(result.Preview, dtos.ImportPreview{
+			Line:     lines[i],
+			Name:     workers[i].Name + " " + workers[i].LastName,
+			Username: username,
+		})
+	}
+	result.Created = len(workers)
+
+	if dryRun {
+		tx.Rollback()
+		return result, nil
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("error al confirmar la transaccion")
+	}
+	return result, nil
+}
+
+// ImportStores - Da de alta tiendas desde un CSV o XLSX
+// -------------------------------------------------------------------
+// Igual que ImportWorkers: sin errores se crean todas en una transaccion,
+// con el usuario y la contraseña de tienda de CreateStore.
+func (s *AdminService) ImportStores(data []byte, dryRun bool) (*dtos.ImportResult, error) {
+	rows, err := readImportRows(data, storeColumns, "name", "city", "phone", "status")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dtos.ImportResult{DryRun: dryRun, Errors: []dtos.ImportError{}}
+	seen := make(map[string]int)
+	stores := make([]models.Store, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		store := models.Store{
+			Name:      row.values["name"],
+			City:      row.values["city"],
+			Phone:     row.values["phone"],
+			Status:    row.values["status"],
+			ClaimMode: row.values["claim_mode"],
+		}
+		if err := utils.ValidateStoreFields(&store); err != nil {
+			result.Errors = append(result.Errors, importError(row.line, err))
+			continue
+		}
+		key := strings.ToLower(store.Name)
+		if first, ok := seen[key]; ok {
+			result.Errors = append(result.Errors, dtos.ImportError{Line: row.line, Error: fmt.Sprintf("tienda repetida, ya aparece en la linea %d", first)})
+			continue
+		}
+		seen[key] = row.line
+
+		existing, err := s.storeRepo.FindStoreByName(store.Name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("error al verificar la existencia de la tienda")
+		}
+		if existing != nil && existing.ID != "" {
+			message := "la tienda ya existe"
+			if existing.DeletedAt.Valid {
+				message = "la tienda esta archivada, restaurala en lugar de importarla"
+			}
+			result.Errors = append(result.Errors, dtos.ImportError{Line: row.line, Error: message})
+			continue
+		}
+
+		stores = append(stores, store)
+		lines = append(lines, row.line)
+	}
+	if len(result.Errors) > 0 {
+		if dryRun {
+			return result, nil
+		}
+		return result, errors.New("el fichero tiene errores y no se ha importado")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i := range stores {
+		username, err := s.provisionStore(tx, &stores[i])
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("linea %d: %s", lines[i], err.Error())
+		}
+		result.Preview = append(result.Preview, dtos.ImportPreview{
+			Line:     lines[i],
+			Name:     stores[i].Name,
+			Username: username,
+		})
+	}
+	result.Created = len(stores)
+
+	if dryRun {
+		tx.Rollback()
+		return result, nil
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("error al confirmar la transaccion")
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"gorm.io/gorm"
+)
+
+type NotificationService struct {
+	notifyRepo *repositories.NotificationRepository
+}
+
+func NewNotificationService(notifyRepo *repositories.NotificationRepository) *NotificationService {
+	return &NotificationService{notifyRepo: notifyRepo}
+}
+
+// GetNotifications - Obtiene las notificaciones del usuario autenticado
+// --------------------------------------------------------------------
+func (s *NotificationService) GetNotifications(userID string, unreadOnly bool) ([]models.Notification, error) {
+	return s.notifyRepo.GetUserNotifications(userID, unreadOnly)
+}
+
+// MarkAsRead - Marca como leida una notificacion del usuario autenticado
+// --------------------------------------------------------------------
+func (s *NotificationService) MarkAsRead(userID, notificationID string) error {
+	return s.notifyRepo.MarkAsRead(userID, notificationID)
+}
+
+// notifyAdmins - Avisa a todos los administradores
+// --------------------------------------------------------------------
+func notifyAdmins(tx *gorm.DB, userRepo *repositories.UserRepository, notifyRepo *repositories.NotificationRepository, notificationType, title, body string) error {
+	admins, err := userRepo.GetUsersByRole("admin")
+	if err != nil {
+		return errors.New("error al obtener los administradores")
+	}
+	for i := range admins {
+		notification := &models.Notification{
+			UserID: admins[i].ID,
+			Type:   notificationType,
+			Title:  title,
+			Body:   body,
+		}
+		if err := notifyRepo.CreateNotification(tx, notification); err != nil {
+			return errors.New("error al notificar a los administradores")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"gorm.io/gorm"
+)
+
+// claimMode - Modo de asignacion de turnos abiertos de una tienda (Aprobacion por defecto)
+func claimMode(store *models.Store) string {
+	if store.ClaimMode == models.ClaimModeFirstCome {
+		return models.ClaimModeFirstCome
+	}
+	return models.ClaimModeApproval
+}
+
+// canClaimShift - Indica si un trabajador puede reclamar un turno abierto
+// -------------------------------------------------------------------
+// Debe estar de alta, tener el cargo requerido y pertenecer a la tienda del
+// turno o, si el turno se ofrece a tiendas cercanas, a una de la misma ciudad.
+func canClaimShift(worker *models.Worker, workerStore, shiftStore *models.Store, shift *models.WorkShift) bool {
+	if worker.Status != "Alta" || workerStore == nil {
+		return false
+	}
+	if shift.Cargo != "" && worker.Cargo != shift.Cargo {
+		return false
+	}
+	if workerStore.ID == shiftStore.ID {
+		return true
+	}
+	return shift.OpenToNearby && workerStore.City == shiftStore.City
+}
+
+// workerStore - Devuelve la tienda de un trabajador (nil si no tiene)
+// -------------------------------------------------------------------
+func (s *ShiftService) workerStore(worker *models.Worker) *models.Store {
+	if worker.StoreID == nil {
+		return nil
+	}
+	store, err := s.storeRepo.FindStoreByID(*worker.StoreID)
+	if err != nil {
+		return nil
+	}
+	return store
+}
+
+// notifyOpenShift - Avisa a los trabajadores que pueden reclamar un turno abierto
+// -------------------------------------------------------------------
+func (s *ShiftService) notifyOpenShift(tx *gorm.DB, shift *models.WorkShift) error {
+	shiftStore, err := s.storeRepo.FindStoreByID(shift.Store)
+	if err != nil {
+		return errors.New("la tienda del turno no existe")
+	}
+
+	stores := []models.Store{*shiftStore}
+	if shift.OpenToNearby {
+		stores, err = s.storeRepo.GetStoresByCity(shiftStore.City)
+		if err != nil {
+			return errors.New("error al obtener las tiendas cercanas")
+		}
+	}
+
+	for i := range stores {
+		workers, err := s.workerRepo.GetWorkersByStore(stores[i].ID)
+		if err != nil {
+			return errors.New("error al obtener los trabajadores")
+		}
+		for j := range workers {
+			if !canClaimShift(&workers[j], &stores[i], shiftStore, shift) {
+				continue
+			}
+			notification := &models.Notification{
+				UserID: workers[j].UserID,
+				Type:   "turno_abierto",
+				Title:  "Turno disponible en " + shiftStore.Name,
+				Body:   describeSnapshot(snapshotOf(shift)),
+			}
+			if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+				return errors.New("error al notificar el turno abierto")
+			}
+		}
+	}
+	return nil
+}
+
+// GetClaimableShifts - Obtiene los turnos abiertos que puede reclamar el trabajador autenticado
+// -------------------------------------------------------------------
+func (s *ShiftService) GetClaimableShifts(userID string) ([]models.WorkShift, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+	ownStore := s.workerStore(worker)
+	if ownStore == nil {
+		return []models.WorkShift{}, nil
+	}
+
+	// Turnos de su tienda y de las tiendas de su ciudad
+	stores, err := s.storeRepo.GetStoresByCity(ownStore.City)
+	if err != nil {
+		return nil, errors.New("error al obtener las tiendas cercanas")
+	}
+	storesByID := make(map[string]*models.Store, len(stores)+1)
+	storeIDs := []string{ownStore.ID}
+	storesByID[ownStore.ID] = ownStore
+	for i := range stores {
+		if stores[i].ID != ownStore.ID {
+			storesByID[stores[i].ID] = &stores[i]
+			storeIDs = append(storeIDs, stores[i].ID)
+		}
+	}
+
+	shifts, err := s.shiftRepo.GetOpenShifts(storeIDs, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return nil, errors.New("error al obtener los turnos abiertos")
+	}
+
+	claimable := []models.WorkShift{}
+	for i := range shifts {
+		if canClaimShift(worker, ownStore, storesByID[shifts[i].Store], &shifts[i]) {
+			claimable = append(claimable, shifts[i])
+		}
+	}
+	return claimable, nil
+}
+
+// ClaimShift - El trabajador autenticado reclama un turno abierto
+// -------------------------------------------------------------------
+// En las tiendas con modo Directo el turno se asigna al momento; en el resto
+// queda una reclamacion pendiente de aprobacion. Devuelve la reclamacion creada.
+func (s *ShiftService) ClaimShift(userID string, shiftID int) (*models.ShiftClaim, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloqueamos el turno para que dos trabajadores no puedan quedarse el mismo
+	shift, err := s.shiftRepo.LockShiftByID(tx, shiftID)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("el turno no existe")
+	}
+	if shift.WorkerID != nil {
+		tx.Rollback()
+		return nil, errors.New("el turno ya esta cubierto")
+	}
+	period, err := s.shiftPeriod(tx, shift)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !isPublished(period) {
+		tx.Rollback()
+		return nil, errors.New("el turno aun no esta publicado")
+	}
+	started, err := shiftStarted(shift, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if started {
+		tx.Rollback()
+		return nil, errors.New("el turno ya ha empezado")
+	}
+
+	// Comprobamos que el trabajador sea elegible y cumpla las reglas de planificacion
+	shiftStore, err := s.storeRepo.FindStoreByID(shift.Store)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("la tienda del turno no existe")
+	}
+	if !canClaimShift(worker, s.workerStore(worker), shiftStore, shift) {
+		tx.Rollback()
+		return nil, errors.New("no puedes reclamar este turno")
+	}
+	if err := s.checkShiftAssignment(tx, worker.ID, shift); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	claim := &models.ShiftClaim{
+		ID:       uuid.New().String(),
+		ShiftID:  shift.ID,
+		WorkerID: worker.ID,
+		Status:   models.ClaimStatusPending,
+	}
+
+	if claimMode(shiftStore) == models.ClaimModeFirstCome {
+		// El primero que lo reclama se queda el turno
+		if err := s.reassignShift(tx, userID, shift, worker.ID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		now := time.Now()
+		claim.Status = models.ClaimStatusApproved
+		claim.DecidedAt = &now
+	} else {
+		// Queda pendiente de que el encargado elija
+		pending, err := s.claimRepo.CountPendingClaims(tx, shift.ID, worker.ID)
+		if err != nil {
+			tx.Rollback()
+			return nil, errors.New("error al comprobar las reclamaciones del turno")
+		}
+		if pending > 0 {
+			tx.Rollback()
+			return nil, errors.New("ya has reclamado este turno")
+		}
+		notification := &models.Notification{
+			UserID: shiftStore.UserID,
+			Type:   "reclamacion_turno",
+			Title:  worker.Name + " " + worker.LastName + " quiere cubrir un turno abierto",
+			Body:   describeSnapshot(snapshotOf(shift)),
+		}
+		if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+			tx.Rollback()
+			return nil, errors.New("error al notificar al encargado")
+		}
+	}
+
+	if err := s.claimRepo.CreateClaim(tx, claim); err != nil {
+		tx.Rollback()
+		return nil, errors.New("error al guardar la reclamacion")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("error al confirmar la transaccion")
+	}
+	return claim, nil
+}
+
+// closeClaim - Cierra una reclamacion pendiente con un estado final
+// -------------------------------------------------------------------
+// Bloquea la reclamacion para que no pise una aprobacion concurrente. check
+// valida la reclamacion bloqueada antes de cerrarla.
+func (s *ShiftService) closeClaim(claimID, status string, decidedByID *string, note string, check func(tx *gorm.DB, claim *models.ShiftClaim) error) error {
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	claim, err := s.claimRepo.LockClaimByID(tx, claimID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la reclamacion no existe")
+	}
+	if err := check(tx, claim); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if claim.Status != models.ClaimStatusPending {
+		tx.Rollback()
+		return errors.New("la reclamacion ya no esta pendiente")
+	}
+
+	now := time.Now()
+	claim.Status = status
+	claim.DecidedByID = decidedByID
+	claim.DecidedAt = &now
+	claim.DecisionNote = note
+	if err := s.claimRepo.UpdateClaimDecision(tx, claim); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar la reclamacion")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// WithdrawClaim - El trabajador retira una reclamacion pendiente
+// -------------------------------------------------------------------
+func (s *ShiftService) WithdrawClaim(userID, claimID string) error {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	return s.closeClaim(claimID, models.ClaimStatusWithdrawn, nil, "", func(tx *gorm.DB, claim *models.ShiftClaim) error {
+		if claim.WorkerID != worker.ID {
+			return errors.New("la reclamacion no es tuya")
+		}
+		return nil
+	})
+}
+
+// GetWorkerClaims - Obtiene las reclamaciones del trabajador autenticado
+// -------------------------------------------------------------------
+func (s *ShiftService) GetWorkerClaims(userID string) ([]models.ShiftClaim, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+	return s.claimRepo.GetClaimsByWorker(worker.ID)
+}
+
+// GetClaims - Obtiene las reclamaciones visibles para un encargado o admin
+// -------------------------------------------------------------------
+func (s *ShiftService) GetClaims(userID, role, status string) ([]models.ShiftClaim, error) {
+	storeID := ""
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+	return s.claimRepo.GetClaims(storeID, status)
+}
+
+// ApproveClaim - El encargado o el admin asigna el turno abierto a una reclamacion
+// -------------------------------------------------------------------
+// El resto de reclamaciones pendientes del turno quedan rechazadas.
+func (s *ShiftService) ApproveClaim(userID, role, claimID, note string) error {
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	claim, err := s.claimRepo.LockClaimByID(tx, claimID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la reclamacion no existe")
+	}
+	if claim.Status != models.ClaimStatusPending {
+		tx.Rollback()
+		return errors.New("la reclamacion ya no esta pendiente")
+	}
+
+	shift, err := s.shiftRepo.LockShiftByID(tx, claim.ShiftID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("el turno no existe")
+	}
+	if err := s.checkStoreAccess(userID, role, shift.Store); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if shift.WorkerID != nil {
+		tx.Rollback()
+		return errors.New("el turno ya esta cubierto")
+	}
+
+	// Volvemos a validar las reglas: el horario del trabajador puede haber cambiado
+	if err := s.checkShiftAssignment(tx, claim.WorkerID, shift); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.reassignShift(tx, userID, shift, claim.WorkerID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	now := time.Now()
+	claim.Status = models.ClaimStatusApproved
+	claim.DecidedByID = &userID
+	claim.DecidedAt = &now
+	claim.DecisionNote = note
+	if err := s.claimRepo.UpdateClaim(tx, claim); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar la reclamacion")
+	}
+	if err := s.claimRepo.RejectOtherClaims(tx, shift.ID, claim.ID, userID, "Turno asignado a otro trabajador"); err != nil {
+		tx.Rollback()
+		return errors.New("error al cerrar el resto de reclamaciones")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// RejectClaim - El encargado o el admin rechaza una reclamacion
+// -------------------------------------------------------------------
+func (s *ShiftService) RejectClaim(userID, role, claimID, note string) error {
+	return s.closeClaim(claimID, models.ClaimStatusRejected, &userID, note, func(tx *gorm.DB, claim *models.ShiftClaim) error {
+		shift, err := s.shiftRepo.FindShiftByID(tx, claim.ShiftID)
+		if err != nil {
+			return errors.New("el turno no existe")
+		}
+		return s.checkStoreAccess(userID, role, shift.Store)
+	})
+}
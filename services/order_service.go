@@ -0,0 +1,417 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+type OrderService struct {
+	orderRepo    *repositories.OrderRepository
+	productRepo  *repositories.ProductRepository
+	stockRepo    *repositories.StockRepository
+	supplierRepo *repositories.SupplierRepository
+	purchaseRepo *repositories.PurchaseOrderRepository
+	storeRepo    *repositories.StoreRepository
+	userRepo     *repositories.UserRepository
+	notifyRepo   *repositories.NotificationRepository
+
+	db *gorm.DB
+}
+
+func NewOrderService(
+	orderRepo *repositories.OrderRepository,
+	productRepo *repositories.ProductRepository,
+	stockRepo *repositories.StockRepository,
+	supplierRepo *repositories.SupplierRepository,
+	purchaseRepo *repositories.PurchaseOrderRepository,
+	storeRepo *repositories.StoreRepository,
+	userRepo *repositories.UserRepository,
+	notifyRepo *repositories.NotificationRepository,
+	db *gorm.DB) *OrderService {
+	return &OrderService{
+		orderRepo:    orderRepo,
+		productRepo:  productRepo,
+		stockRepo:    stockRepo,
+		supplierRepo: supplierRepo,
+		purchaseRepo: purchaseRepo,
+		storeRepo:    storeRepo,
+		userRepo:     userRepo,
+		notifyRepo:   notifyRepo,
+		db:           db,
+	}
+}
+
+// OrderTransitionError - El pedido no puede pasar al estado pedido
+type OrderTransitionError struct {
+	From    string
+	To      string
+	Allowed []string // Estados a los que si puede pasar quien lo intenta
+}
+
+func (e *OrderTransitionError) Error() string {
+	return fmt.Sprintf("un pedido %s no puede pasar a %s", e.From, e.To)
+}
+
+// CreateOrder - La tienda crea un pedido de suministros, como borrador o ya enviado
+// -------------------------------------------------------------------
+// El numero de pedido es correlativo por tienda y año (2025-0001).
+func (s *OrderService) CreateOrder(userID string, order *models.Order, draft bool) error {
+	store, err := s.storeRepo.FindStoreByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro la tienda del usuario")
+	}
+	return s.createOrder(store, userID, "store", order, draft)
+}
+
+// createOrder - Crea un pedido de una tienda en nombre de un usuario
+// -------------------------------------------------------------------
+func (s *OrderService) createOrder(store *models.Store, userID, role string, order *models.Order, draft bool) error {
+	now := time.Now()
+	order.ID = uuid.New().String()
+	order.StoreID = store.ID
+	order.Date = now.Format("2006-01-02")
+	order.Status = models.OrderStatusSubmitted
+	if draft {
+		order.Status = models.OrderStatusDraft
+	}
+	order.CreatedByID = userID
+	for i := range order.Lines {
+		order.Lines[i].ID = 0
+		order.Lines[i].OrderID = order.ID
+	}
+	if err := utils.ValidateOrderFields(order); err != nil {
+		return err
+	}
+	if err := s.fillOrderLines(store.ID, order.Lines); err != nil {
+		return err
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	sequence, err := s.orderRepo.NextOrderSequence(tx, store.ID, now.Year())
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al numerar el pedido")
+	}
+	order.Sequence = sequence
+	order.Number = fmt.Sprintf("%d-%04d", now.Year(), sequence)
+
+	if err := s.orderRepo.CreateOrder(tx, order); err != nil {
+		tx.Rollback()
+		return errors.New("error al crear el pedido")
+	}
+
+	change := &models.OrderStatusChange{
+		OrderID:   order.ID,
+		ToStatus:  order.Status,
+		ActorID:   userID,
+		ActorRole: role,
+	}
+	if err := s.orderRepo.CreateStatusChange(tx, change); err != nil {
+		tx.Rollback()
+		return errors.New("error al registrar el historial del pedido")
+	}
+
+	if !draft {
+		title := fmt.Sprintf("Nuevo pedido %s de %s", order.Number, store.Name)
+		body := fmt.Sprintf("%d lineas", len(order.Lines))
+		if err := notifyAdmins(tx, s.userRepo, s.notifyRepo, "nuevo_pedido", title, body); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	order.StoreName = store.Name
+	return nil
+}
+
+// fillOrderLines - Completa las lineas con los datos del catalogo
+// -------------------------------------------------------------------
+// Cada producto debe estar activo, en la lista de la tienda y pedirse en
+// multiplos de su bulto.
+func (s *OrderService) fillOrderLines(storeID string, lines []models.OrderLine) error {
+	productIDs := make([]string, 0, len(lines))
+	lineOf := make(map[string]int)
+	for i, line := range lines {
+		if first, ok := lineOf[*line.ProductID]; ok {
+			return fmt.Errorf("el producto de la linea %d ya esta en la linea %d", i+1, first+1)
+		}
+		lineOf[*line.ProductID] = i
+		productIDs = append(productIDs, *line.ProductID)
+	}
+
+	allowed, err := s.productRepo.GetAllowedProducts(storeID, productIDs)
+	if err != nil {
+		return errors.New("error al comprobar los productos del pedido")
+	}
+	products := make(map[string]models.Product, len(allowed))
+	for _, product := range allowed {
+		products[product.ID] = product
+	}
+
+	for i := range lines {
+		line := &lines[i]
+		product, ok := products[*line.ProductID]
+		if !ok {
+			return fmt.Errorf("el producto de la linea %d no existe o la tienda no puede pedirlo", i+1)
+		}
+		if product.PackSize > 1 && line.Quantity%product.PackSize != 0 {
+			return fmt.Errorf("%s se pide en bultos de %d %s", product.Name, product.PackSize, product.Unit)
+		}
+		line.SKU = product.SKU
+		line.Product = product.Name
+		line.Unit = product.Unit
+	}
+	return nil
+}
+
+// GetOrders - Obtiene los pedidos filtrando por tienda, estado y fechas
+// -------------------------------------------------------------------
+// Las tiendas solo ven sus pedidos.
+func (s *OrderService) GetOrders(userID, role, storeID, status, from, to string) ([]models.Order, error) {
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+	if from != "" {
+		if _, err := utils.ParseDate(from); err != nil {
+			return nil, errors.New("la fecha de inicio no tiene el formato YYYY-MM-DD")
+		}
+	}
+	if to != "" {
+		if _, err := utils.ParseDate(to); err != nil {
+			return nil, errors.New("la fecha de fin no tiene el formato YYYY-MM-DD")
+		}
+	}
+
+	orders, err := s.orderRepo.GetOrders(storeID, status, from, to)
+	if err != nil {
+		return nil, errors.New("error al obtener los pedidos")
+	}
+	return orders, nil
+}
+
+// GetOrder - Obtiene un pedido con sus lineas
+// -------------------------------------------------------------------
+func (s *OrderService) GetOrder(userID, role, orderID string) (*models.Order, error) {
+	order, err := s.orderRepo.FindOrderByID(orderID)
+	if err != nil {
+		return nil, errors.New("el pedido no existe")
+	}
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil || store.ID != order.StoreID {
+			return nil, errors.New("el pedido no existe")
+		}
+	}
+	return order, nil
+}
+
+// UpdateDraft - La tienda cambia las lineas de un pedido en borrador
+// -------------------------------------------------------------------
+func (s *OrderService) UpdateDraft(userID, orderID string, order *models.Order) error {
+	store, err := s.storeRepo.FindStoreByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro la tienda del usuario")
+	}
+	for i := range order.Lines {
+		order.Lines[i].ID = 0
+		order.Lines[i].OrderID = orderID
+	}
+	if err := utils.ValidateOrderFields(order); err != nil {
+		return err
+	}
+	if err := s.fillOrderLines(store.ID, order.Lines); err != nil {
+		return err
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	current, err := s.orderRepo.LockOrderByID(tx, orderID)
+	if err != nil || current.StoreID != store.ID {
+		tx.Rollback()
+		return errors.New("el pedido no existe")
+	}
+	if current.Status != models.OrderStatusDraft {
+		tx.Rollback()
+		return errors.New("solo se pueden modificar los pedidos en borrador")
+	}
+	if err := s.orderRepo.ReplaceOrderLines(tx, orderID, order.Comment, order.Lines); err != nil {
+		tx.Rollback()
+		return errors.New("error al guardar las lineas del pedido")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// ApproveOrder - El admin aprueba un pedido enviado
+// -------------------------------------------------------------------
+func (s *OrderService) ApproveOrder(userID, orderID, comment string) error {
+	return s.TransitionOrder(userID, "admin", orderID, models.OrderStatusApproved, comment)
+}
+
+// CancelOrder - La tienda cancela un pedido o el admin lo rechaza
+// -------------------------------------------------------------------
+func (s *OrderService) CancelOrder(userID, role, orderID, comment string) error {
+	return s.TransitionOrder(userID, role, orderID, models.OrderStatusCancelled, comment)
+}
+
+// TransitionOrder - Lleva un pedido a otro estado si el rol puede hacerlo
+// -------------------------------------------------------------------
+// Si el cambio no esta permitido devuelve un OrderTransitionError con los
+// estados a los que si se puede pasar.
+func (s *OrderService) TransitionOrder(userID, role, orderID, status, comment string) error {
+	var store *models.Store
+	var err error
+	if role != "admin" {
+		store, err = s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return errors.New("no se encontro la tienda del usuario")
+		}
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	order, err := s.orderRepo.LockOrderByID(tx, orderID)
+	if err != nil || (store != nil && store.ID != order.StoreID) {
+		tx.Rollback()
+		return errors.New("el pedido no existe")
+	}
+
+	allowed := models.NextOrderStatuses(order.Status, role)
+	if !slices.Contains(allowed, status) {
+		tx.Rollback()
+		return &OrderTransitionError{From: order.Status, To: status, Allowed: allowed}
+	}
+
+	// Al enviar un borrador volvemos a comprobar las lineas contra el catalogo
+	if status == models.OrderStatusSubmitted {
+		lines, err := s.orderRepo.GetOrderLines(tx, order.ID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("error al obtener las lineas del pedido")
+		}
+		if err := utils.ValidateOrderFields(&models.Order{Lines: lines}); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := s.fillOrderLines(order.StoreID, lines); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := s.changeStatus(tx, order, status, userID, role, comment); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// changeStatus - Cambia el estado de un pedido, lo anota en el historial y avisa a la otra parte
+// -------------------------------------------------------------------
+// No comprueba permisos; lo usan TransitionOrder y los cambios automaticos.
+func (s *OrderService) changeStatus(tx *gorm.DB, order *models.Order, status, userID, role, comment string) error {
+	if err := s.orderRepo.UpdateOrderStatus(tx, order.ID, status); err != nil {
+		return errors.New("error al actualizar el pedido")
+	}
+	change := &models.OrderStatusChange{
+		OrderID:    order.ID,
+		FromStatus: order.Status,
+		ToStatus:   status,
+		ActorID:    userID,
+		ActorRole:  role,
+		Comment:    comment,
+	}
+	if err := s.orderRepo.CreateStatusChange(tx, change); err != nil {
+		return errors.New("error al registrar el historial del pedido")
+	}
+	order.Status = status
+
+	store, err := s.storeRepo.FindStoreByID(order.StoreID)
+	if err != nil {
+		return errors.New("la tienda del pedido no existe")
+	}
+	title := fmt.Sprintf("Pedido %s: %s", order.Number, status)
+	if role == "admin" {
+		notification := &models.Notification{
+			UserID: store.UserID,
+			Type:   "estado_pedido",
+			Title:  title,
+			Body:   comment,
+		}
+		if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+			return errors.New("error al notificar a la tienda")
+		}
+		return nil
+	}
+	return notifyAdmins(tx, s.userRepo, s.notifyRepo, "estado_pedido", title+" ("+store.Name+")", comment)
+}
+
+// GetOrderHistory - Obtiene el historial de estados de un pedido y a que estados puede pasar
+// -------------------------------------------------------------------
+func (s *OrderService) GetOrderHistory(userID, role, orderID string) ([]models.OrderStatusChange, []string, error) {
+	order, err := s.GetOrder(userID, role, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := s.orderRepo.GetStatusChanges(order.ID)
+	if err != nil {
+		return nil, nil, errors.New("error al obtener el historial del pedido")
+	}
+	return changes, models.NextOrderStatuses(order.Status, role), nil
+}
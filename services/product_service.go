@@ -0,0 +1,465 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Filas maximas de un CSV de productos
+const maxProductImportRows = 5000
+
+// Nombres de columna admitidos en el CSV de productos
+var productColumns = map[string]string{
+	"sku":        "sku",
+	"referencia": "sku",
+	"name":       "name",
+	"nombre":     "name",
+	"category":   "category",
+	"categoria":  "category",
+	"unit":       "unit",
+	"unidad":     "unit",
+	"pack_size":  "pack_size",
+	"bulto":      "pack_size",
+	"active":     "active",
+	"activo":     "active",
+	"price":      "price",
+	"precio":     "price",
+	"supplier":   "supplier",
+	"proveedor":  "supplier",
+}
+
+type ProductService struct {
+	productRepo  *repositories.ProductRepository
+	supplierRepo *repositories.SupplierRepository
+	storeRepo    *repositories.StoreRepository
+
+	db *gorm.DB
+}
+
+func NewProductService(
+	productRepo *repositories.ProductRepository,
+	supplierRepo *repositories.SupplierRepository,
+	storeRepo *repositories.StoreRepository,
+	db *gorm.DB) *ProductService {
+	return &ProductService{
+		productRepo:  productRepo,
+		supplierRepo: supplierRepo,
+		storeRepo:    storeRepo,
+		db:           db,
+	}
+}
+
+// CreateProduct - Crea un producto del catalogo; los productos nuevos estan activos
+// -------------------------------------------------------------------
+func (s *ProductService) CreateProduct(product *models.Product) error {
+	product.ID = uuid.New().String()
+	product.Active = true
+	if err := s.validateProduct(product); err != nil {
+		return err
+	}
+	if _, err := s.productRepo.FindProductBySKU(product.SKU); err == nil {
+		return errors.New("ya existe un producto con ese SKU")
+	}
+	if err := s.productRepo.CreateProduct(nil, product); err != nil {
+		return errors.New("error al crear el producto")
+	}
+	return nil
+}
+
+// UpdateProduct - Actualiza un producto del catalogo
+// -------------------------------------------------------------------
+func (s *ProductService) UpdateProduct(productID string, product *models.Product) error {
+	current, err := s.productRepo.FindProductByID(productID)
+	if err != nil {
+		return errors.New("el producto no existe")
+	}
+	product.ID = current.ID
+	product.CreatedAt = current.CreatedAt
+	if err := s.validateProduct(product); err != nil {
+		return err
+	}
+	if other, err := s.productRepo.FindProductBySKU(product.SKU); err == nil && other.ID != product.ID {
+		return errors.New("ya existe un producto con ese SKU")
+	}
+	if err := s.productRepo.UpdateProduct(nil, product); err != nil {
+		return errors.New("error al actualizar el producto")
+	}
+	return nil
+}
+
+// validateProduct - Valida los campos de un producto y que exista su proveedor
+// -------------------------------------------------------------------
+func (s *ProductService) validateProduct(product *models.Product) error {
+	if err := utils.ValidateProductFields(product); err != nil {
+		return err
+	}
+	if product.SupplierID != nil && *product.SupplierID == "" {
+		product.SupplierID = nil
+	}
+	if product.SupplierID != nil {
+		if _, err := s.supplierRepo.FindSupplierByID(*product.SupplierID); err != nil {
+			return errors.New("el proveedor no existe")
+		}
+	}
+	return nil
+}
+
+// GetProducts - Obtiene el catalogo
+// -------------------------------------------------------------------
+func (s *ProductService) GetProducts(category string, activeOnly bool) ([]models.Product, error) {
+	return s.productRepo.GetProducts(category, activeOnly)
+}
+
+// ImportProducts - Crea o actualiza productos desde un CSV
+// -------------------------------------------------------------------
+// La primera fila es la cabecera y debe incluir el SKU. Los productos se
+// buscan por SKU: si existen solo se cambian las columnas del fichero. Si
+// alguna fila tiene errores no se importa nada y se devuelven todos.
+func (s *ProductService) ImportProducts(data []byte) (*dtos.ImportResult, error) {
+	reader := utils.NewCSVReader(data)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("el CSV esta vacio")
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		if field, ok := productColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["sku"]; !ok {
+		return nil, errors.New("el CSV debe tener una columna sku")
+	}
+
+	type productRow struct {
+		line   int
+		record []string
+	}
+	var rows []productRow
+	var skus []string
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al leer el CSV en la linea %d", line)
+		}
+		if len(record) == 0 || strings.Join(record, "") == "" {
+			continue
+		}
+		if len(rows) == maxProductImportRows {
+			return nil, fmt.Errorf("el CSV no puede tener mas de %d productos", maxProductImportRows)
+		}
+		rows = append(rows, productRow{line, record})
+		skus = append(skus, strings.TrimSpace(cell(record, columns["sku"])))
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("el CSV no contiene productos")
+	}
+
+	existing, err := s.productRepo.GetProductsBySKU(skus)
+	if err != nil {
+		return nil, errors.New("error al obtener los productos")
+	}
+	bySKU := make(map[string]models.Product, len(existing))
+	for _, product := range existing {
+		bySKU[product.SKU] = product
+	}
+	suppliers, err := s.supplierRepo.GetSuppliers(false)
+	if err != nil {
+		return nil, errors.New("error al obtener los proveedores")
+	}
+	supplierIDs := make(map[string]string, len(suppliers))
+	for _, supplier := range suppliers {
+		supplierIDs[strings.ToLower(supplier.Name)] = supplier.ID
+	}
+
+	result := &dtos.ImportResult{Errors: []dtos.ImportError{}}
+	seen := make(map[string]int)
+	var created, updated []models.Product
+	for _, row := range rows {
+		sku := strings.TrimSpace(cell(row.record, columns["sku"]))
+		if first, ok := seen[sku]; ok {
+			result.Errors = append(result.Errors, dtos.ImportError{Line: row.line, Error: fmt.Sprintf("SKU repetido, ya aparece en la linea %d", first)})
+			continue
+		}
+		seen[sku] = row.line
+
+		product, exists := bySKU[sku]
+		if !exists {
+			product = models.Product{ID: uuid.New().String(), SKU: sku, Active: true}
+		}
+		err := applyProductColumns(&product, row.record, columns, supplierIDs)
+		if err == nil {
+			err = utils.ValidateProductFields(&product)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, dtos.ImportError{Line: row.line, Error: err.Error()})
+			continue
+		}
+
+		if exists {
+			updated = append(updated, product)
+		} else {
+			created = append(created, product)
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, errors.New("el CSV tiene errores y no se ha importado")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i := range created {
+		if err := s.productRepo.CreateProduct(tx, &created[i]); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("error al crear el producto %s", created[i].SKU)
+		}
+	}
+	for i := range updated {
+		if err := s.productRepo.UpdateProduct(tx, &updated[i]); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("error al actualizar el producto %s", updated[i].SKU)
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("error al confirmar la transaccion")
+	}
+	result.Created = len(created)
+	result.Updated = len(updated)
+	return result, nil
+}
+
+// cell - Devuelve una celda de una fila o vacio si la fila es mas corta
+func cell(record []string, index int) string {
+	if index < len(record) {
+		return record[index]
+	}
+	return ""
+}
+
+// applyProductColumns - Copia en el producto las columnas presentes en el CSV
+// -------------------------------------------------------------------
+// El proveedor se indica por su nombre, que debe existir.
+func applyProductColumns(product *models.Product, record []string, columns map[string]int, supplierIDs map[string]string) error {
+	for field, index := range columns {
+		value := strings.TrimSpace(cell(record, index))
+		switch field {
+		case "name":
+			product.Name = value
+		case "category":
+			product.Category = value
+		case "unit":
+			product.Unit = value
+		case "supplier":
+			if value == "" {
+				product.SupplierID = nil
+				continue
+			}
+			supplierID, ok := supplierIDs[strings.ToLower(value)]
+			if !ok {
+				return fmt.Errorf("el proveedor %q no existe", value)
+			}
+			product.SupplierID = &supplierID
+		case "price":
+			if value == "" {
+				product.Price = 0
+				continue
+			}
+			price, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+			if err != nil {
+				return fmt.Errorf("el precio %q no es un numero", value)
+			}
+			product.Price = price
+		case "pack_size":
+			if value == "" {
+				product.PackSize = 1
+				continue
+			}
+			size, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("el tamaño del bulto %q no es un numero entero", value)
+			}
+			product.PackSize = size
+		case "active":
+			active, err := parseYesNo(value)
+			if err != nil {
+				return err
+			}
+			product.Active = active
+		}
+	}
+	return nil
+}
+
+// parseYesNo - Interpreta si/no, true/false o 1/0; vacio es si
+func parseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "si", "sí", "s", "true", "1", "yes":
+		return true, nil
+	case "no", "n", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("el valor %q debe ser si o no", value)
+}
+
+// GetStoreProducts - Obtiene los productos que puede pedir una tienda
+// -------------------------------------------------------------------
+// La tienda solo ve los suyos y solo los activos.
+func (s *ProductService) GetStoreProducts(userID, role, storeID string) ([]models.Product, error) {
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	} else if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return nil, errors.New("la tienda no existe")
+	}
+
+	products, err := s.productRepo.GetStoreProducts(storeID, role != "admin")
+	if err != nil {
+		return nil, errors.New("error al obtener los productos de la tienda")
+	}
+	return products, nil
+}
+
+// SetStoreProducts - Sustituye o amplia la lista de productos que puede pedir una tienda
+// -------------------------------------------------------------------
+func (s *ProductService) SetStoreProducts(storeID string, productIDs []string, replace bool) error {
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return errors.New("la tienda no existe")
+	}
+
+	unique := make([]string, 0, len(productIDs))
+	seen := make(map[string]bool)
+	for _, productID := range productIDs {
+		if productID != "" && !seen[productID] {
+			seen[productID] = true
+			unique = append(unique, productID)
+		}
+	}
+	if len(unique) > 0 {
+		count, err := s.productRepo.CountProducts(unique)
+		if err != nil {
+			return errors.New("error al comprobar los productos")
+		}
+		if int(count) != len(unique) {
+			return errors.New("alguno de los productos no existe")
+		}
+	}
+
+	if !replace {
+		if len(unique) == 0 {
+			return nil
+		}
+		if err := s.productRepo.AddStoreProducts(storeID, unique); err != nil {
+			return errors.New("error al añadir los productos a la tienda")
+		}
+		return nil
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := s.productRepo.SetStoreProducts(tx, storeID, unique); err != nil {
+		tx.Rollback()
+		return errors.New("error al guardar los productos de la tienda")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// RemoveStoreProduct - Quita un producto de la lista de una tienda
+// -------------------------------------------------------------------
+func (s *ProductService) RemoveStoreProduct(storeID, productID string) error {
+	return s.productRepo.RemoveStoreProduct(storeID, productID)
+}
+
+// GetReorderParameters - Obtiene los parametros de reposicion de un producto o de todos
+// -------------------------------------------------------------------
+func (s *ProductService) GetReorderParameters(productID string) ([]models.ReorderParameter, error) {
+	parameters, err := s.productRepo.GetReorderParameters(productID)
+	if err != nil {
+		return nil, errors.New("error al obtener los parametros de reposicion")
+	}
+	return parameters, nil
+}
+
+// SaveReorderParameter - Fija los parametros de reposicion de un producto, general o de una tienda
+// -------------------------------------------------------------------
+// Los campos vacios se calculan con el consumo al sugerir pedidos.
+func (s *ProductService) SaveReorderParameter(parameter *models.ReorderParameter) error {
+	if _, err := s.productRepo.FindProductByID(parameter.ProductID); err != nil {
+		return errors.New("el producto no existe")
+	}
+	if parameter.StoreID != "" {
+		if _, err := s.storeRepo.FindStoreByID(parameter.StoreID); err != nil {
+			return errors.New("la tienda no existe")
+		}
+	}
+	for _, value := range []*int{parameter.MinLevel, parameter.MaxLevel, parameter.LeadTimeDays, parameter.ReviewDays} {
+		if value != nil && *value < 0 {
+			return errors.New("los parametros de reposicion no pueden ser negativos")
+		}
+	}
+	if parameter.MinLevel != nil && parameter.MaxLevel != nil && *parameter.MaxLevel < *parameter.MinLevel {
+		return errors.New("el maximo no puede ser menor que el minimo")
+	}
+	if (parameter.LeadTimeDays != nil && *parameter.LeadTimeDays > 365) || (parameter.ReviewDays != nil && *parameter.ReviewDays > 365) {
+		return errors.New("el plazo y la cobertura no pueden superar 365 dias")
+	}
+	if err := s.productRepo.SaveReorderParameter(parameter); err != nil {
+		return errors.New("error al guardar los parametros de reposicion")
+	}
+	return nil
+}
+
+// DeleteReorderParameter - Borra los parametros de reposicion de un producto, general o de una tienda
+// -------------------------------------------------------------------
+func (s *ProductService) DeleteReorderParameter(productID, storeID string) error {
+	deleted, err := s.productRepo.DeleteReorderParameter(productID, storeID)
+	if err != nil {
+		return errors.New("error al borrar los parametros de reposicion")
+	}
+	if deleted == 0 {
+		return errors.New("el producto no tiene esos parametros de reposicion")
+	}
+	return nil
+}
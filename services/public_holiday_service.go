@@ -0,0 +1,341 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+type PublicHolidayService struct {
+	publicHolidayRepo *repositories.PublicHolidayRepository
+	storeRepo         *repositories.StoreRepository
+
+	db *gorm.DB
+}
+
+func NewPublicHolidayService(
+	publicHolidayRepo *repositories.PublicHolidayRepository,
+	storeRepo *repositories.StoreRepository,
+	db *gorm.DB) *PublicHolidayService {
+	return &PublicHolidayService{
+		publicHolidayRepo: publicHolidayRepo,
+		storeRepo:         storeRepo,
+		db:                db,
+	}
+}
+
+// isWorkingDay - Indica si un dia es laborable (ni fin de semana ni festivo)
+// -------------------------------------------------------------------
+func isWorkingDay(day time.Time, publicHolidays map[string]string) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	_, holiday := publicHolidays[day.Format("2006-01-02")]
+	return !holiday
+}
+
+// storePublicHolidays - Obtiene los festivos de una tienda indexados por fecha
+// -------------------------------------------------------------------
+// Sin tienda solo se aplican los festivos nacionales.
+func storePublicHolidays(publicHolidayRepo *repositories.PublicHolidayRepository, storeRepo *repositories.StoreRepository,
+	storeID string, from, to time.Time) (map[string]string, error) {
+	city := ""
+	if storeID != "" {
+		store, err := storeRepo.FindStoreByID(storeID)
+		if err != nil {
+			return nil, errors.New("la tienda no existe")
+		}
+		city = store.City
+	}
+
+	holidays, err := publicHolidayRepo.GetStoreHolidays(storeID, city, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, errors.New("error al obtener los festivos")
+	}
+	dates := make(map[string]string, len(holidays))
+	for _, holiday := range holidays {
+		dates[holiday.Date[:10]] = holiday.Name
+	}
+	return dates, nil
+}
+
+// CreateCalendar - Crea un calendario de festivos
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) CreateCalendar(calendar *models.PublicHolidayCalendar) error {
+	calendar.Name = strings.TrimSpace(calendar.Name)
+	calendar.City = strings.TrimSpace(calendar.City)
+	if calendar.Name == "" {
+		return errors.New("el nombre del calendario es obligatorio")
+	}
+	switch calendar.Scope {
+	case models.PublicHolidayScopeNational, models.PublicHolidayScopeRegional:
+	case models.PublicHolidayScopeLocal:
+		if calendar.City == "" {
+			return errors.New("los calendarios locales necesitan una ciudad")
+		}
+	default:
+		return errors.New("el ambito debe ser nacional, autonomico o local")
+	}
+
+	calendar.ID = uuid.New().String()
+	if err := s.publicHolidayRepo.CreateCalendar(calendar); err != nil {
+		return errors.New("error al crear el calendario")
+	}
+	return nil
+}
+
+// GetCalendars - Obtiene todos los calendarios de festivos
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) GetCalendars() ([]models.PublicHolidayCalendar, error) {
+	return s.publicHolidayRepo.GetCalendars()
+}
+
+// GetCalendar - Obtiene un calendario con sus festivos
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) GetCalendar(calendarID string) (*models.PublicHolidayCalendar, []models.PublicHoliday, error) {
+	calendar, err := s.publicHolidayRepo.FindCalendarByID(calendarID)
+	if err != nil {
+		return nil, nil, errors.New("el calendario no existe")
+	}
+	holidays, err := s.publicHolidayRepo.GetCalendarHolidays(calendarID)
+	if err != nil {
+		return nil, nil, errors.New("error al obtener los festivos del calendario")
+	}
+	return calendar, holidays, nil
+}
+
+// DeleteCalendar - Elimina un calendario con sus festivos y asignaciones
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) DeleteCalendar(calendarID string) error {
+	if _, err := s.publicHolidayRepo.FindCalendarByID(calendarID); err != nil {
+		return errors.New("el calendario no existe")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := s.publicHolidayRepo.DeleteCalendar(tx, calendarID); err != nil {
+		tx.Rollback()
+		return errors.New("error al eliminar el calendario")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// AddHoliday - Añade un festivo a un calendario
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) AddHoliday(calendarID string, holiday *models.PublicHoliday) error {
+	if _, err := s.publicHolidayRepo.FindCalendarByID(calendarID); err != nil {
+		return errors.New("el calendario no existe")
+	}
+	if _, err := utils.ParseDate(holiday.Date); err != nil {
+		return err
+	}
+	holiday.ID = 0
+	holiday.CalendarID = calendarID
+	holiday.Name = strings.TrimSpace(holiday.Name)
+
+	if err := s.publicHolidayRepo.SaveHolidays(nil, []models.PublicHoliday{*holiday}); err != nil {
+		return errors.New("error al guardar el festivo")
+	}
+	return nil
+}
+
+// DeleteHoliday - Elimina un festivo de un calendario
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) DeleteHoliday(calendarID, holidayID string) error {
+	return s.publicHolidayRepo.DeleteHoliday(calendarID, holidayID)
+}
+
+// ImportHolidays - Importa los festivos de un calendario desde un fichero iCalendar o CSV
+// -------------------------------------------------------------------
+// Los festivos que ya existen en la misma fecha se actualizan con el nuevo
+// nombre. Devuelve el numero de dias importados.
+func (s *PublicHolidayService) ImportHolidays(calendarID, filename string, data []byte) (int, error) {
+	if _, err := s.publicHolidayRepo.FindCalendarByID(calendarID); err != nil {
+		return 0, errors.New("el calendario no existe")
+	}
+
+	var dates map[string]string
+	var err error
+	if strings.EqualFold(filepath.Ext(filename), ".ics") || bytes.Contains(data, []byte("BEGIN:VCALENDAR")) {
+		dates, err = parseICalHolidays(string(data))
+	} else {
+		dates, err = parseCSVHolidays(data)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	holidays := make([]models.PublicHoliday, 0, len(dates))
+	for date, name := range dates {
+		holidays = append(holidays, models.PublicHoliday{CalendarID: calendarID, Date: date, Name: name})
+	}
+	if err := s.publicHolidayRepo.SaveHolidays(nil, holidays); err != nil {
+		return 0, errors.New("error al guardar los festivos")
+	}
+	return len(holidays), nil
+}
+
+// parseICalHolidays - Extrae los dias festivos de los eventos de un calendario iCalendar
+// -------------------------------------------------------------------
+func parseICalHolidays(data string) (map[string]string, error) {
+	events, err := utils.ParseICalEvents(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make(map[string]string)
+	for _, event := range events {
+		if event.Cancelled {
+			continue
+		}
+		// Los eventos de varios dias marcan todos sus dias (el fin es exclusivo)
+		day := time.Date(event.Start.Year(), event.Start.Month(), event.Start.Day(), 0, 0, 0, 0, time.UTC)
+		for {
+			dates[day.Format("2006-01-02")] = event.Summary
+			day = day.AddDate(0, 0, 1)
+			if !event.AllDay || !day.Before(event.End) {
+				break
+			}
+		}
+	}
+	return dates, nil
+}
+
+// parseCSVHolidays - Lee los festivos de un CSV con las columnas fecha y nombre
+// -------------------------------------------------------------------
+// Acepta coma o punto y coma como separador, fechas YYYY-MM-DD o DD/MM/YYYY
+// y una fila de cabecera opcional.
+func parseCSVHolidays(data []byte) (map[string]string, error) {
+	reader := utils.NewCSVReader(data)
+
+	dates := make(map[string]string)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al leer el CSV en la linea %d", line)
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		date, err := parseImportDate(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue // Cabecera
+			}
+			return nil, fmt.Errorf("fecha invalida en la linea %d: %s", line, record[0])
+		}
+		name := ""
+		if len(record) > 1 {
+			name = strings.TrimSpace(record[1])
+		}
+		dates[date.Format("2006-01-02")] = name
+	}
+
+	if len(dates) == 0 {
+		return nil, errors.New("el CSV no contiene festivos")
+	}
+	return dates, nil
+}
+
+// parseImportDate - Interpreta una fecha en formato YYYY-MM-DD o DD/MM/YYYY
+func parseImportDate(value string) (time.Time, error) {
+	if date, err := time.Parse("02/01/2006", value); err == nil {
+		return date, nil
+	}
+	return utils.ParseDate(value)
+}
+
+// AssignCalendar - Asigna un calendario de festivos a una tienda
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) AssignCalendar(storeID, calendarID string) error {
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return errors.New("la tienda no existe")
+	}
+	if _, err := s.publicHolidayRepo.FindCalendarByID(calendarID); err != nil {
+		return errors.New("el calendario no existe")
+	}
+	if err := s.publicHolidayRepo.AssignCalendar(storeID, calendarID); err != nil {
+		return errors.New("error al asignar el calendario")
+	}
+	return nil
+}
+
+// UnassignCalendar - Quita un calendario de festivos de una tienda
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) UnassignCalendar(storeID, calendarID string) error {
+	return s.publicHolidayRepo.UnassignCalendar(storeID, calendarID)
+}
+
+// GetStoreCalendars - Obtiene los calendarios que se aplican a una tienda
+// -------------------------------------------------------------------
+func (s *PublicHolidayService) GetStoreCalendars(storeID string) ([]models.PublicHolidayCalendar, error) {
+	store, err := s.storeRepo.FindStoreByID(storeID)
+	if err != nil {
+		return nil, errors.New("la tienda no existe")
+	}
+	return s.publicHolidayRepo.GetStoreCalendars(store.ID, store.City)
+}
+
+// GetStorePublicHolidays - Obtiene los festivos de una tienda entre dos fechas
+// -------------------------------------------------------------------
+// Los encargados solo pueden consultar su propia tienda.
+func (s *PublicHolidayService) GetStorePublicHolidays(userID, role, storeID, from, to string) ([]models.PublicHoliday, error) {
+	if role == "store" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+
+	fromDate, err := utils.ParseDate(from)
+	if err != nil {
+		return nil, err
+	}
+	toDate, err := utils.ParseDate(to)
+	if err != nil {
+		return nil, err
+	}
+	if toDate.Before(fromDate) {
+		return nil, errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
+	}
+
+	city := ""
+	if storeID != "" {
+		store, err := s.storeRepo.FindStoreByID(storeID)
+		if err != nil {
+			return nil, errors.New("la tienda no existe")
+		}
+		city = store.City
+	}
+	return s.publicHolidayRepo.GetStoreHolidays(storeID, city, from, to)
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/utils"
+)
+
+// nextDeliveryDate - Primer dia de reparto del proveedor tras su plazo de entrega
+// -------------------------------------------------------------------
+func nextDeliveryDate(supplier *models.Supplier, from time.Time) time.Time {
+	earliest := from.AddDate(0, 0, supplier.LeadTimeDays)
+	if len(supplier.DeliveryDays) == 0 {
+		return earliest
+	}
+	for i := 0; i < 7; i++ {
+		day := earliest.AddDate(0, 0, i)
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7 // Domingo
+		}
+		for _, delivery := range supplier.DeliveryDays {
+			if delivery == weekday {
+				return day
+			}
+		}
+	}
+	return earliest
+}
+
+// ConsolidateOrders - Agrupa los pedidos aprobados en pedidos a proveedor
+// -------------------------------------------------------------------
+// Las lineas se reparten por proveedor y fecha de entrega; si ya hay un
+// pedido abierto de ese proveedor para esa fecha se le suman. Sin fecha se
+// usa el primer reparto de cada proveedor tras su plazo. Los pedidos con
+// algun producto sin proveedor activo se dejan aprobados y se devuelven
+// como saltados. Los consolidados pasan a Pedido al proveedor.
+func (s *OrderService) ConsolidateOrders(userID, deliveryDate string) (*dtos.ConsolidationResult, error) {
+	today, _ := utils.ParseDate(time.Now().Format("2006-01-02"))
+	if deliveryDate != "" {
+		date, err := utils.ParseDate(deliveryDate)
+		if err != nil {
+			return nil, err
+		}
+		if date.Before(today) {
+			return nil, errors.New("la fecha de entrega no puede ser anterior a hoy")
+		}
+		deliveryDate = deliveryDate[:10]
+	}
+
+	result := &dtos.ConsolidationResult{
+		PurchaseOrders: []models.PurchaseOrder{},
+		Skipped:        []dtos.SkippedOrder{},
+		Warnings:       []string{},
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	orders, err := s.orderRepo.LockOrdersByStatus(tx, models.OrderStatusApproved)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("error al obtener los pedidos aprobados")
+	}
+	if len(orders) == 0 {
+		tx.Rollback()
+		return result, nil
+	}
+
+	var productIDs []string
+	for _, order := range orders {
+		for _, line := range order.Lines {
+			if line.ProductID != nil {
+				productIDs = append(productIDs, *line.ProductID)
+			}
+		}
+	}
+	catalog, err := s.productRepo.GetProductsByIDs(productIDs)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("error al obtener los productos")
+	}
+	products := make(map[string]models.Product, len(catalog))
+	for _, product := range catalog {
+		products[product.ID] = product
+	}
+
+	// Repartimos las lineas por proveedor y fecha de entrega
+	type group struct {
+		supplier *models.Supplier
+		date     string
+		lines    []models.PurchaseOrderLine
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	var consolidated []int
+	for i, order := range orders {
+		reason := ""
+		for _, line := range order.Lines {
+			if line.ProductID == nil {
+				reason = fmt.Sprintf("%s no esta en el catalogo", line.Product)
+				break
+			}
+			product := products[*line.ProductID]
+			if product.Supplier == nil || !product.Supplier.Active {
+				reason = fmt.Sprintf("%s no tiene un proveedor activo", line.Product)
+				break
+			}
+		}
+		store, _ := s.storeRepo.FindStoreByID(order.StoreID)
+		if reason != "" {
+			skipped := dtos.SkippedOrder{OrderID: order.ID, Number: order.Number, Reason: reason}
+			if store != nil {
+				skipped.StoreName = store.Name
+			}
+			result.Skipped = append(result.Skipped, skipped)
+			continue
+		}
+
+		for _, line := range order.Lines {
+			product := products[*line.ProductID]
+			date := deliveryDate
+			if date == "" {
+				date = nextDeliveryDate(product.Supplier, today).Format("2006-01-02")
+			}
+			key := *product.SupplierID + "|" + date
+			if groups[key] == nil {
+				groups[key] = &group{supplier: product.Supplier, date: date}
+				keys = append(keys, key)
+			}
+			groups[key].lines = append(groups[key].lines, models.PurchaseOrderLine{
+				OrderLineID: line.ID,
+				OrderID:     order.ID,
+				StoreID:     order.StoreID,
+				ProductID:   product.ID,
+				SKU:         line.SKU,
+				Product:     line.Product,
+				Unit:        line.Unit,
+				Quantity:    line.Quantity,
+				UnitPrice:   product.Price,
+			})
+		}
+		consolidated = append(consolidated, i)
+	}
+
+	// Creamos o ampliamos los pedidos a proveedor
+	numbers := make(map[string][]string) // Pedido de tienda -> pedidos a proveedor
+	for _, key := range keys {
+		g := groups[key]
+		purchase, err := s.purchaseRepo.FindOpenPurchaseOrder(tx, g.supplier.ID, g.date)
+		if err != nil {
+			sequence, err := s.purchaseRepo.NextPurchaseSequence(tx, time.Now().Year())
+			if err != nil {
+				tx.Rollback()
+				return nil, errors.New("error al numerar el pedido a proveedor")
+			}
+			purchase = &models.PurchaseOrder{
+				ID:           uuid.New().String(),
+				Number:       fmt.Sprintf("OC-%d-%04d", time.Now().Year(), sequence),
+				Sequence:     sequence,
+				SupplierID:   g.supplier.ID,
+				DeliveryDate: g.date,
+				Status:       models.PurchaseOrderOpen,
+				CreatedByID:  userID,
+			}
+			if err := s.purchaseRepo.CreatePurchaseOrder(tx, purchase); err != nil {
+				tx.Rollback()
+				return nil, errors.New("error al crear el pedido a proveedor")
+			}
+		}
+
+		for i := range g.lines {
+			g.lines[i].PurchaseOrderID = purchase.ID
+			numbers[g.lines[i].OrderID] = append(numbers[g.lines[i].OrderID], purchase.Number)
+		}
+		if err := s.purchaseRepo.CreateLines(tx, g.lines); err != nil {
+			tx.Rollback()
+			return nil, errors.New("error al guardar las lineas del pedido a proveedor")
+		}
+		if purchase.Total, err = s.purchaseRepo.UpdateTotal(tx, purchase.ID); err != nil {
+			tx.Rollback()
+			return nil, errors.New("error al calcular el importe del pedido a proveedor")
+		}
+		if purchase.Total < g.supplier.MinOrderAmount {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s (%s): %.2f € no llega al minimo de %.2f €",
+				purchase.Number, g.supplier.Name, purchase.Total, g.supplier.MinOrderAmount))
+		}
+		purchase.SupplierName = g.supplier.Name
+		result.PurchaseOrders = append(result.PurchaseOrders, *purchase)
+	}
+
+	for _, i := range consolidated {
+		order := &orders[i]
+		comment := "Incluido en " + strings.Join(uniqueStrings(numbers[order.ID]), ", ")
+		if err := s.changeStatus(tx, order, models.OrderStatusOrdered, userID, "admin", comment); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.New("error al confirmar la transaccion")
+	}
+	result.Orders = len(consolidated)
+	return result, nil
+}
+
+// uniqueStrings - Quita los repetidos manteniendo el orden
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// GetPurchaseOrders - Obtiene los pedidos a proveedor filtrando por proveedor, estado y entrega
+// -------------------------------------------------------------------
+func (s *OrderService) GetPurchaseOrders(supplierID, status, from, to string) ([]models.PurchaseOrder, error) {
+	for _, date := range []string{from, to} {
+		if date != "" {
+			if _, err := utils.ParseDate(date); err != nil {
+				return nil, err
+			}
+		}
+	}
+	purchases, err := s.purchaseRepo.GetPurchaseOrders(supplierID, status, from, to)
+	if err != nil {
+		return nil, errors.New("error al obtener los pedidos a proveedor")
+	}
+	return purchases, nil
+}
+
+// GetPurchaseOrder - Obtiene un pedido a proveedor con el total por producto y por tienda
+// -------------------------------------------------------------------
+func (s *OrderService) GetPurchaseOrder(purchaseID string) (*dtos.PurchaseOrderDetail, error) {
+	purchase, err := s.purchaseRepo.FindPurchaseOrderByID(purchaseID)
+	if err != nil {
+		return nil, errors.New("el pedido a proveedor no existe")
+	}
+	supplier, err := s.supplierRepo.FindSupplierByID(purchase.SupplierID)
+	if err != nil {
+		return nil, errors.New("el proveedor no existe")
+	}
+	purchase.DeliveryDate = purchase.DeliveryDate[:10]
+
+	detail := &dtos.PurchaseOrderDetail{
+		PurchaseOrder: purchase,
+		Supplier:      supplier,
+		Products:      []dtos.PurchaseProduct{},
+		Stores:        []dtos.PurchaseStoreTotal{},
+		BelowMinimum:  purchase.Total < supplier.MinOrderAmount,
+	}
+	byProduct := make(map[string]int)
+	byStore := make(map[string]int)
+	for _, line := range purchase.Lines {
+		amount := math.Round(float64(line.Quantity)*line.UnitPrice*100) / 100
+		index, ok := byProduct[line.ProductID]
+		if !ok {
+			index = len(detail.Products)
+			byProduct[line.ProductID] = index
+			detail.Products = append(detail.Products, dtos.PurchaseProduct{
+				ProductID: line.ProductID,
+				SKU:       line.SKU,
+				Product:   line.Product,
+				Unit:      line.Unit,
+				UnitPrice: line.UnitPrice,
+			})
+		}
+		product := &detail.Products[index]
+		product.Quantity += line.Quantity
+		product.Amount += amount
+		product.Stores = append(product.Stores, dtos.PurchaseStoreQuantity{
+			StoreID:     line.StoreID,
+			StoreName:   line.StoreName,
+			OrderNumber: line.OrderNumber,
+			Quantity:    line.Quantity,
+		})
+
+		index, ok = byStore[line.StoreID]
+		if !ok {
+			index = len(detail.Stores)
+			byStore[line.StoreID] = index
+			detail.Stores = append(detail.Stores, dtos.PurchaseStoreTotal{StoreID: line.StoreID, StoreName: line.StoreName})
+		}
+		detail.Stores[index].Lines++
+		detail.Stores[index].Amount += amount
+	}
+	sort.Slice(detail.Stores, func(i, j int) bool { return detail.Stores[i].StoreName < detail.Stores[j].StoreName })
+	return detail, nil
+}
+
+// SendPurchaseOrder - Marca un pedido a proveedor como enviado; ya no admite mas pedidos
+// -------------------------------------------------------------------
+func (s *OrderService) SendPurchaseOrder(purchaseID string) error {
+	updated, err := s.purchaseRepo.MarkSent(purchaseID)
+	if err != nil {
+		return errors.New("error al actualizar el pedido a proveedor")
+	}
+	if updated == 0 {
+		return errors.New("el pedido a proveedor no existe o ya se ha enviado")
+	}
+	return nil
+}
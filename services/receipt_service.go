@@ -0,0 +1,346 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Dias maximos del informe de incidencias
+const maxDiscrepancyDays = 366
+
+// ReceiveOrder - Registra una entrega de mercancia de un pedido
+// -------------------------------------------------------------------
+// Por cada linea se indican las unidades recibidas en buen estado, las
+// rotas y las servidas con otro producto. Con final la tienda da por
+// cerrada la recepcion y lo que siga pendiente queda como falta. El pedido
+// pasa a Recibido cuando no queda nada pendiente y a Recibido parcialmente
+// en otro caso.
+func (s *OrderService) ReceiveOrder(userID, role, orderID string, receipt *models.OrderReceipt) error {
+	var store *models.Store
+	var err error
+	if role != "admin" {
+		store, err = s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return errors.New("no se encontro la tienda del usuario")
+		}
+	}
+	if len(receipt.Lines) == 0 && !receipt.Final {
+		return errors.New("la recepcion debe tener al menos una linea")
+	}
+	if err := s.fillSubstitutes(receipt.Lines); err != nil {
+		return err
+	}
+
+	receipt.ID = uuid.New().String()
+	receipt.OrderID = orderID
+	receipt.Date = time.Now().Format("2006-01-02")
+	receipt.ReceivedByID = userID
+	receipt.Photo = ""
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	order, err := s.orderRepo.LockOrderByID(tx, orderID)
+	if err != nil || (store != nil && store.ID != order.StoreID) {
+		tx.Rollback()
+		return errors.New("el pedido no existe")
+	}
+	if order.Status != models.OrderStatusOrdered && order.Status != models.OrderStatusPartiallyReceived {
+		tx.Rollback()
+		return fmt.Errorf("no se puede recibir mercancia de un pedido %s", order.Status)
+	}
+
+	lines, err := s.orderRepo.GetOrderLines(tx, order.ID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al obtener las lineas del pedido")
+	}
+	byID := make(map[int]*models.OrderLine, len(lines))
+	for i := range lines {
+		byID[lines[i].ID] = &lines[i]
+	}
+
+	received := make(map[int]int) // Linea del pedido -> linea de la recepcion
+	for i := range receipt.Lines {
+		line := &receipt.Lines[i]
+		orderLine, ok := byID[line.OrderLineID]
+		if !ok {
+			tx.Rollback()
+			return fmt.Errorf("la linea %d de la recepcion no pertenece al pedido", i+1)
+		}
+		if _, ok := received[line.OrderLineID]; ok {
+			tx.Rollback()
+			return fmt.Errorf("la linea %d de la recepcion repite un producto", i+1)
+		}
+		received[line.OrderLineID] = i
+
+		line.ID = 0
+		line.ReceiptID = receipt.ID
+		line.Short = 0
+		delivered := line.Received + line.Damaged + line.SubstituteQuantity
+		if delivered > orderLine.Pending() {
+			tx.Rollback()
+			return fmt.Errorf("%s: se reciben %d %s y solo quedan %d pendientes", orderLine.Product, delivered, orderLine.Unit, orderLine.Pending())
+		}
+		orderLine.Received += line.Received
+		orderLine.Damaged += line.Damaged
+		orderLine.Substituted += line.SubstituteQuantity
+	}
+
+	// Al cerrar la recepcion lo pendiente pasa a ser falta
+	if receipt.Final {
+		for i := range lines {
+			pending := lines[i].Pending()
+			if pending == 0 {
+				continue
+			}
+			lines[i].Short += pending
+			if index, ok := received[lines[i].ID]; ok {
+				receipt.Lines[index].Short = pending
+				continue
+			}
+			receipt.Lines = append(receipt.Lines, models.OrderReceiptLine{
+				ReceiptID:   receipt.ID,
+				OrderLineID: lines[i].ID,
+				Short:       pending,
+			})
+		}
+	}
+
+	if err := s.orderRepo.CreateReceipt(tx, receipt); err != nil {
+		tx.Rollback()
+		return errors.New("error al guardar la recepcion")
+	}
+	if err := s.stockFromReceipt(tx, order, byID, receipt); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	status := models.OrderStatusReceived
+	for i := range lines {
+		if err := s.orderRepo.UpdateLineReception(tx, &lines[i]); err != nil {
+			tx.Rollback()
+			return errors.New("error al actualizar las lineas del pedido")
+		}
+		if lines[i].Pending() > 0 {
+			status = models.OrderStatusPartiallyReceived
+		}
+	}
+
+	if status != order.Status {
+		if err := s.changeStatus(tx, order, status, userID, role, receipt.Comment); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// stockFromReceipt - Suma al stock de la tienda lo recibido en buen estado
+// -------------------------------------------------------------------
+// Los sustitutos del catalogo suman a su propio producto. Las lineas de
+// pedidos anteriores al catalogo no tienen producto y no mueven stock.
+func (s *OrderService) stockFromReceipt(tx *gorm.DB, order *models.Order, lines map[int]*models.OrderLine, receipt *models.OrderReceipt) error {
+	type entry struct {
+		productID *string
+		quantity  int
+	}
+	reason := fmt.Sprintf("Pedido %s", order.Number)
+	for _, line := range receipt.Lines {
+		orderLine := lines[line.OrderLineID]
+		for _, e := range []entry{{orderLine.ProductID, line.Received}, {line.SubstituteProductID, line.SubstituteQuantity}} {
+			if e.productID == nil || e.quantity == 0 {
+				continue
+			}
+			movement := &models.StockMovement{
+				StoreID:   order.StoreID,
+				ProductID: *e.productID,
+				Type:      models.StockMovementReceipt,
+				Quantity:  e.quantity,
+				OrderID:   &order.ID,
+				Reason:    reason,
+				ActorID:   receipt.ReceivedByID,
+			}
+			if err := applyStockMovement(tx, s.stockRepo, movement, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fillSubstitutes - Comprueba las cantidades y completa los productos sustitutos del catalogo
+// -------------------------------------------------------------------
+func (s *OrderService) fillSubstitutes(lines []models.OrderReceiptLine) error {
+	for i := range lines {
+		line := &lines[i]
+		if line.Received < 0 || line.Damaged < 0 || line.SubstituteQuantity < 0 {
+			return fmt.Errorf("las cantidades de la linea %d no pueden ser negativas", i+1)
+		}
+		if line.Received+line.Damaged+line.SubstituteQuantity == 0 {
+			return fmt.Errorf("la linea %d no tiene ninguna unidad recibida", i+1)
+		}
+		if line.SubstituteQuantity == 0 {
+			line.SubstituteProductID = nil
+			line.SubstituteProduct = ""
+			continue
+		}
+		if line.SubstituteProductID != nil && *line.SubstituteProductID != "" {
+			product, err := s.productRepo.FindProductByID(*line.SubstituteProductID)
+			if err != nil {
+				return fmt.Errorf("el producto sustituto de la linea %d no existe", i+1)
+			}
+			line.SubstituteProduct = product.Name
+			continue
+		}
+		line.SubstituteProductID = nil
+		if line.SubstituteProduct == "" {
+			return fmt.Errorf("indica el producto que sustituye a la linea %d", i+1)
+		}
+	}
+	return nil
+}
+
+// GetOrderReceipts - Obtiene las recepciones de un pedido
+// -------------------------------------------------------------------
+func (s *OrderService) GetOrderReceipts(userID, role, orderID string) ([]models.OrderReceipt, error) {
+	order, err := s.GetOrder(userID, role, orderID)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := s.orderRepo.GetReceipts(order.ID)
+	if err != nil {
+		return nil, errors.New("error al obtener las recepciones del pedido")
+	}
+	return receipts, nil
+}
+
+// receiptOf - Busca una recepcion comprobando que el usuario puede verla
+// -------------------------------------------------------------------
+func (s *OrderService) receiptOf(userID, role, receiptID string) (*models.OrderReceipt, error) {
+	receipt, err := s.orderRepo.FindReceiptByID(receiptID)
+	if err != nil {
+		return nil, errors.New("la recepcion no existe")
+	}
+	if _, err := s.GetOrder(userID, role, receipt.OrderID); err != nil {
+		return nil, errors.New("la recepcion no existe")
+	}
+	return receipt, nil
+}
+
+// AttachReceiptPhoto - Adjunta la foto de una recepcion
+// -------------------------------------------------------------------
+// Devuelve la ruta de la foto anterior para que se pueda borrar.
+func (s *OrderService) AttachReceiptPhoto(userID, role, receiptID, path string) (string, error) {
+	receipt, err := s.receiptOf(userID, role, receiptID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.orderRepo.UpdateReceiptPhoto(receipt.ID, path); err != nil {
+		return "", errors.New("error al guardar la foto")
+	}
+	return receipt.Photo, nil
+}
+
+// GetReceiptPhoto - Obtiene la ruta de la foto de una recepcion
+// -------------------------------------------------------------------
+func (s *OrderService) GetReceiptPhoto(userID, role, receiptID string) (string, error) {
+	receipt, err := s.receiptOf(userID, role, receiptID)
+	if err != nil {
+		return "", err
+	}
+	if receipt.Photo == "" {
+		return "", errors.New("la recepcion no tiene foto")
+	}
+	return receipt.Photo, nil
+}
+
+// GetDiscrepancyReport - Faltas, roturas y sustituciones por proveedor y por tienda
+// -------------------------------------------------------------------
+// Filtra por la fecha de la recepcion; la tienda y el proveedor son opcionales.
+func (s *OrderService) GetDiscrepancyReport(from, to, storeID, supplierID string) (*dtos.DiscrepancyReport, error) {
+	from, to, err := parsePeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	start, _ := utils.ParseDate(from)
+	end, _ := utils.ParseDate(to)
+	if end.Sub(start) > maxDiscrepancyDays*24*time.Hour {
+		return nil, fmt.Errorf("el informe no puede abarcar mas de %d dias", maxDiscrepancyDays)
+	}
+
+	lines, err := s.orderRepo.GetDiscrepancies(from, to, storeID, supplierID)
+	if err != nil {
+		return nil, errors.New("error al obtener las incidencias")
+	}
+
+	report := &dtos.DiscrepancyReport{
+		From:       from,
+		To:         to,
+		BySupplier: []dtos.DiscrepancySummary{},
+		ByStore:    []dtos.DiscrepancySummary{},
+		Lines:      []dtos.DiscrepancyLine{},
+	}
+	bySupplier := make(map[string]*dtos.DiscrepancySummary)
+	byStore := make(map[string]*dtos.DiscrepancySummary)
+	supplierOrders := make(map[string]map[string]bool)
+	storeOrders := make(map[string]map[string]bool)
+	for _, line := range lines {
+		if line.Supplier == "" {
+			line.Supplier = "Sin proveedor"
+		}
+		line.Date = line.Date[:10]
+		report.Lines = append(report.Lines, line)
+
+		if bySupplier[line.Supplier] == nil {
+			bySupplier[line.Supplier] = &dtos.DiscrepancySummary{SupplierID: line.SupplierID, Supplier: line.Supplier}
+			supplierOrders[line.Supplier] = make(map[string]bool)
+		}
+		if byStore[line.StoreID] == nil {
+			byStore[line.StoreID] = &dtos.DiscrepancySummary{StoreID: line.StoreID, StoreName: line.StoreName}
+			storeOrders[line.StoreID] = make(map[string]bool)
+		}
+		supplierOrders[line.Supplier][line.OrderID] = true
+		storeOrders[line.StoreID][line.OrderID] = true
+		for _, summary := range []*dtos.DiscrepancySummary{bySupplier[line.Supplier], byStore[line.StoreID]} {
+			summary.Lines++
+			summary.ShortUnits += line.Short
+			summary.DamagedUnits += line.Damaged
+			summary.SubstitutedUnits += line.SubstituteQuantity
+		}
+	}
+
+	for name, summary := range bySupplier {
+		summary.Orders = len(supplierOrders[name])
+		report.BySupplier = append(report.BySupplier, *summary)
+	}
+	for id, summary := range byStore {
+		summary.Orders = len(storeOrders[id])
+		report.ByStore = append(report.ByStore, *summary)
+	}
+	sort.Slice(report.BySupplier, func(i, j int) bool { return report.BySupplier[i].Supplier < report.BySupplier[j].Supplier })
+	sort.Slice(report.ByStore, func(i, j int) bool { return report.ByStore[i].StoreName < report.ByStore[j].StoreName })
+	return report, nil
+}
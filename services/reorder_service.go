@@ -0,0 +1,299 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+)
+
+// Valores de reposicion cuando el producto no tiene parametros
+const (
+	consumptionWindowDays = 56 // Dias de historial para calcular el consumo
+	defaultLeadTimeDays   = 2  // Si el producto no tiene proveedor
+	defaultReviewDays     = 7
+)
+
+// Comentario de los borradores creados a partir de las sugerencias
+const suggestedOrderComment = "Pedido sugerido"
+
+// GetReorderSuggestions - Cantidades sugeridas para el proximo pedido de una tienda
+// -------------------------------------------------------------------
+// La tienda solo ve la suya; el admin indica cual.
+func (s *OrderService) GetReorderSuggestions(userID, role, storeID string) (*dtos.ReorderSuggestions, error) {
+	var store *models.Store
+	var err error
+	if role != "admin" {
+		store, err = s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+	} else if store, err = s.storeRepo.FindStoreByID(storeID); err != nil {
+		return nil, errors.New("la tienda no existe")
+	}
+	return s.suggestForStore(store)
+}
+
+// CreateSuggestedDrafts - Crea un borrador de pedido con las sugerencias de cada tienda
+// -------------------------------------------------------------------
+// La tienda lo crea para si misma. El admin para una tienda o, sin indicar
+// ninguna, para todas. Las tiendas a las que no hace falta pedir nada se
+// saltan.
+func (s *OrderService) CreateSuggestedDrafts(userID, role, storeID string) ([]models.Order, error) {
+	var stores []models.Store
+	switch {
+	case role != "admin":
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		stores = append(stores, *store)
+	case storeID != "":
+		store, err := s.storeRepo.FindStoreByID(storeID)
+		if err != nil {
+			return nil, errors.New("la tienda no existe")
+		}
+		stores = append(stores, *store)
+	default:
+		all, err := s.storeRepo.GetAllStores()
+		if err != nil {
+			return nil, errors.New("error al obtener las tiendas")
+		}
+		stores = all
+	}
+
+	orders := []models.Order{}
+	for i := range stores {
+		suggestions, err := s.suggestForStore(&stores[i])
+		if err != nil {
+			return orders, err
+		}
+		order := models.Order{Comment: suggestedOrderComment}
+		for _, suggestion := range suggestions.Lines {
+			if suggestion.Quantity == 0 {
+				continue
+			}
+			productID := suggestion.ProductID
+			order.Lines = append(order.Lines, models.OrderLine{ProductID: &productID, Quantity: suggestion.Quantity})
+		}
+		if len(order.Lines) == 0 {
+			continue
+		}
+		if err := s.createOrder(&stores[i], userID, role, &order, true); err != nil {
+			return orders, fmt.Errorf("%s: %w", stores[i].Name, err)
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// suggestForStore - Calcula la sugerencia de cada producto que puede pedir la tienda
+// -------------------------------------------------------------------
+// El consumo diario sale de los recuentos: lo contado en el primero, mas
+// lo recibido despues, menos lo contado en el ultimo. Si no hay dos
+// recuentos se usa lo pedido en el periodo. Se pide cuando el stock previsto
+// al llegar el pedido (stock + pendiente - consumo durante el plazo) no
+// supera el minimo, y se pide hasta el maximo.
+func (s *OrderService) suggestForStore(store *models.Store) (*dtos.ReorderSuggestions, error) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -consumptionWindowDays)
+
+	products, err := s.productRepo.GetStoreProducts(store.ID, true)
+	if err != nil {
+		return nil, errors.New("error al obtener los productos de la tienda")
+	}
+	levels, err := s.stockRepo.GetStockLevels(store.ID)
+	if err != nil {
+		return nil, errors.New("error al obtener el stock")
+	}
+	incoming, err := s.orderRepo.GetPendingQuantities(store.ID)
+	if err != nil {
+		return nil, errors.New("error al obtener los pedidos en curso")
+	}
+	ordered, err := s.orderRepo.GetOrderedQuantities(store.ID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, errors.New("error al obtener los pedidos anteriores")
+	}
+	points, err := s.stockRepo.GetCountPoints(store.ID, since)
+	if err != nil {
+		return nil, errors.New("error al obtener los recuentos")
+	}
+	receipts, err := s.stockRepo.GetMovementsSince(store.ID, models.StockMovementReceipt, since)
+	if err != nil {
+		return nil, errors.New("error al obtener las recepciones")
+	}
+	parameters, err := s.productRepo.GetStoreReorderParameters(store.ID)
+	if err != nil {
+		return nil, errors.New("error al obtener los parametros de reposicion")
+	}
+	suppliers, err := s.supplierRepo.GetSuppliers(false)
+	if err != nil {
+		return nil, errors.New("error al obtener los proveedores")
+	}
+
+	leadTimes := make(map[string]int, len(suppliers))
+	for _, supplier := range suppliers {
+		leadTimes[supplier.ID] = supplier.LeadTimeDays
+	}
+
+	stock := make(map[string]int, len(levels))
+	for _, level := range levels {
+		stock[level.ProductID] = level.Quantity
+	}
+	countsOf := make(map[string][]dtos.StockCountPoint)
+	for _, point := range points {
+		countsOf[point.ProductID] = append(countsOf[point.ProductID], point)
+	}
+	receiptsOf := make(map[string][]models.StockMovement)
+	for _, movement := range receipts {
+		receiptsOf[movement.ProductID] = append(receiptsOf[movement.ProductID], movement)
+	}
+	general := make(map[string]models.ReorderParameter)
+	specific := make(map[string]models.ReorderParameter)
+	for _, parameter := range parameters {
+		if parameter.StoreID == "" {
+			general[parameter.ProductID] = parameter
+		} else {
+			specific[parameter.ProductID] = parameter
+		}
+	}
+
+	result := &dtos.ReorderSuggestions{
+		StoreID:     store.ID,
+		StoreName:   store.Name,
+		GeneratedAt: now.Format(time.RFC3339),
+		Lines:       []dtos.ReorderSuggestion{},
+	}
+	for _, product := range products {
+		suggestion := dtos.ReorderSuggestion{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Product:   product.Name,
+			Unit:      product.Unit,
+			PackSize:  max(product.PackSize, 1),
+			Stock:     stock[product.ID],
+			Incoming:  incoming[product.ID],
+		}
+
+		// Consumo medio
+		if daily, from, to, ok := consumptionFromCounts(countsOf[product.ID], receiptsOf[product.ID]); ok {
+			suggestion.DailyConsumption = daily
+			suggestion.ConsumptionSource = dtos.ConsumptionFromCounts
+			suggestion.ConsumptionFrom = from.Format("2006-01-02")
+			suggestion.ConsumptionTo = to.Format("2006-01-02")
+		} else if ordered[product.ID] > 0 {
+			suggestion.DailyConsumption = float64(ordered[product.ID]) / consumptionWindowDays
+			suggestion.ConsumptionSource = dtos.ConsumptionFromOrders
+			suggestion.ConsumptionFrom = since.Format("2006-01-02")
+			suggestion.ConsumptionTo = now.Format("2006-01-02")
+		} else {
+			suggestion.ConsumptionSource = dtos.ConsumptionNoData
+		}
+		suggestion.DailyConsumption = math.Round(suggestion.DailyConsumption*100) / 100
+
+		// Parametros: los de la tienda pisan a los generales y el plazo por defecto es el del proveedor
+		own, common := specific[product.ID], general[product.ID]
+		leadTime := defaultLeadTimeDays
+		if product.SupplierID != nil {
+			leadTime = leadTimes[*product.SupplierID]
+		}
+		suggestion.LeadTimeDays = pickParameter(own.LeadTimeDays, common.LeadTimeDays, leadTime)
+		suggestion.ReviewDays = pickParameter(own.ReviewDays, common.ReviewDays, defaultReviewDays)
+		daily := suggestion.DailyConsumption
+		suggestion.MinLevel = pickParameter(own.MinLevel, common.MinLevel,
+			int(math.Ceil(daily*float64(suggestion.LeadTimeDays))))
+		suggestion.MaxLevel = pickParameter(own.MaxLevel, common.MaxLevel,
+			int(math.Ceil(daily*float64(suggestion.LeadTimeDays+suggestion.ReviewDays))))
+		suggestion.MaxLevel = max(suggestion.MaxLevel, suggestion.MinLevel)
+		fixedLevels := own.MinLevel != nil || common.MinLevel != nil || own.MaxLevel != nil || common.MaxLevel != nil
+
+		// Cantidad
+		projected := float64(suggestion.Stock+suggestion.Incoming) - daily*float64(suggestion.LeadTimeDays)
+		suggestion.ProjectedStock = math.Round(projected*10) / 10
+		if suggestion.ProjectedStock <= float64(suggestion.MinLevel) && float64(suggestion.MaxLevel) > suggestion.ProjectedStock {
+			need := float64(suggestion.MaxLevel) - suggestion.ProjectedStock
+			packs := int(math.Ceil(need / float64(suggestion.PackSize)))
+			suggestion.Quantity = packs * suggestion.PackSize
+		}
+
+		suggestion.Explanation = explainSuggestion(&suggestion, fixedLevels)
+		result.Lines = append(result.Lines, suggestion)
+	}
+	return result, nil
+}
+
+// consumptionFromCounts - Consumo diario entre el primer y el ultimo recuento de un producto
+// -------------------------------------------------------------------
+// Necesita dos recuentos separados al menos un dia.
+func consumptionFromCounts(points []dtos.StockCountPoint, receipts []models.StockMovement) (float64, time.Time, time.Time, bool) {
+	if len(points) < 2 {
+		return 0, time.Time{}, time.Time{}, false
+	}
+	first, last := points[0], points[len(points)-1]
+	days := last.CountedAt.Sub(first.CountedAt).Hours() / 24
+	if days < 1 {
+		return 0, time.Time{}, time.Time{}, false
+	}
+
+	received := 0
+	for _, movement := range receipts {
+		if movement.CreatedAt.After(first.CountedAt) && !movement.CreatedAt.After(last.CountedAt) {
+			received += movement.Quantity
+		}
+	}
+	used := max(first.Counted+received-last.Counted, 0)
+	return float64(used) / days, first.CountedAt, last.CountedAt, true
+}
+
+// pickParameter - Primer parametro relleno o el valor por defecto
+func pickParameter(own, common *int, fallback int) int {
+	if own != nil {
+		return *own
+	}
+	if common != nil {
+		return *common
+	}
+	return fallback
+}
+
+// explainSuggestion - Explica en una frase por paso como se llega a la cantidad sugerida
+// -------------------------------------------------------------------
+func explainSuggestion(suggestion *dtos.ReorderSuggestion, fixedLevels bool) string {
+	var parts []string
+	switch suggestion.ConsumptionSource {
+	case dtos.ConsumptionFromCounts:
+		parts = append(parts, fmt.Sprintf("Consumo medio de %.2f %s/dia segun los recuentos del %s al %s y lo recibido entre ellos.",
+			suggestion.DailyConsumption, suggestion.Unit, suggestion.ConsumptionFrom, suggestion.ConsumptionTo))
+	case dtos.ConsumptionFromOrders:
+		parts = append(parts, fmt.Sprintf("Consumo medio de %.2f %s/dia segun lo pedido en los ultimos %d dias; no hay dos recuentos para calcularlo mejor.",
+			suggestion.DailyConsumption, suggestion.Unit, consumptionWindowDays))
+	default:
+		parts = append(parts, fmt.Sprintf("No hay recuentos ni pedidos en los ultimos %d dias para estimar el consumo.", consumptionWindowDays))
+	}
+
+	parts = append(parts, fmt.Sprintf("Hay %d en stock y %d pendientes de recibir; tras %d dias de plazo quedarian %.1f.",
+		suggestion.Stock, suggestion.Incoming, suggestion.LeadTimeDays, suggestion.ProjectedStock))
+
+	if fixedLevels {
+		parts = append(parts, fmt.Sprintf("Minimo %d y maximo %d fijados en los parametros del producto.",
+			suggestion.MinLevel, suggestion.MaxLevel))
+	} else {
+		parts = append(parts, fmt.Sprintf("Minimo %d (consumo del plazo) y maximo %d (consumo de %d dias de plazo y %d de cobertura).",
+			suggestion.MinLevel, suggestion.MaxLevel, suggestion.LeadTimeDays, suggestion.ReviewDays))
+	}
+
+	if suggestion.Quantity == 0 {
+		parts = append(parts, "No hace falta pedir.")
+	} else {
+		decision := fmt.Sprintf("Se piden %d %s para llegar al maximo", suggestion.Quantity, suggestion.Unit)
+		if suggestion.PackSize > 1 {
+			decision += fmt.Sprintf(", redondeando a bultos de %d", suggestion.PackSize)
+		}
+		parts = append(parts, decision+".")
+	}
+	return strings.Join(parts, " ")
+}
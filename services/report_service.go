@@ -0,0 +1,482 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+)
+
+// Minutos de cortesia por defecto antes de marcar un retraso o una salida anticipada
+const DefaultGraceMinutes = 5
+
+// Margen alrededor de un turno dentro del que un fichaje se asocia a ese turno
+const attendanceMatchWindow = 2 * time.Hour
+
+type ReportService struct {
+	shiftRepo   *repositories.ShiftRepository
+	timelogRepo *repositories.TimelogRepository
+	workerRepo  *repositories.WorkerRepository
+	storeRepo   *repositories.StoreRepository
+
+	publicHolidayRepo *repositories.PublicHolidayRepository
+	contractRepo      *repositories.ContractRepository
+}
+
+func NewReportService(
+	shiftRepo *repositories.ShiftRepository,
+	timelogRepo *repositories.TimelogRepository,
+	workerRepo *repositories.WorkerRepository,
+	storeRepo *repositories.StoreRepository,
+	publicHolidayRepo *repositories.PublicHolidayRepository,
+	contractRepo *repositories.ContractRepository) *ReportService {
+	return &ReportService{
+		shiftRepo:         shiftRepo,
+		timelogRepo:       timelogRepo,
+		workerRepo:        workerRepo,
+		storeRepo:         storeRepo,
+		publicHolidayRepo: publicHolidayRepo,
+		contractRepo:      contractRepo,
+	}
+}
+
+// workSession - Par entrada/salida de un trabajador
+type workSession struct {
+	workerID string
+	storeID  string
+	in       time.Time
+	out      *time.Time
+	shift    *models.WorkShift // Turno al que se ha asociado, nil si no es de ninguno
+}
+
+// end - Fin de la sesion (la propia entrada si no hay salida)
+func (ws *workSession) end() time.Time {
+	if ws.out != nil {
+		return *ws.out
+	}
+	return ws.in
+}
+
+// buildSessions - Agrupa los fichajes de cada trabajador en sesiones entrada/salida
+// -------------------------------------------------------------------
+// Una entrada sin salida queda abierta; una salida sin entrada se descarta.
+func buildSessions(timelogs []models.Timelog) (map[string][]*workSession, error) {
+	sessions := make(map[string][]*workSession)
+	open := make(map[string]*workSession)
+
+	for _, timelog := range timelogs {
+		at, err := utils.ParseTimestamp(timelog.Timelog)
+		if err != nil {
+			return nil, err
+		}
+
+		switch timelog.InOut {
+		case "Entrada":
+			session := &workSession{workerID: timelog.WorkerID, storeID: timelog.StoreID, in: at}
+			sessions[timelog.WorkerID] = append(sessions[timelog.WorkerID], session)
+			open[timelog.WorkerID] = session
+		case "Salida":
+			if session, ok := open[timelog.WorkerID]; ok {
+				session.out = &at
+				delete(open, timelog.WorkerID)
+			}
+		}
+	}
+	return sessions, nil
+}
+
+// matchSessions - Asocia cada sesion al turno del trabajador con el que mas se solapa
+// -------------------------------------------------------------------
+// Solo cuentan los turnos a menos de attendanceMatchWindow de la sesion. Sin
+// solape, como las entradas sin salida, gana el turno que empieza mas cerca
+// de la entrada. Asi en un turno partido (10-14 y 15-19) cada sesion va a su
+// tramo aunque las dos caigan dentro del margen del primero.
+func matchSessions(shifts []models.WorkShift, sessions map[string][]*workSession) error {
+	type shiftSpan struct {
+		shift      *models.WorkShift
+		start, end time.Time
+	}
+	spans := make(map[string][]shiftSpan)
+	for i := range shifts {
+		workerID := assignedWorker(&shifts[i])
+		if workerID == "" {
+			continue
+		}
+		start, end, err := shiftWindow(&shifts[i])
+		if err != nil {
+			return err
+		}
+		spans[workerID] = append(spans[workerID], shiftSpan{&shifts[i], start, end})
+	}
+
+	for workerID, workerSessions := range sessions {
+		for _, session := range workerSessions {
+			var bestOverlap, bestDistance time.Duration
+			for _, span := range spans[workerID] {
+				if !session.in.Before(span.end.Add(attendanceMatchWindow)) ||
+					!session.end().After(span.start.Add(-attendanceMatchWindow)) {
+					continue
+				}
+				from, to := session.in, session.end()
+				if span.start.After(from) {
+					from = span.start
+				}
+				if span.end.Before(to) {
+					to = span.end
+				}
+				overlap := max(to.Sub(from), 0)
+				distance := session.in.Sub(span.start)
+				if distance < 0 {
+					distance = -distance
+				}
+				if session.shift == nil || overlap > bestOverlap || (overlap == bestOverlap && distance < bestDistance) {
+					session.shift = span.shift
+					bestOverlap, bestDistance = overlap, distance
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// AttendanceReport - Compara los turnos planificados con los fichajes reales
+// -------------------------------------------------------------------
+// Los encargados de tienda solo pueden consultar su propia tienda.
+func (s *ReportService) AttendanceReport(userID, role, from, to, storeID, workerID string, graceMinutes int) (*dtos.AttendanceReport, error) {
+
+	// Validamos el periodo
+	fromDate, err := utils.ParseDate(from)
+	if err != nil {
+		return nil, errors.New("la fecha de inicio no tiene el formato YYYY-MM-DD")
+	}
+	toDate, err := utils.ParseDate(to)
+	if err != nil {
+		return nil, errors.New("la fecha de fin no tiene el formato YYYY-MM-DD")
+	}
+	if toDate.Before(fromDate) {
+		return nil, errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
+	}
+	if graceMinutes < 0 {
+		return nil, errors.New("los minutos de cortesia no pueden ser negativos")
+	}
+
+	// Los encargados solo ven su tienda
+	if role == "store" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+
+	// Obtenemos los turnos, los fichajes y los trabajadores
+	shifts, err := s.shiftRepo.GetShifts(from, to, storeID, workerID)
+	if err != nil {
+		return nil, errors.New("error al obtener los turnos")
+	}
+
+	// Pedimos un dia extra de fichajes para cubrir los turnos que cruzan la
+	// medianoche, y de todas las tiendas porque se puede fichar en otra
+	timelogs, err := s.timelogRepo.GetTimelogs(
+		fromDate.Format("2006-01-02 15:04:05"),
+		toDate.AddDate(0, 0, 2).Format("2006-01-02 15:04:05"),
+		"", workerID)
+	if err != nil {
+		return nil, errors.New("error al obtener los registros horarios")
+	}
+	sessions, err := buildSessions(timelogs)
+	if err != nil {
+		return nil, err
+	}
+
+	workers, err := s.workerRepo.GetAllWorkers()
+	if err != nil {
+		return nil, errors.New("error al obtener los trabajadores")
+	}
+	names := make(map[string]string, len(workers))
+	for _, worker := range workers {
+		names[worker.ID] = worker.Name + " " + worker.LastName
+	}
+
+	// Festivos de cada tienda, cargados segun aparecen en el informe
+	publicHolidays := make(map[string]map[string]string)
+	publicHolidayOf := func(storeID, date string) (string, error) {
+		dates, ok := publicHolidays[storeID]
+		if !ok {
+			var err error
+			dates, err = storePublicHolidays(s.publicHolidayRepo, s.storeRepo, storeID, fromDate, toDate.AddDate(0, 0, 1))
+			if err != nil {
+				return "", err
+			}
+			publicHolidays[storeID] = dates
+		}
+		return dates[date], nil
+	}
+
+	if err := matchSessions(shifts, sessions); err != nil {
+		return nil, err
+	}
+
+	grace := time.Duration(graceMinutes) * time.Minute
+	now := time.Now()
+	rows := []dtos.AttendanceRow{}
+
+	// Recorremos los turnos planificados
+	for i := range shifts {
+		shift := shifts[i]
+		workerID := assignedWorker(&shift)
+		if workerID == "" {
+			continue // Los turnos abiertos sin cubrir no tienen fichajes que comparar
+		}
+		start, end, err := shiftWindow(&shift)
+		if err != nil {
+			return nil, err
+		}
+
+		// Los turnos que aun no han terminado no se evaluan
+		if end.After(now) {
+			continue
+		}
+
+		row := dtos.AttendanceRow{
+			WorkerID:         workerID,
+			WorkerName:       names[workerID],
+			StoreID:          shift.Store,
+			Date:             start.Format("2006-01-02"),
+			ShiftID:          &shift.ID,
+			ScheduledStart:   start.Format("2006-01-02 15:04"),
+			ScheduledEnd:     end.Format("2006-01-02 15:04"),
+			ScheduledMinutes: int(end.Sub(start).Minutes()),
+			Flags:            []string{},
+		}
+		if row.PublicHoliday, err = publicHolidayOf(row.StoreID, row.Date); err != nil {
+			return nil, err
+		}
+		if row.PublicHoliday != "" {
+			row.Flags = append(row.Flags, dtos.AttendancePublicHoliday)
+		}
+
+		// Sesiones del trabajador asociadas a este turno
+		var firstIn, lastOut time.Time
+		var worked time.Duration
+		matched, missingOut := 0, false
+		for _, session := range sessions[workerID] {
+			if session.shift != &shifts[i] {
+				continue
+			}
+			matched++
+			if firstIn.IsZero() || session.in.Before(firstIn) {
+				firstIn = session.in
+				row.StoreID = session.storeID
+			}
+			if session.out == nil {
+				missingOut = true
+				continue
+			}
+			if session.out.After(lastOut) {
+				lastOut = *session.out
+			}
+			worked += session.out.Sub(session.in)
+		}
+
+		if matched == 0 {
+			row.Flags = append(row.Flags, dtos.AttendanceNoShow)
+			rows = append(rows, row)
+			continue
+		}
+
+		if row.StoreID != shift.Store {
+			row.ShiftStoreID = shift.Store
+		}
+		row.ActualIn = firstIn.Format("2006-01-02 15:04")
+		row.WorkedMinutes = int(worked.Minutes())
+		if late := firstIn.Sub(start); late > grace {
+			row.LateMinutes = int(late.Minutes())
+			row.Flags = append(row.Flags, dtos.AttendanceLate)
+		}
+		if missingOut {
+			row.Flags = append(row.Flags, dtos.AttendanceMissingClockOut)
+		}
+		if !lastOut.IsZero() {
+			row.ActualOut = lastOut.Format("2006-01-02 15:04")
+			if early := end.Sub(lastOut); early > grace {
+				row.EarlyLeaveMinutes = int(early.Minutes())
+				row.Flags = append(row.Flags, dtos.AttendanceEarlyDeparture)
+			}
+		}
+		if extra := worked - end.Sub(start); extra > grace {
+			row.OvertimeMinutes = int(extra.Minutes())
+			row.Flags = append(row.Flags, dtos.AttendanceOvertime)
+		}
+		rows = append(rows, row)
+	}
+
+	// Las sesiones del periodo que no encajan con ningun turno son trabajo no planificado
+	periodEnd := toDate.AddDate(0, 0, 1)
+	for _, workerSessions := range sessions {
+		for _, session := range workerSessions {
+			if session.shift != nil || session.in.Before(fromDate) || !session.in.Before(periodEnd) ||
+				(storeID != "" && session.storeID != storeID) {
+				continue
+			}
+			row := dtos.AttendanceRow{
+				WorkerID:   session.workerID,
+				WorkerName: names[session.workerID],
+				StoreID:    session.storeID,
+				Date:       session.in.Format("2006-01-02"),
+				ActualIn:   session.in.Format("2006-01-02 15:04"),
+				Flags:      []string{dtos.AttendanceUnscheduled},
+			}
+			if row.PublicHoliday, err = publicHolidayOf(row.StoreID, row.Date); err != nil {
+				return nil, err
+			}
+			if row.PublicHoliday != "" {
+				row.Flags = append(row.Flags, dtos.AttendancePublicHoliday)
+			}
+			if session.out != nil {
+				row.ActualOut = session.out.Format("2006-01-02 15:04")
+				row.WorkedMinutes = int(session.out.Sub(session.in).Minutes())
+			} else {
+				row.Flags = append(row.Flags, dtos.AttendanceMissingClockOut)
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Date != rows[j].Date {
+			return rows[i].Date < rows[j].Date
+		}
+		if rows[i].WorkerName != rows[j].WorkerName {
+			return rows[i].WorkerName < rows[j].WorkerName
+		}
+		return rows[i].ScheduledStart < rows[j].ScheduledStart
+	})
+
+	// Calculamos los resumenes global, por trabajador y por tienda
+	report := &dtos.AttendanceReport{
+		From:         from,
+		To:           to,
+		StoreID:      storeID,
+		WorkerID:     workerID,
+		GraceMinutes: graceMinutes,
+		ByWorker:     []dtos.AttendanceSummary{},
+		ByStore:      []dtos.AttendanceSummary{},
+		Rows:         rows,
+	}
+	byWorker := make(map[string]*dtos.AttendanceSummary)
+	byStore := make(map[string]*dtos.AttendanceSummary)
+	order, storeOrder := []string{}, []string{}
+	for _, row := range rows {
+		summary, ok := byWorker[row.WorkerID]
+		if !ok {
+			summary = &dtos.AttendanceSummary{WorkerID: row.WorkerID, WorkerName: row.WorkerName}
+			byWorker[row.WorkerID] = summary
+			order = append(order, row.WorkerID)
+		}
+		storeSummary, ok := byStore[row.StoreID]
+		if !ok {
+			storeSummary = &dtos.AttendanceSummary{StoreID: row.StoreID}
+			if store, err := s.storeRepo.FindStoreByID(row.StoreID); err == nil {
+				storeSummary.StoreName = store.Name
+			}
+			byStore[row.StoreID] = storeSummary
+			storeOrder = append(storeOrder, row.StoreID)
+		}
+		addToAttendanceSummary(summary, row)
+		addToAttendanceSummary(storeSummary, row)
+		addToAttendanceSummary(&report.Summary, row)
+	}
+	for _, id := range storeOrder {
+		report.ByStore = append(report.ByStore, *byStore[id])
+	}
+	sort.SliceStable(report.ByStore, func(i, j int) bool {
+		return report.ByStore[i].StoreName < report.ByStore[j].StoreName
+	})
+
+	// Horas de contrato de cada trabajador en el periodo
+	contracts, err := s.contractRepo.GetContractsBetween(order, fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, errors.New("error al obtener los contratos")
+	}
+	for i := range contracts {
+		minutes := contractedMinutes(&contracts[i], fromDate, toDate)
+		byWorker[contracts[i].WorkerID].ContractedMinutes += minutes
+		report.Summary.ContractedMinutes += minutes
+	}
+
+	for _, id := range order {
+		report.ByWorker = append(report.ByWorker, *byWorker[id])
+	}
+	sort.SliceStable(report.ByWorker, func(i, j int) bool {
+		return report.ByWorker[i].WorkerName < report.ByWorker[j].WorkerName
+	})
+
+	return report, nil
+}
+
+// contractedMinutes - Minutos de contrato dentro de un periodo
+// -------------------------------------------------------------------
+// Cada dia natural cubierto por el contrato cuenta un septimo de las horas semanales.
+func contractedMinutes(contract *models.Contract, from, to time.Time) int {
+	start, err := utils.ParseDate(contract.StartDate)
+	if err != nil {
+		return 0
+	}
+	if start.Before(from) {
+		start = from
+	}
+	end := to
+	if contract.EndDate != nil {
+		if contractEnd, err := utils.ParseDate(*contract.EndDate); err == nil && contractEnd.Before(to) {
+			end = contractEnd
+		}
+	}
+	if end.Before(start) {
+		return 0
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+	return int(math.Round(float64(days) * contract.WeeklyHours * 60 / 7))
+}
+
+// addToAttendanceSummary - Suma una fila del informe a un resumen
+// -------------------------------------------------------------------
+func addToAttendanceSummary(summary *dtos.AttendanceSummary, row dtos.AttendanceRow) {
+	summary.ScheduledMinutes += row.ScheduledMinutes
+	summary.WorkedMinutes += row.WorkedMinutes
+	if row.ShiftID != nil {
+		summary.Shifts++
+	}
+
+	onTime := row.ShiftID != nil
+	for _, flag := range row.Flags {
+		switch flag {
+		case dtos.AttendanceLate:
+			summary.Late++
+			onTime = false
+		case dtos.AttendanceEarlyDeparture:
+			summary.EarlyDepartures++
+			onTime = false
+		case dtos.AttendanceNoShow:
+			summary.NoShows++
+			onTime = false
+		case dtos.AttendanceUnscheduled:
+			summary.Unscheduled++
+		case dtos.AttendanceOvertime:
+			summary.Overtime++
+		case dtos.AttendanceMissingClockOut:
+			summary.MissingClockOuts++
+			onTime = false
+		case dtos.AttendancePublicHoliday:
+			summary.HolidayMinutes += row.WorkedMinutes
+		}
+	}
+	if onTime {
+		summary.OnTime++
+	}
+}
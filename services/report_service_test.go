@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+)
+
+func TestMatchSessions(t *testing.T) {
+	worker := "w1"
+	at := func(clock string) time.Time {
+		day, err := time.Parse("2006-01-02 15:04", "2025-03-03 "+clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return day
+	}
+	session := func(in, out string) *workSession {
+		s := &workSession{workerID: worker, in: at(in)}
+		if out != "" {
+			end := at(out)
+			s.out = &end
+		}
+		return s
+	}
+	shift := func(start, end string) models.WorkShift {
+		return models.WorkShift{WorkDate: "2025-03-03", StartInterval: start, EndInterval: end, WorkerID: &worker}
+	}
+
+	tests := []struct {
+		name     string
+		shifts   []models.WorkShift
+		sessions []*workSession
+		want     []int // Indice del turno de cada sesion, -1 si ninguno
+	}{
+		{
+			"turno partido",
+			[]models.WorkShift{shift("10:00", "14:00"), shift("15:00", "19:00")},
+			[]*workSession{session("10:00", "14:00"), session("15:00", "19:00")},
+			[]int{0, 1},
+		},
+		{
+			"turno partido con salida tardia",
+			[]models.WorkShift{shift("10:00", "14:00"), shift("15:00", "19:00")},
+			[]*workSession{session("10:05", "14:30"), session("14:50", "19:10")},
+			[]int{0, 1},
+		},
+		{
+			"entrada sin salida al turno mas cercano",
+			[]models.WorkShift{shift("10:00", "14:00"), shift("15:00", "19:00")},
+			[]*workSession{session("14:50", "")},
+			[]int{1},
+		},
+		{
+			"varias sesiones en un turno con pausa",
+			[]models.WorkShift{shift("09:00", "17:00")},
+			[]*workSession{session("09:00", "13:00"), session("13:30", "17:00")},
+			[]int{0, 0},
+		},
+		{
+			"fuera del margen",
+			[]models.WorkShift{shift("09:00", "13:00")},
+			[]*workSession{session("16:00", "18:00")},
+			[]int{-1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := matchSessions(tt.shifts, map[string][]*workSession{worker: tt.sessions}); err != nil {
+				t.Fatal(err)
+			}
+			for i, s := range tt.sessions {
+				got := -1
+				for j := range tt.shifts {
+					if s.shift == &tt.shifts[j] {
+						got = j
+					}
+				}
+				if got != tt.want[i] {
+					t.Errorf("sesion %d asociada al turno %d, want %d", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,611 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// snapshotOf - Copia los datos de un turno para el historial de cambios
+// -------------------------------------------------------------------
+// Normaliza la fecha y las horas para que las copias sean comparables
+// independientemente de si vienen de la base de datos o de la peticion.
+func snapshotOf(shift *models.WorkShift) *models.ShiftSnapshot {
+	snapshot := &models.ShiftSnapshot{
+		WorkDate:      shift.WorkDate,
+		StartInterval: shift.StartInterval,
+		EndInterval:   shift.EndInterval,
+		Store:         shift.Store,
+		WorkerID:      assignedWorker(shift),
+	}
+	if date, err := utils.ParseDate(shift.WorkDate); err == nil {
+		snapshot.WorkDate = date.Format("2006-01-02")
+	}
+	if clock, err := utils.ParseClock(shift.StartInterval); err == nil {
+		snapshot.StartInterval = time.Time{}.Add(clock).Format("15:04:05")
+	}
+	if clock, err := utils.ParseClock(shift.EndInterval); err == nil {
+		snapshot.EndInterval = time.Time{}.Add(clock).Format("15:04:05")
+	}
+	return snapshot
+}
+
+// describeSnapshot - Texto corto de un turno: "2024-05-02 de 09:00 a 17:00"
+func describeSnapshot(snapshot *models.ShiftSnapshot) string {
+	clock := func(value string) string {
+		if len(value) >= 5 {
+			return value[:5]
+		}
+		return value
+	}
+	return fmt.Sprintf("%s de %s a %s", snapshot.WorkDate, clock(snapshot.StartInterval), clock(snapshot.EndInterval))
+}
+
+// describeChange - Describe un cambio de horario desde el punto de vista de un trabajador
+// -------------------------------------------------------------------
+func describeChange(workerID string, change *models.ScheduleChange) string {
+	wasMine := change.Before != nil && change.Before.WorkerID == workerID
+	isMine := change.After != nil && change.After.WorkerID == workerID
+
+	switch {
+	case change.ChangeType == models.ChangeTypeCreated:
+		return "Nuevo turno el " + describeSnapshot(change.After)
+	case change.ChangeType == models.ChangeTypeDeleted:
+		return "Se ha eliminado tu turno del " + describeSnapshot(change.Before)
+	case wasMine && !isMine:
+		return "Tu turno del " + describeSnapshot(change.Before) + " ya no esta asignado a ti"
+	case !wasMine && isMine:
+		return "Se te ha asignado el turno del " + describeSnapshot(change.After)
+	default:
+		return "Tu turno del " + describeSnapshot(change.Before) + " pasa a ser el " + describeSnapshot(change.After)
+	}
+}
+
+// shiftPeriod - Devuelve el periodo de un turno (nil en los turnos sin periodo)
+// -------------------------------------------------------------------
+func (s *ShiftService) shiftPeriod(tx *gorm.DB, shift *models.WorkShift) (*models.SchedulePeriod, error) {
+	if shift.PeriodID == nil {
+		return nil, nil
+	}
+	period, err := s.scheduleRepo.FindPeriodByID(tx, *shift.PeriodID)
+	if err != nil {
+		return nil, errors.New("el periodo del turno no existe")
+	}
+	return period, nil
+}
+
+// isPublished - Indica si un periodo es visible para los trabajadores
+func isPublished(period *models.SchedulePeriod) bool {
+	return period == nil || period.Status == models.PeriodStatusPublished
+}
+
+// storeForUser - Devuelve la tienda sobre la que actua el usuario
+// -------------------------------------------------------------------
+// Los encargados siempre actuan sobre su tienda; el admin indica la tienda.
+func (s *ShiftService) storeForUser(userID, role, storeID string) (string, error) {
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return "", errors.New("no se encontro la tienda del usuario")
+		}
+		return store.ID, nil
+	}
+	if storeID == "" {
+		return "", errors.New("el id de la tienda es obligatorio")
+	}
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return "", errors.New("la tienda no existe")
+	}
+	return storeID, nil
+}
+
+// recordChange - Registra un cambio sobre un horario publicado y avisa a los trabajadores afectados
+// -------------------------------------------------------------------
+func (s *ShiftService) recordChange(tx *gorm.DB, userID string, period *models.SchedulePeriod, shiftID int, changeType string, before, after *models.ShiftSnapshot) error {
+	change := &models.ScheduleChange{
+		ShiftID:     shiftID,
+		ChangeType:  changeType,
+		Before:      before,
+		After:       after,
+		ChangedByID: userID,
+	}
+	if period != nil {
+		change.PeriodID = period.ID
+	}
+	if err := s.scheduleRepo.CreateChange(tx, change); err != nil {
+		return errors.New("error al registrar el cambio de horario")
+	}
+
+	// Avisamos a cada trabajador afectado con el cambio de su turno
+	affected := []string{}
+	if before != nil && before.WorkerID != "" {
+		affected = append(affected, before.WorkerID)
+	}
+	if after != nil && after.WorkerID != "" && (before == nil || after.WorkerID != before.WorkerID) {
+		affected = append(affected, after.WorkerID)
+	}
+	for _, workerID := range affected {
+		worker, err := s.workerRepo.FindWorkerByID(workerID)
+		if err != nil {
+			continue
+		}
+		notification := &models.Notification{
+			UserID: worker.UserID,
+			Type:   "cambio_horario",
+			Title:  "Cambio en tu horario",
+			Body:   describeChange(workerID, change),
+		}
+		if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+			return errors.New("error al notificar el cambio de horario")
+		}
+	}
+	return nil
+}
+
+// CreatePeriod - Crea un periodo de planificacion en borrador
+// -------------------------------------------------------------------
+func (s *ShiftService) CreatePeriod(userID, role string, period *models.SchedulePeriod) error {
+	storeID, err := s.storeForUser(userID, role, period.StoreID)
+	if err != nil {
+		return err
+	}
+
+	// Validamos las fechas del periodo
+	start, err := time.Parse("2006-01-02", period.StartDate)
+	if err != nil {
+		return errors.New("la fecha de inicio no tiene el formato YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", period.EndDate)
+	if err != nil {
+		return errors.New("la fecha de fin no tiene el formato YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
+	}
+
+	// Los periodos de una tienda no se pueden solapar
+	overlapping, err := s.scheduleRepo.CountOverlappingPeriods(storeID, period.StartDate, period.EndDate)
+	if err != nil {
+		return errors.New("error al comprobar los periodos de la tienda")
+	}
+	if overlapping > 0 {
+		return errors.New("el periodo se solapa con otro periodo de la tienda")
+	}
+
+	period.ID = uuid.New().String()
+	period.StoreID = storeID
+	period.Status = models.PeriodStatusDraft
+	period.PublishedAt = nil
+	period.PublishedByID = nil
+
+	if err := s.scheduleRepo.CreatePeriod(period); err != nil {
+		return errors.New("error al crear el periodo")
+	}
+	return nil
+}
+
+// GetPeriods - Obtiene los periodos de planificacion visibles para el usuario
+// -------------------------------------------------------------------
+func (s *ShiftService) GetPeriods(userID, role, storeID string) ([]models.SchedulePeriod, error) {
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+	return s.scheduleRepo.GetPeriods(storeID)
+}
+
+// GetPeriod - Obtiene un periodo con todos sus turnos y los festivos de la tienda
+// -------------------------------------------------------------------
+func (s *ShiftService) GetPeriod(userID, role, periodID string) (*models.SchedulePeriod, []models.WorkShift, []models.PublicHoliday, error) {
+	period, err := s.scheduleRepo.FindPeriodByID(nil, periodID)
+	if err != nil {
+		return nil, nil, nil, errors.New("el periodo no existe")
+	}
+	if err := s.checkStoreAccess(userID, role, period.StoreID); err != nil {
+		return nil, nil, nil, err
+	}
+	shifts, err := s.shiftRepo.GetPeriodShifts(nil, period.ID)
+	if err != nil {
+		return nil, nil, nil, errors.New("error al obtener los turnos del periodo")
+	}
+
+	store, err := s.storeRepo.FindStoreByID(period.StoreID)
+	if err != nil {
+		return nil, nil, nil, errors.New("la tienda del periodo no existe")
+	}
+	publicHolidays, err := s.publicHolidayRepo.GetStoreHolidays(store.ID, store.City, period.StartDate[:10], period.EndDate[:10])
+	if err != nil {
+		return nil, nil, nil, errors.New("error al obtener los festivos del periodo")
+	}
+	return period, shifts, publicHolidays, nil
+}
+
+// GetPeriodChanges - Obtiene los cambios hechos sobre un periodo publicado
+// -------------------------------------------------------------------
+func (s *ShiftService) GetPeriodChanges(userID, role, periodID string) ([]models.ScheduleChange, error) {
+	period, err := s.scheduleRepo.FindPeriodByID(nil, periodID)
+	if err != nil {
+		return nil, errors.New("el periodo no existe")
+	}
+	if err := s.checkStoreAccess(userID, role, period.StoreID); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepo.GetPeriodChanges(period.ID)
+}
+
+// PublishPeriod - Publica un periodo y avisa a cada trabajador con su horario
+// -------------------------------------------------------------------
+func (s *ShiftService) PublishPeriod(userID, role, periodID string) error {
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	period, err := s.scheduleRepo.LockPeriodByID(tx, periodID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("el periodo no existe")
+	}
+	if err := s.checkStoreAccess(userID, role, period.StoreID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if period.Status != models.PeriodStatusDraft {
+		tx.Rollback()
+		return errors.New("el periodo ya esta publicado")
+	}
+
+	// Publicamos el periodo
+	now := time.Now()
+	period.Status = models.PeriodStatusPublished
+	period.PublishedAt = &now
+	period.PublishedByID = &userID
+	if err := s.scheduleRepo.UpdatePeriod(tx, period); err != nil {
+		tx.Rollback()
+		return errors.New("error al publicar el periodo")
+	}
+
+	// Enviamos a cada trabajador la lista de sus turnos
+	shifts, err := s.shiftRepo.GetPeriodShifts(tx, period.ID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al obtener los turnos del periodo")
+	}
+	byWorker := make(map[string][]string)
+	order := []string{}
+	for i := range shifts {
+		workerID := assignedWorker(&shifts[i])
+		if workerID == "" {
+			if err := s.notifyOpenShift(tx, &shifts[i]); err != nil {
+				tx.Rollback()
+				return err
+			}
+			continue
+		}
+		if _, ok := byWorker[workerID]; !ok {
+			order = append(order, workerID)
+		}
+		byWorker[workerID] = append(byWorker[workerID], describeSnapshot(snapshotOf(&shifts[i])))
+	}
+	for _, workerID := range order {
+		worker, err := s.workerRepo.FindWorkerByID(workerID)
+		if err != nil {
+			continue
+		}
+		notification := &models.Notification{
+			UserID: worker.UserID,
+			Type:   "horario_publicado",
+			Title:  fmt.Sprintf("Horario publicado del %s al %s", period.StartDate[:10], period.EndDate[:10]),
+			Body:   strings.Join(byWorker[workerID], "\n"),
+		}
+		if err := s.notifyRepo.CreateNotification(tx, notification); err != nil {
+			tx.Rollback()
+			return errors.New("error al notificar el horario")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// CreateShift - Crea un turno dentro del periodo de planificacion de su fecha
+// -------------------------------------------------------------------
+func (s *ShiftService) CreateShift(userID, role string, shift *models.WorkShift) error {
+
+	// Validaciones de los campos del turno
+	if err := utils.ValidateShiftFields(shift); err != nil {
+		return err
+	}
+	storeID, err := s.storeForUser(userID, role, shift.Store)
+	if err != nil {
+		return err
+	}
+
+	// Buscamos el periodo que contiene la fecha del turno
+	period, err := s.scheduleRepo.FindPeriodForDate(storeID, shift.WorkDate)
+	if err != nil {
+		return errors.New("no hay un periodo de planificacion para esa fecha")
+	}
+
+	shift.ID = 0
+	shift.Store = storeID
+	shift.PeriodID = &period.ID
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if shift.WorkerID != nil {
+		if err := s.checkShiftAssignment(tx, *shift.WorkerID, shift); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := s.shiftRepo.CreateShift(tx, shift); err != nil {
+		tx.Rollback()
+		return errors.New("error al crear el turno")
+	}
+
+	// En un horario publicado el cambio queda registrado y se avisa al trabajador
+	// o, si es un turno abierto, a los trabajadores que pueden reclamarlo
+	if isPublished(period) {
+		if err := s.recordChange(tx, userID, period, shift.ID, models.ChangeTypeCreated, nil, snapshotOf(shift)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if shift.WorkerID == nil {
+			if err := s.notifyOpenShift(tx, shift); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// UpdateShift - Modifica la fecha, el horario, el color o el trabajador de un turno
+// -------------------------------------------------------------------
+func (s *ShiftService) UpdateShift(userID, role string, shiftID int, input *models.WorkShift) error {
+
+	// Validaciones de los campos del turno
+	if err := utils.ValidateShiftFields(input); err != nil {
+		return err
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	shift, err := s.shiftRepo.LockShiftByID(tx, shiftID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("el turno no existe")
+	}
+	if err := s.checkStoreAccess(userID, role, shift.Store); err != nil {
+		tx.Rollback()
+		return err
+	}
+	period, err := s.shiftPeriod(tx, shift)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// La nueva fecha tiene que seguir dentro del periodo del turno
+	if period != nil && (input.WorkDate < period.StartDate[:10] || input.WorkDate > period.EndDate[:10]) {
+		tx.Rollback()
+		return errors.New("la fecha del turno debe estar dentro de su periodo")
+	}
+
+	before := snapshotOf(shift)
+	shift.WorkDate = input.WorkDate
+	shift.StartInterval = input.StartInterval
+	shift.EndInterval = input.EndInterval
+	shift.CellColor = input.CellColor
+	shift.WorkerID = input.WorkerID
+	shift.Cargo = input.Cargo
+	shift.OpenToNearby = input.OpenToNearby
+
+	if shift.WorkerID != nil {
+		if err := s.checkShiftAssignment(tx, *shift.WorkerID, shift); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := s.shiftRepo.UpdateShift(tx, shift); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar el turno")
+	}
+
+	// En un horario publicado el cambio queda registrado y se avisa a los afectados
+	if isPublished(period) {
+		after := snapshotOf(shift)
+		if *before != *after {
+			if err := s.recordChange(tx, userID, period, shift.ID, models.ChangeTypeModified, before, after); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if before.WorkerID != "" && before.WorkerID != after.WorkerID {
+			if err := s.cancelShiftEvent(tx, shift, before.WorkerID); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// DeleteShift - Elimina un turno
+// -------------------------------------------------------------------
+func (s *ShiftService) DeleteShift(userID, role string, shiftID int) error {
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	shift, err := s.shiftRepo.LockShiftByID(tx, shiftID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("el turno no existe")
+	}
+	if err := s.checkStoreAccess(userID, role, shift.Store); err != nil {
+		tx.Rollback()
+		return err
+	}
+	period, err := s.shiftPeriod(tx, shift)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	inProgress, err := s.swapRepo.CountActiveSwapsByShift(tx, shift.ID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al comprobar las solicitudes del turno")
+	}
+	if inProgress > 0 {
+		tx.Rollback()
+		return errors.New("no se puede eliminar un turno con una solicitud de cambio activa")
+	}
+
+	// Las solicitudes cerradas y las reclamaciones apuntan al turno y se van con el
+	if err := s.swapRepo.DeleteSwapsByShift(tx, shift.ID); err != nil {
+		tx.Rollback()
+		return errors.New("error al eliminar las solicitudes de cambio del turno")
+	}
+	if err := s.claimRepo.DeleteClaimsByShift(tx, shift.ID); err != nil {
+		tx.Rollback()
+		return errors.New("error al eliminar las reclamaciones del turno")
+	}
+
+	if err := s.shiftRepo.DeleteShift(tx, shift.ID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("el turno no existe")
+		}
+		return errors.New("error al eliminar el turno")
+	}
+
+	// En un horario publicado el cambio queda registrado y el turno se cancela en los calendarios
+	if isPublished(period) {
+		if err := s.recordChange(tx, userID, period, shift.ID, models.ChangeTypeDeleted, snapshotOf(shift), nil); err != nil {
+			tx.Rollback()
+			return err
+		}
+		cancellation, err := newShiftCancellation(shift, shift.WorkerID, &shift.Store)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := s.calendarRepo.CreateCancellation(tx, cancellation); err != nil {
+			tx.Rollback()
+			return errors.New("error al actualizar los calendarios")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetWorkerShifts - Obtiene los turnos publicados del trabajador autenticado
+// -------------------------------------------------------------------
+func (s *ShiftService) GetWorkerShifts(userID, from, to string) ([]models.WorkShift, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		return nil, errors.New("la fecha de inicio no tiene el formato YYYY-MM-DD")
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil {
+		return nil, errors.New("la fecha de fin no tiene el formato YYYY-MM-DD")
+	}
+	return s.shiftRepo.GetShifts(from, to, "", worker.ID)
+}
+
+// GetWorkerChanges - Obtiene los cambios de horario que afectan al trabajador autenticado
+// -------------------------------------------------------------------
+func (s *ShiftService) GetWorkerChanges(userID, since string) ([]dtos.WorkerScheduleChange, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, errors.New("no se encontro el trabajador del usuario")
+	}
+
+	changes, err := s.scheduleRepo.GetWorkerChanges(worker.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dtos.WorkerScheduleChange, 0, len(changes))
+	for i := range changes {
+		result = append(result, dtos.WorkerScheduleChange{
+			ID:          changes[i].ID,
+			ShiftID:     changes[i].ShiftID,
+			ChangeType:  changes[i].ChangeType,
+			Before:      changes[i].Before,
+			After:       changes[i].After,
+			Description: describeChange(worker.ID, &changes[i]),
+			CreatedAt:   changes[i].CreatedAt,
+		})
+	}
+	return result, nil
+}
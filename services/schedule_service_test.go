@@ -0,0 +1,106 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newShiftTestService - ShiftService sobre una base de datos SQLite temporal
+// -------------------------------------------------------------------
+// Activa las claves foraneas para que los borrados fallen como en Postgres.
+// Algunos repositorios leen fuera de la transaccion, asi que la base va en un
+// fichero en modo WAL para que varias conexiones la compartan.
+func newShiftTestService(t *testing.T) (*ShiftService, *gorm.DB) {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(&models.User{}, &models.Store{}, &models.Worker{}, &models.SchedulePeriod{},
+		&models.WorkShift{}, &models.ShiftSwap{}, &models.ShiftClaim{}, &models.ScheduleChange{},
+		&models.Notification{}, &models.CalendarCancellation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewShiftService(
+		repositories.NewShiftRepository(db),
+		repositories.NewShiftSwapRepository(db),
+		repositories.NewShiftClaimRepository(db),
+		repositories.NewWorkerRepository(db),
+		repositories.NewStoreRepository(db),
+		repositories.NewHolidaysRepository(db),
+		repositories.NewCalendarRepository(db),
+		repositories.NewScheduleRepository(db),
+		repositories.NewNotificationRepository(db),
+		repositories.NewPublicHolidayRepository(db),
+		repositories.NewContractRepository(db),
+		db)
+	return service, db
+}
+
+func TestDeleteShiftWithHistory(t *testing.T) {
+	tests := []struct {
+		name       string
+		swapStatus string
+		wantErr    bool
+	}{
+		{"solicitud cancelada", models.SwapStatusCancelled, false},
+		{"solicitud aprobada", models.SwapStatusApproved, false},
+		{"solicitud pendiente", models.SwapStatusPending, true},
+		{"solicitud aceptada", models.SwapStatusAccepted, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, db := newShiftTestService(t)
+
+			user := models.User{ID: "u1", Username: "ana", Password: "x", Role: "worker"}
+			worker := models.Worker{ID: "w1", Nie: "12345678Z", UserID: user.ID}
+			shift := models.WorkShift{WorkDate: "2025-03-03", StartInterval: "09:00:00", EndInterval: "17:00:00", WorkerID: &worker.ID}
+			for _, row := range []interface{}{&user, &worker, &shift} {
+				if err := db.Create(row).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			swap := models.ShiftSwap{ID: "s1", Type: models.SwapTypeOpen, Status: tt.swapStatus, ShiftID: shift.ID, RequesterID: worker.ID}
+			claim := models.ShiftClaim{ID: "c1", ShiftID: shift.ID, WorkerID: worker.ID, Status: models.ClaimStatusRejected}
+			if err := db.Omit("Shift", "Requester").Create(&swap).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Omit("Shift", "Worker").Create(&claim).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			err := service.DeleteShift("admin", "admin", shift.ID)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("DeleteShift deberia fallar con una solicitud activa")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeleteShift: %v", err)
+			}
+
+			var shifts, swaps, claims int64
+			db.Model(&models.WorkShift{}).Where("id = ?", shift.ID).Count(&shifts)
+			db.Model(&models.ShiftSwap{}).Where("shift_id = ?", shift.ID).Count(&swaps)
+			db.Model(&models.ShiftClaim{}).Where("shift_id = ?", shift.ID).Count(&claims)
+			if shifts != 0 || swaps != 0 || claims != 0 {
+				t.Errorf("quedan %d turnos, %d solicitudes y %d reclamaciones", shifts, swaps, claims)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Descanso minimo entre el fin de un turno y el inicio del siguiente (art. 34.3 ET)
+const minRestBetweenShifts = 12 * time.Hour
+
+// shiftWindow - Devuelve el inicio y el fin de un turno
+// -------------------------------------------------------------------
+// Si la hora de salida es anterior a la de entrada el turno cruza la medianoche.
+func shiftWindow(shift *models.WorkShift) (time.Time, time.Time, error) {
+	date, err := utils.ParseDate(shift.WorkDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	startClock, err := utils.ParseClock(shift.StartInterval)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endClock, err := utils.ParseClock(shift.EndInterval)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	start := date.Add(startClock)
+	end := date.Add(endClock)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// shiftStarted - Indica si un turno ya ha empezado en un momento dado
+// -------------------------------------------------------------------
+// Los turnos guardan la hora local de la tienda sin zona y shiftWindow los
+// construye en UTC, asi que now se pasa a UTC conservando su hora local.
+func shiftStarted(shift *models.WorkShift, now time.Time) (bool, error) {
+	start, _, err := shiftWindow(shift)
+	if err != nil {
+		return false, err
+	}
+	wall := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), time.UTC)
+	return start.Before(wall), nil
+}
+
+// assignedWorker - Devuelve el ID del trabajador de un turno ("" si es un turno abierto)
+func assignedWorker(shift *models.WorkShift) string {
+	if shift.WorkerID == nil {
+		return ""
+	}
+	return *shift.WorkerID
+}
+
+// checkShiftAssignment - Comprueba que un trabajador pueda hacer un turno
+// -------------------------------------------------------------------
+// Valida que el trabajador este de alta, que no este de vacaciones ese dia,
+// que el turno no se solape con otros suyos, que respete el descanso minimo
+// y que no supere las horas semanales de su contrato. Si tiene contratos
+// registrados, en vez del estado de hoy se mira el contrato en vigor el dia
+// del turno. Los turnos de ignoreShiftIDs no se tienen en cuenta (p.ej. el
+// turno que cede el propio trabajador en un intercambio).
+func (s *ShiftService) checkShiftAssignment(tx *gorm.DB, workerID string, shift *models.WorkShift, ignoreShiftIDs ...int) error {
+
+	worker, err := s.workerRepo.FindWorkerByID(workerID)
+	if err != nil {
+		return errors.New("el trabajador no existe")
+	}
+
+	start, end, err := shiftWindow(shift)
+	if err != nil {
+		return err
+	}
+	date := start.Format("2006-01-02")
+
+	// Comprobamos que tenga contrato ese dia o, sin contratos, que este de alta
+	hasContracts, err := s.contractRepo.HasContracts(tx, workerID)
+	if err != nil {
+		return errors.New("error al comprobar los contratos del trabajador")
+	}
+	var contract *models.Contract
+	if hasContracts {
+		contract, err = s.contractRepo.FindContractOn(tx, workerID, date)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s %s no tiene contrato en vigor el %s", worker.Name, worker.LastName, date)
+		}
+		if err != nil {
+			return errors.New("error al comprobar los contratos del trabajador")
+		}
+	} else if worker.Status != "Alta" {
+		return fmt.Errorf("%s %s no esta de alta", worker.Name, worker.LastName)
+	}
+
+	// Comprobamos que no tenga vacaciones ese dia
+	holidays, err := s.holidaysRepo.GetWorkerHolidaysBetween(tx, workerID, date, date)
+	if err != nil {
+		return errors.New("error al comprobar las vacaciones del trabajador")
+	}
+	for i := range holidays {
+		if isApprovedHoliday(&holidays[i]) {
+			return fmt.Errorf("%s %s tiene vacaciones el %s", worker.Name, worker.LastName, date)
+		}
+	}
+
+	// Comprobamos solapes y descansos con los turnos del dia anterior, el mismo y el siguiente
+	ignore := append([]int{shift.ID}, ignoreShiftIDs...)
+	others, err := s.shiftRepo.GetWorkerShiftsBetween(tx, workerID,
+		start.AddDate(0, 0, -1).Format("2006-01-02"),
+		start.AddDate(0, 0, 1).Format("2006-01-02"),
+		ignore...)
+	if err != nil {
+		return errors.New("error al comprobar los turnos del trabajador")
+	}
+
+	for i := range others {
+		otherStart, otherEnd, err := shiftWindow(&others[i])
+		if err != nil {
+			return err
+		}
+		if start.Before(otherEnd) && otherStart.Before(end) {
+			return fmt.Errorf("el turno se solapa con otro turno de %s %s el %s",
+				worker.Name, worker.LastName, otherStart.Format("2006-01-02 15:04"))
+		}
+		if (!otherEnd.After(start) && start.Sub(otherEnd) < minRestBetweenShifts) ||
+			(!end.After(otherStart) && otherStart.Sub(end) < minRestBetweenShifts) {
+			return fmt.Errorf("%s %s no tendria el descanso minimo de %d horas entre turnos",
+				worker.Name, worker.LastName, int(minRestBetweenShifts.Hours()))
+		}
+	}
+
+	// Comprobamos las horas de la semana (de lunes a domingo) contra el contrato
+	if contract != nil {
+		monday := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		week, err := s.shiftRepo.GetWorkerShiftsBetween(tx, workerID,
+			monday.Format("2006-01-02"), monday.AddDate(0, 0, 6).Format("2006-01-02"), ignore...)
+		if err != nil {
+			return errors.New("error al comprobar los turnos del trabajador")
+		}
+		minutes := end.Sub(start).Minutes()
+		for i := range week {
+			weekStart, weekEnd, err := shiftWindow(&week[i])
+			if err != nil {
+				return err
+			}
+			minutes += weekEnd.Sub(weekStart).Minutes()
+		}
+		if minutes > contract.WeeklyHours*60 {
+			return fmt.Errorf("%s %s pasaria a %.1f horas la semana del %s y su contrato es de %.1f horas semanales",
+				worker.Name, worker.LastName, minutes/60, monday.Format("2006-01-02"), contract.WeeklyHours)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/javimartzs/worker-hub-backend/models"
+)
+
+func TestShiftStarted(t *testing.T) {
+	madrid := time.FixedZone("CEST", 2*3600)
+	shift := &models.WorkShift{WorkDate: "2025-06-02", StartInterval: "09:00:00", EndInterval: "17:00:00"}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"una hora antes en Madrid", time.Date(2025, 6, 2, 8, 0, 0, 0, madrid), false},
+		{"a la hora en Madrid", time.Date(2025, 6, 2, 9, 0, 0, 0, madrid), false},
+		{"un minuto despues en Madrid", time.Date(2025, 6, 2, 9, 1, 0, 0, madrid), true},
+		{"misma hora en UTC", time.Date(2025, 6, 2, 8, 59, 0, 0, time.UTC), false},
+		{"dia anterior", time.Date(2025, 6, 1, 23, 0, 0, 0, madrid), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shiftStarted(shift, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("shiftStarted(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
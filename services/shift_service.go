@@ -0,0 +1,597 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"gorm.io/gorm"
+)
+
+type ShiftService struct {
+	shiftRepo    *repositories.ShiftRepository
+	swapRepo     *repositories.ShiftSwapRepository
+	claimRepo    *repositories.ShiftClaimRepository
+	workerRepo   *repositories.WorkerRepository
+	storeRepo    *repositories.StoreRepository
+	holidaysRepo *repositories.HolidaysRepository
+	calendarRepo *repositories.CalendarRepository
+	scheduleRepo *repositories.ScheduleRepository
+	notifyRepo   *repositories.NotificationRepository
+	contractRepo *repositories.ContractRepository
+
+	publicHolidayRepo *repositories.PublicHolidayRepository
+
+	db *gorm.DB
+}
+
+func NewShiftService(
+	shiftRepo *repositories.ShiftRepository,
+	swapRepo *repositories.ShiftSwapRepository,
+	claimRepo *repositories.ShiftClaimRepository,
+	workerRepo *repositories.WorkerRepository,
+	storeRepo *repositories.StoreRepository,
+	holidaysRepo *repositories.HolidaysRepository,
+	calendarRepo *repositories.CalendarRepository,
+	scheduleRepo *repositories.ScheduleRepository,
+	notifyRepo *repositories.NotificationRepository,
+	publicHolidayRepo *repositories.PublicHolidayRepository,
+	contractRepo *repositories.ContractRepository,
+	db *gorm.DB) *ShiftService {
+	return &ShiftService{
+		shiftRepo:    shiftRepo,
+		swapRepo:     swapRepo,
+		claimRepo:    claimRepo,
+		workerRepo:   workerRepo,
+		storeRepo:    storeRepo,
+		holidaysRepo: holidaysRepo,
+		calendarRepo: calendarRepo,
+		scheduleRepo: scheduleRepo,
+		notifyRepo:   notifyRepo,
+		contractRepo: contractRepo,
+
+		publicHolidayRepo: publicHolidayRepo,
+
+		db: db,
+	}
+}
+
+// isEligibleForShift - Indica si un trabajador puede cubrir el turno de otro
+// -------------------------------------------------------------------
+// Debe estar de alta, pertenecer a la tienda del turno y tener el mismo cargo.
+func isEligibleForShift(candidate, requester *models.Worker, shift *models.WorkShift) bool {
+	return candidate.Status == "Alta" &&
+		candidate.Cargo == requester.Cargo &&
+		candidate.StoreID != nil && *candidate.StoreID == shift.Store
+}
+
+// checkStoreAccess - Comprueba que el usuario pueda gestionar los turnos de una tienda
+// -------------------------------------------------------------------
+func (s *ShiftService) checkStoreAccess(userID, role, storeID string) error {
+	if role == "admin" {
+		return nil
+	}
+	store, err := s.storeRepo.FindStoreByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro la tienda del usuario")
+	}
+	if store.ID != storeID {
+		return errors.New("el turno no pertenece a tu tienda")
+	}
+	return nil
+}
+
+// cancelShiftEvent - Publica como cancelado un turno en el calendario de un trabajador
+// -------------------------------------------------------------------
+func (s *ShiftService) cancelShiftEvent(tx *gorm.DB, shift *models.WorkShift, workerID string) error {
+	cancellation, err := newShiftCancellation(shift, &workerID, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.calendarRepo.CreateCancellation(tx, cancellation); err != nil {
+		return errors.New("error al actualizar el calendario del trabajador")
+	}
+	return nil
+}
+
+// reassignShift - Asigna un turno a otro trabajador registrando el cambio
+// -------------------------------------------------------------------
+// El turno desaparece del calendario de su antiguo titular y, si el horario
+// esta publicado, el cambio queda en el historial y se avisa a ambos.
+func (s *ShiftService) reassignShift(tx *gorm.DB, userID string, shift *models.WorkShift, workerID string) error {
+	period, err := s.shiftPeriod(tx, shift)
+	if err != nil {
+		return err
+	}
+
+	before := snapshotOf(shift)
+	if err := s.shiftRepo.UpdateShiftWorker(tx, shift.ID, workerID); err != nil {
+		return errors.New("error al reasignar el turno")
+	}
+	if before.WorkerID != "" {
+		if err := s.cancelShiftEvent(tx, shift, before.WorkerID); err != nil {
+			return err
+		}
+	}
+	shift.WorkerID = &workerID
+
+	if isPublished(period) {
+		return s.recordChange(tx, userID, period, shift.ID, models.ChangeTypeModified, before, snapshotOf(shift))
+	}
+	return nil
+}
+
+// checkSwappable - Comprueba que un turno este publicado y no haya empezado
+// -------------------------------------------------------------------
+// Se aplica a los dos turnos de un intercambio al crearlo y al aprobarlo;
+// name nombra el turno en el mensaje de error.
+func (s *ShiftService) checkSwappable(tx *gorm.DB, shift *models.WorkShift, name string) error {
+	period, err := s.shiftPeriod(tx, shift)
+	if err != nil {
+		return err
+	}
+	if !isPublished(period) {
+		return fmt.Errorf("%s aun no esta publicado", name)
+	}
+	started, err := shiftStarted(shift, time.Now())
+	if err != nil {
+		return err
+	}
+	if started {
+		return fmt.Errorf("%s ya ha empezado y no se puede cambiar", name)
+	}
+	return nil
+}
+
+// CreateSwap - Crea una solicitud de intercambio o cesion de turno
+// -------------------------------------------------------------------
+func (s *ShiftService) CreateSwap(userID string, swap *models.ShiftSwap) error {
+
+	// Buscamos el trabajador que hace la solicitud
+	requester, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	// Comprobamos que el turno exista, sea suyo y no haya pasado
+	shift, err := s.shiftRepo.FindShiftByID(nil, swap.ShiftID)
+	if err != nil {
+		return errors.New("el turno no existe")
+	}
+	if assignedWorker(shift) != requester.ID {
+		return errors.New("solo puedes ceder tus propios turnos")
+	}
+	if err := s.checkSwappable(nil, shift, "el turno"); err != nil {
+		return err
+	}
+
+	// Validamos la contraparte segun el tipo de solicitud
+	switch swap.Type {
+	case models.SwapTypeExchange:
+		if swap.TargetShiftID == nil {
+			return errors.New("el turno a intercambiar es obligatorio")
+		}
+		targetShift, err := s.shiftRepo.FindShiftByID(nil, *swap.TargetShiftID)
+		if err != nil {
+			return errors.New("el turno a intercambiar no existe")
+		}
+		if targetShift.Store != shift.Store {
+			return errors.New("los turnos a intercambiar deben ser de la misma tienda")
+		}
+		if err := s.checkSwappable(nil, targetShift, "el turno a intercambiar"); err != nil {
+			return err
+		}
+		targetWorker, err := s.workerRepo.FindWorkerByID(assignedWorker(targetShift))
+		if err != nil {
+			return errors.New("el trabajador del turno a intercambiar no existe")
+		}
+		if targetWorker.ID == requester.ID {
+			return errors.New("no puedes intercambiar un turno contigo mismo")
+		}
+		if !isEligibleForShift(targetWorker, requester, shift) {
+			return errors.New("el trabajador debe ser de la misma tienda y cargo")
+		}
+		swap.TargetWorkerID = &targetWorker.ID
+
+	case models.SwapTypeGiveAway:
+		if swap.TargetWorkerID == nil || *swap.TargetWorkerID == "" {
+			return errors.New("el trabajador destino es obligatorio")
+		}
+		targetWorker, err := s.workerRepo.FindWorkerByID(*swap.TargetWorkerID)
+		if err != nil {
+			return errors.New("el trabajador destino no existe")
+		}
+		if targetWorker.ID == requester.ID {
+			return errors.New("no puedes cederte un turno a ti mismo")
+		}
+		if !isEligibleForShift(targetWorker, requester, shift) {
+			return errors.New("el trabajador debe ser de la misma tienda y cargo")
+		}
+		swap.TargetShiftID = nil
+
+	case models.SwapTypeOpen:
+		swap.TargetWorkerID = nil
+		swap.TargetShiftID = nil
+
+	default:
+		return errors.New("el tipo debe ser Intercambio, Cesion o Abierta")
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Con el turno bloqueado dos solicitudes a la vez no pueden pasar la comprobacion
+	shift, err = s.shiftRepo.LockShiftByID(tx, shift.ID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("el turno no existe")
+	}
+	if assignedWorker(shift) != requester.ID {
+		tx.Rollback()
+		return errors.New("solo puedes ceder tus propios turnos")
+	}
+
+	// Comprobamos que el turno no tenga ya una solicitud en curso
+	inProgress, err := s.swapRepo.CountActiveSwapsByShift(tx, shift.ID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("error al comprobar las solicitudes del turno")
+	}
+	if inProgress > 0 {
+		tx.Rollback()
+		return errors.New("el turno ya tiene una solicitud de cambio en curso")
+	}
+
+	// Guardamos la solicitud
+	swap.ID = uuid.New().String()
+	swap.Status = models.SwapStatusPending
+	swap.RequesterID = requester.ID
+	swap.AcceptedByID = nil
+	swap.DecidedByID = nil
+	swap.AcceptedAt = nil
+	swap.DecidedAt = nil
+	swap.DecisionNote = ""
+
+	if err := s.swapRepo.CreateSwap(tx, swap); err != nil {
+		tx.Rollback()
+		return errors.New("error al crear la solicitud de cambio")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetWorkerSwaps - Obtiene las solicitudes de un trabajador y las ofertas abiertas que puede aceptar
+// -------------------------------------------------------------------
+func (s *ShiftService) GetWorkerSwaps(userID string) ([]models.ShiftSwap, []models.ShiftSwap, error) {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return nil, nil, errors.New("no se encontro el trabajador del usuario")
+	}
+
+	swaps, err := s.swapRepo.GetSwapsByWorker(worker.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	open := []models.ShiftSwap{}
+	if worker.StoreID != nil && worker.Status == "Alta" {
+		open, err = s.swapRepo.GetOpenSwaps(*worker.StoreID, worker.Cargo, worker.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return swaps, open, nil
+}
+
+// AcceptSwap - La contraparte acepta una solicitud de cambio
+// -------------------------------------------------------------------
+func (s *ShiftService) AcceptSwap(userID, swapID string) error {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	// Bloqueamos la solicitud para que dos trabajadores no acepten la misma oferta
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	swap, err := s.swapRepo.LockSwapByID(tx, swapID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la solicitud no existe")
+	}
+	if swap.Status != models.SwapStatusPending {
+		tx.Rollback()
+		return errors.New("la solicitud ya no esta pendiente")
+	}
+
+	if swap.Type == models.SwapTypeOpen {
+		// En las ofertas abiertas cualquier trabajador elegible puede aceptar
+		requester, err := s.workerRepo.FindWorkerByID(swap.RequesterID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("el trabajador solicitante no existe")
+		}
+		shift, err := s.shiftRepo.FindShiftByID(tx, swap.ShiftID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("el turno no existe")
+		}
+		if worker.ID == requester.ID || !isEligibleForShift(worker, requester, shift) {
+			tx.Rollback()
+			return errors.New("no puedes aceptar esta oferta")
+		}
+		swap.TargetWorkerID = &worker.ID
+	} else if swap.TargetWorkerID == nil || *swap.TargetWorkerID != worker.ID {
+		tx.Rollback()
+		return errors.New("la solicitud no esta dirigida a ti")
+	}
+
+	now := time.Now()
+	swap.Status = models.SwapStatusAccepted
+	swap.AcceptedByID = &worker.ID
+	swap.AcceptedAt = &now
+
+	if err := s.swapRepo.UpdateSwap(tx, swap); err != nil {
+		tx.Rollback()
+		return errors.New("error al aceptar la solicitud")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// closeSwap - Cierra una solicitud con un estado final
+// -------------------------------------------------------------------
+// Bloquea la solicitud para que no pise una aprobacion concurrente. check
+// valida la solicitud bloqueada antes de cerrarla.
+func (s *ShiftService) closeSwap(userID, swapID, status, note string, check func(tx *gorm.DB, swap *models.ShiftSwap) error) error {
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	swap, err := s.swapRepo.LockSwapByID(tx, swapID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la solicitud no existe")
+	}
+	if err := check(tx, swap); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	now := time.Now()
+	swap.Status = status
+	swap.DecidedByID = &userID
+	swap.DecidedAt = &now
+	swap.DecisionNote = note
+
+	if err := s.swapRepo.UpdateSwapDecision(tx, swap); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar la solicitud")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// DeclineSwap - La contraparte rechaza una solicitud dirigida a ella
+// -------------------------------------------------------------------
+func (s *ShiftService) DeclineSwap(userID, swapID, note string) error {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	return s.closeSwap(userID, swapID, models.SwapStatusRejected, note, func(tx *gorm.DB, swap *models.ShiftSwap) error {
+		if swap.Type == models.SwapTypeOpen || swap.TargetWorkerID == nil || *swap.TargetWorkerID != worker.ID {
+			return errors.New("la solicitud no esta dirigida a ti")
+		}
+		if swap.Status != models.SwapStatusPending {
+			return errors.New("la solicitud ya no esta pendiente")
+		}
+		return nil
+	})
+}
+
+// CancelSwap - El solicitante cancela su solicitud antes de la aprobacion
+// -------------------------------------------------------------------
+func (s *ShiftService) CancelSwap(userID, swapID string) error {
+	worker, err := s.workerRepo.FindWorkerByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro el trabajador del usuario")
+	}
+
+	return s.closeSwap(userID, swapID, models.SwapStatusCancelled, "", func(tx *gorm.DB, swap *models.ShiftSwap) error {
+		if swap.RequesterID != worker.ID {
+			return errors.New("solo el solicitante puede cancelar la solicitud")
+		}
+		if swap.Status != models.SwapStatusPending && swap.Status != models.SwapStatusAccepted {
+			return errors.New("la solicitud ya no se puede cancelar")
+		}
+		return nil
+	})
+}
+
+// GetSwaps - Obtiene las solicitudes visibles para un encargado o admin
+// -------------------------------------------------------------------
+func (s *ShiftService) GetSwaps(userID, role, status string) ([]models.ShiftSwap, error) {
+	storeID := ""
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return nil, errors.New("no se encontro la tienda del usuario")
+		}
+		storeID = store.ID
+	}
+	return s.swapRepo.GetSwaps(storeID, status)
+}
+
+// ApproveSwap - El encargado o el admin aprueba un cambio aceptado y reasigna los turnos
+// -------------------------------------------------------------------
+func (s *ShiftService) ApproveSwap(userID, role, swapID, note string) error {
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Bloqueamos la solicitud y los turnos implicados
+	swap, err := s.swapRepo.LockSwapByID(tx, swapID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("la solicitud no existe")
+	}
+	if swap.Status != models.SwapStatusAccepted {
+		tx.Rollback()
+		return errors.New("solo se pueden aprobar solicitudes aceptadas por la contraparte")
+	}
+
+	shift, err := s.shiftRepo.LockShiftByID(tx, swap.ShiftID)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("el turno no existe")
+	}
+	if err := s.checkStoreAccess(userID, role, shift.Store); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if assignedWorker(shift) != swap.RequesterID {
+		tx.Rollback()
+		return errors.New("el turno ha cambiado desde que se hizo la solicitud")
+	}
+	if err := s.checkSwappable(tx, shift, "el turno"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var targetShift *models.WorkShift
+	if swap.Type == models.SwapTypeExchange {
+		targetShift, err = s.shiftRepo.LockShiftByID(tx, *swap.TargetShiftID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("el turno a intercambiar no existe")
+		}
+		if assignedWorker(targetShift) != *swap.TargetWorkerID {
+			tx.Rollback()
+			return errors.New("el turno a intercambiar ha cambiado desde que se hizo la solicitud")
+		}
+		if targetShift.Store != shift.Store {
+			tx.Rollback()
+			return errors.New("los turnos a intercambiar deben ser de la misma tienda")
+		}
+		if err := s.checkSwappable(tx, targetShift, "el turno a intercambiar"); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Validamos las reglas de planificacion con la asignacion resultante
+	if targetShift != nil {
+		if err := s.checkShiftAssignment(tx, *swap.TargetWorkerID, shift, targetShift.ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := s.checkShiftAssignment(tx, swap.RequesterID, targetShift, shift.ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	} else if err := s.checkShiftAssignment(tx, *swap.TargetWorkerID, shift); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Reasignamos los turnos
+	if err := s.reassignShift(tx, userID, shift, *swap.TargetWorkerID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if targetShift != nil {
+		if err := s.reassignShift(tx, userID, targetShift, swap.RequesterID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Registramos la decision en la solicitud
+	now := time.Now()
+	swap.Status = models.SwapStatusApproved
+	swap.DecidedByID = &userID
+	swap.DecidedAt = &now
+	swap.DecisionNote = note
+
+	if err := s.swapRepo.UpdateSwap(tx, swap); err != nil {
+		tx.Rollback()
+		return errors.New("error al actualizar la solicitud")
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// RejectSwap - El encargado o el admin rechaza una solicitud
+// -------------------------------------------------------------------
+func (s *ShiftService) RejectSwap(userID, role, swapID, note string) error {
+	return s.closeSwap(userID, swapID, models.SwapStatusRejected, note, func(tx *gorm.DB, swap *models.ShiftSwap) error {
+		shift, err := s.shiftRepo.FindShiftByID(tx, swap.ShiftID)
+		if err != nil {
+			return errors.New("el turno no existe")
+		}
+		if err := s.checkStoreAccess(userID, role, shift.Store); err != nil {
+			return err
+		}
+		if swap.Status != models.SwapStatusPending && swap.Status != models.SwapStatusAccepted {
+			return errors.New("la solicitud ya esta cerrada")
+		}
+		return nil
+	})
+}
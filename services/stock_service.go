@@ -0,0 +1,344 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/models/dtos"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Productos maximos de un recuento
+const maxCountLines = 1000
+
+type StockService struct {
+	stockRepo   *repositories.StockRepository
+	productRepo *repositories.ProductRepository
+	storeRepo   *repositories.StoreRepository
+
+	db *gorm.DB
+}
+
+func NewStockService(
+	stockRepo *repositories.StockRepository,
+	productRepo *repositories.ProductRepository,
+	storeRepo *repositories.StoreRepository,
+	db *gorm.DB) *StockService {
+	return &StockService{
+		stockRepo:   stockRepo,
+		productRepo: productRepo,
+		storeRepo:   storeRepo,
+		db:          db,
+	}
+}
+
+// applyStockMovement - Suma un movimiento al stock de la tienda y lo anota en el historial
+// -------------------------------------------------------------------
+// Lo usan las mermas y las recepciones de pedidos dentro de su transaccion.
+// Con allowNegative false no deja que el stock baje de cero.
+func applyStockMovement(tx *gorm.DB, stockRepo *repositories.StockRepository, movement *models.StockMovement, allowNegative bool) error {
+	level, err := stockRepo.LockStockLevel(tx, movement.StoreID, movement.ProductID)
+	if err != nil {
+		return errors.New("error al obtener el stock")
+	}
+	if !allowNegative && level.Quantity+movement.Quantity < 0 {
+		return fmt.Errorf("solo hay %d unidades en stock", level.Quantity)
+	}
+	level.Quantity += movement.Quantity
+	if err := stockRepo.SaveStockLevel(tx, level); err != nil {
+		return errors.New("error al actualizar el stock")
+	}
+	movement.Balance = level.Quantity
+	if err := stockRepo.CreateMovement(tx, movement); err != nil {
+		return errors.New("error al registrar el movimiento de stock")
+	}
+	return nil
+}
+
+// storeFor - Tienda sobre la que trabaja el usuario
+// -------------------------------------------------------------------
+// Las tiendas solo trabajan con la suya; el admin indica cual.
+func (s *StockService) storeFor(userID, role, storeID string) (string, error) {
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil {
+			return "", errors.New("no se encontro la tienda del usuario")
+		}
+		return store.ID, nil
+	}
+	if _, err := s.storeRepo.FindStoreByID(storeID); err != nil {
+		return "", errors.New("la tienda no existe")
+	}
+	return storeID, nil
+}
+
+// parsePeriod - Comprueba un periodo YYYY-MM-DD y lo devuelve sin la hora
+func parsePeriod(from, to string) (string, string, error) {
+	start, err := utils.ParseDate(from)
+	if err != nil {
+		return "", "", err
+	}
+	end, err := utils.ParseDate(to)
+	if err != nil {
+		return "", "", err
+	}
+	if end.Before(start) {
+		return "", "", errors.New("la fecha de fin es anterior a la de inicio")
+	}
+	return from[:10], to[:10], nil
+}
+
+// GetStock - Obtiene el stock actual de una tienda
+// -------------------------------------------------------------------
+func (s *StockService) GetStock(userID, role, storeID string) ([]models.StockLevel, error) {
+	storeID, err := s.storeFor(userID, role, storeID)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.stockRepo.GetStockLevels(storeID)
+	if err != nil {
+		return nil, errors.New("error al obtener el stock")
+	}
+	return levels, nil
+}
+
+// GetStockHistory - Obtiene los movimientos de stock de una tienda entre dos fechas
+// -------------------------------------------------------------------
+func (s *StockService) GetStockHistory(userID, role, storeID, productID, from, to string) ([]models.StockMovement, error) {
+	storeID, err := s.storeFor(userID, role, storeID)
+	if err != nil {
+		return nil, err
+	}
+	if from, to, err = parsePeriod(from, to); err != nil {
+		return nil, err
+	}
+	movements, err := s.stockRepo.GetMovements(storeID, productID, from, to)
+	if err != nil {
+		return nil, errors.New("error al obtener el historial de stock")
+	}
+	return movements, nil
+}
+
+// SubmitCount - La tienda registra un recuento y su stock pasa a ser lo contado
+// -------------------------------------------------------------------
+// Cada linea guarda el stock esperado y la diferencia, y las diferencias
+// quedan en el historial como movimientos de recuento.
+func (s *StockService) SubmitCount(userID string, count *models.StockCount) error {
+	store, err := s.storeRepo.FindStoreByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro la tienda del usuario")
+	}
+	if len(count.Lines) == 0 {
+		return errors.New("el recuento debe tener al menos un producto")
+	}
+	if len(count.Lines) > maxCountLines {
+		return fmt.Errorf("el recuento no puede tener mas de %d productos", maxCountLines)
+	}
+
+	productIDs := make([]string, 0, len(count.Lines))
+	seen := make(map[string]bool)
+	for i, line := range count.Lines {
+		if line.ProductID == "" {
+			return fmt.Errorf("el producto de la linea %d es obligatorio", i+1)
+		}
+		if line.Counted < 0 {
+			return fmt.Errorf("la cantidad contada de la linea %d no puede ser negativa", i+1)
+		}
+		if seen[line.ProductID] {
+			return fmt.Errorf("el producto de la linea %d esta repetido", i+1)
+		}
+		seen[line.ProductID] = true
+		productIDs = append(productIDs, line.ProductID)
+	}
+	found, err := s.productRepo.CountProducts(productIDs)
+	if err != nil {
+		return errors.New("error al comprobar los productos")
+	}
+	if int(found) != len(productIDs) {
+		return errors.New("alguno de los productos no existe")
+	}
+
+	today := time.Now().Format("2006-01-02")
+	count.ID = uuid.New().String()
+	count.StoreID = store.ID
+	count.Date = today
+	count.CreatedByID = userID
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i := range count.Lines {
+		line := &count.Lines[i]
+		line.ID = 0
+		line.CountID = count.ID
+
+		level, err := s.stockRepo.LockStockLevel(tx, store.ID, line.ProductID)
+		if err != nil {
+			tx.Rollback()
+			return errors.New("error al obtener el stock")
+		}
+		line.Expected = level.Quantity
+		line.Variance = line.Counted - level.Quantity
+
+		level.Quantity = line.Counted
+		level.CountedAt = &today
+		if err := s.stockRepo.SaveStockLevel(tx, level); err != nil {
+			tx.Rollback()
+			return errors.New("error al actualizar el stock")
+		}
+	}
+	if err := s.stockRepo.CreateCount(tx, count); err != nil {
+		tx.Rollback()
+		return errors.New("error al guardar el recuento")
+	}
+	for _, line := range count.Lines {
+		if line.Variance == 0 {
+			continue
+		}
+		movement := &models.StockMovement{
+			StoreID:   store.ID,
+			ProductID: line.ProductID,
+			Type:      models.StockMovementCount,
+			Quantity:  line.Variance,
+			Balance:   line.Counted,
+			CountID:   &count.ID,
+			Reason:    count.Comment,
+			ActorID:   userID,
+		}
+		if err := s.stockRepo.CreateMovement(tx, movement); err != nil {
+			tx.Rollback()
+			return errors.New("error al registrar el movimiento de stock")
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetCounts - Obtiene los recuentos de una tienda entre dos fechas
+// -------------------------------------------------------------------
+func (s *StockService) GetCounts(userID, role, storeID, from, to string) ([]models.StockCount, error) {
+	storeID, err := s.storeFor(userID, role, storeID)
+	if err != nil {
+		return nil, err
+	}
+	if from, to, err = parsePeriod(from, to); err != nil {
+		return nil, err
+	}
+	counts, err := s.stockRepo.GetCounts(storeID, from, to)
+	if err != nil {
+		return nil, errors.New("error al obtener los recuentos")
+	}
+	return counts, nil
+}
+
+// GetCount - Obtiene un recuento con sus lineas
+// -------------------------------------------------------------------
+func (s *StockService) GetCount(userID, role, countID string) (*models.StockCount, error) {
+	count, err := s.stockRepo.FindCountByID(countID)
+	if err != nil {
+		return nil, errors.New("el recuento no existe")
+	}
+	if role != "admin" {
+		store, err := s.storeRepo.FindStoreByUserID(userID)
+		if err != nil || store.ID != count.StoreID {
+			return nil, errors.New("el recuento no existe")
+		}
+	}
+	return count, nil
+}
+
+// RecordWaste - La tienda registra mermas, que restan del stock
+// -------------------------------------------------------------------
+func (s *StockService) RecordWaste(userID string, entries []dtos.StockWaste) error {
+	store, err := s.storeRepo.FindStoreByUserID(userID)
+	if err != nil {
+		return errors.New("no se encontro la tienda del usuario")
+	}
+	if len(entries) == 0 {
+		return errors.New("indica al menos un producto")
+	}
+	for i, entry := range entries {
+		if entry.Quantity <= 0 {
+			return fmt.Errorf("la cantidad de la linea %d debe ser mayor que cero", i+1)
+		}
+	}
+
+	// Iniciamos la transaccion
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return errors.New("error al iniciar la transaccion")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i, entry := range entries {
+		product, err := s.productRepo.FindProductByID(entry.ProductID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("el producto de la linea %d no existe", i+1)
+		}
+		movement := &models.StockMovement{
+			StoreID:   store.ID,
+			ProductID: product.ID,
+			Type:      models.StockMovementWaste,
+			Quantity:  -entry.Quantity,
+			Reason:    entry.Reason,
+			ActorID:   userID,
+		}
+		if err := applyStockMovement(tx, s.stockRepo, movement, false); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", product.Name, err)
+		}
+	}
+
+	// Confirmamos la transaccion
+	if err := tx.Commit().Error; err != nil {
+		return errors.New("error al confirmar la transaccion")
+	}
+	return nil
+}
+
+// GetVarianceReport - Diferencias de los recuentos por tienda y producto entre dos fechas
+// -------------------------------------------------------------------
+// Las tiendas solo ven la suya; el admin puede ver todas dejando la tienda vacia.
+func (s *StockService) GetVarianceReport(userID, role, storeID, from, to string) (*dtos.StockVarianceReport, error) {
+	var err error
+	if role != "admin" || storeID != "" {
+		if storeID, err = s.storeFor(userID, role, storeID); err != nil {
+			return nil, err
+		}
+	}
+	if from, to, err = parsePeriod(from, to); err != nil {
+		return nil, err
+	}
+	rows, err := s.stockRepo.GetCountVariances(storeID, from, to)
+	if err != nil {
+		return nil, errors.New("error al obtener las diferencias de stock")
+	}
+	if rows == nil {
+		rows = []dtos.StockVarianceRow{}
+	}
+	return &dtos.StockVarianceReport{From: from, To: to, Rows: rows}, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/javimartzs/worker-hub-backend/models"
+	"github.com/javimartzs/worker-hub-backend/repositories"
+	"github.com/javimartzs/worker-hub-backend/utils"
+)
+
+type SupplierService struct {
+	supplierRepo *repositories.SupplierRepository
+	productRepo  *repositories.ProductRepository
+}
+
+func NewSupplierService(
+	supplierRepo *repositories.SupplierRepository,
+	productRepo *repositories.ProductRepository) *SupplierService {
+	return &SupplierService{
+		supplierRepo: supplierRepo,
+		productRepo:  productRepo,
+	}
+}
+
+// CreateSupplier - Crea un proveedor; los proveedores nuevos estan activos
+// -------------------------------------------------------------------
+func (s *SupplierService) CreateSupplier(supplier *models.Supplier) error {
+	supplier.ID = uuid.New().String()
+	supplier.Active = true
+	if err := utils.ValidateSupplierFields(supplier); err != nil {
+		return err
+	}
+	if _, err := s.supplierRepo.FindSupplierByName(supplier.Name); err == nil {
+		return errors.New("ya existe un proveedor con ese nombre")
+	}
+	if err := s.supplierRepo.CreateSupplier(supplier); err != nil {
+		return errors.New("error al crear el proveedor")
+	}
+	return nil
+}
+
+// UpdateSupplier - Actualiza un proveedor
+// -------------------------------------------------------------------
+func (s *SupplierService) UpdateSupplier(supplierID string, supplier *models.Supplier) error {
+	current, err := s.supplierRepo.FindSupplierByID(supplierID)
+	if err != nil {
+		return errors.New("el proveedor no existe")
+	}
+	supplier.ID = current.ID
+	supplier.CreatedAt = current.CreatedAt
+	if err := utils.ValidateSupplierFields(supplier); err != nil {
+		return err
+	}
+	if other, err := s.supplierRepo.FindSupplierByName(supplier.Name); err == nil && other.ID != supplier.ID {
+		return errors.New("ya existe un proveedor con ese nombre")
+	}
+	if err := s.supplierRepo.UpdateSupplier(supplier); err != nil {
+		return errors.New("error al actualizar el proveedor")
+	}
+	return nil
+}
+
+// GetSuppliers - Obtiene los proveedores
+// -------------------------------------------------------------------
+func (s *SupplierService) GetSuppliers(activeOnly bool) ([]models.Supplier, error) {
+	suppliers, err := s.supplierRepo.GetSuppliers(activeOnly)
+	if err != nil {
+		return nil, errors.New("error al obtener los proveedores")
+	}
+	return suppliers, nil
+}
+
+// GetSupplier - Obtiene un proveedor con los productos que sirve
+// -------------------------------------------------------------------
+func (s *SupplierService) GetSupplier(supplierID string) (*models.Supplier, []models.Product, error) {
+	supplier, err := s.supplierRepo.FindSupplierByID(supplierID)
+	if err != nil {
+		return nil, nil, errors.New("el proveedor no existe")
+	}
+	products, err := s.supplierRepo.GetSupplierProducts(supplierID)
+	if err != nil {
+		return nil, nil, errors.New("error al obtener los productos del proveedor")
+	}
+	return supplier, products, nil
+}
+
+// AssignProducts - Pasa unos productos a un proveedor
+// -------------------------------------------------------------------
+// Cada producto tiene un solo proveedor: si ya tenia otro, lo cambia.
+func (s *SupplierService) AssignProducts(supplierID string, productIDs []string) error {
+	if _, err := s.supplierRepo.FindSupplierByID(supplierID); err != nil {
+		return errors.New("el proveedor no existe")
+	}
+	unique := make([]string, 0, len(productIDs))
+	seen := make(map[string]bool)
+	for _, productID := range productIDs {
+		if productID != "" && !seen[productID] {
+			seen[productID] = true
+			unique = append(unique, productID)
+		}
+	}
+	if len(unique) == 0 {
+		return errors.New("indica al menos un producto")
+	}
+	count, err := s.productRepo.CountProducts(unique)
+	if err != nil {
+		return errors.New("error al comprobar los productos")
+	}
+	if int(count) != len(unique) {
+		return errors.New("alguno de los productos no existe")
+	}
+	if err := s.supplierRepo.AssignProducts(supplierID, unique); err != nil {
+		return errors.New("error al asignar los productos al proveedor")
+	}
+	return nil
+}
+
+// UnassignProduct - Deja un producto sin proveedor
+// -------------------------------------------------------------------
+func (s *SupplierService) UnassignProduct(supplierID, productID string) error {
+	updated, err := s.supplierRepo.UnassignProduct(supplierID, productID)
+	if err != nil {
+		return errors.New("error al quitar el producto del proveedor")
+	}
+	if updated == 0 {
+		return errors.New("el producto no es de este proveedor")
+	}
+	return nil
+}
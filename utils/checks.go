@@ -2,31 +2,142 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/javimartzs/worker-hub-backend/models"
 )
 
+var cellColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+var leaveCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]{1,50}$`)
+
 // Función para validar los campos del trabajador
 func ValidateWorkerFields(worker *models.Worker) error {
 	if worker.Name == "" || worker.LastName == "" {
 		return errors.New("el nombre y apellido son obligatorios")
 	}
-	if worker.Email == "" {
-		return errors.New("el Email del trabajador es obligatorio")
-	}
 	if worker.Cargo == "" {
 		return errors.New("el cargo del trabajador es obligatorio")
 	}
-	if worker.Nie == "" {
-		return errors.New("el NIE del trabajador es obligatorio")
+
+	// Documento, email y Seguridad Social se guardan normalizados
+	var err error
+	if worker.Nie, err = NormalizeDocument("nie", worker.Nie); err != nil {
+		return err
+	}
+	if worker.Email, err = NormalizeEmail("email", worker.Email); err != nil {
+		return err
+	}
+	if worker.Nss != "" {
+		if worker.Nss, err = NormalizeSocialSecurity("nss", worker.Nss); err != nil {
+			return err
+		}
 	}
-	if worker.Status != "Alta" && worker.Status != "Baja" {
+	if worker.Status != "" && worker.Status != "Alta" && worker.Status != "Baja" {
 		return errors.New("el estado debe ser Alta o Baja")
 	}
-	if worker.Prueba != "Si" && worker.Prueba != "No" {
+	if worker.Prueba != "" && worker.Prueba != "Si" && worker.Prueba != "No" {
 		return errors.New("la prueba debe ser Si o No")
 	}
+	if worker.HireDate != nil && *worker.HireDate == "" {
+		worker.HireDate = nil
+	}
+	if worker.HireDate != nil {
+		if _, err := ParseDate(*worker.HireDate); err != nil {
+			return errors.New("la fecha de inicio de contrato debe tener el formato YYYY-MM-DD")
+		}
+	}
+	return nil
+}
+
+// Funcion para validar los campos de un contrato
+func ValidateContractFields(contract *models.Contract) error {
+	switch contract.Type {
+	case models.ContractTypeIndefinite, models.ContractTypeTemporary, models.ContractTypeDiscontinued:
+	default:
+		return errors.New("el tipo de contrato debe ser indefinido, temporal o fijo-discontinuo")
+	}
+	startDate, err := time.Parse("2006-01-02", contract.StartDate)
+	if err != nil {
+		return errors.New("la fecha de inicio no tiene el formato YYYY-MM-DD")
+	}
+	if contract.EndDate != nil && *contract.EndDate == "" {
+		contract.EndDate = nil
+	}
+	if contract.EndDate != nil {
+		endDate, err := time.Parse("2006-01-02", *contract.EndDate)
+		if err != nil {
+			return errors.New("la fecha de fin no tiene el formato YYYY-MM-DD")
+		}
+		if endDate.Before(startDate) {
+			return errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
+		}
+	}
+	if contract.Type == models.ContractTypeTemporary && contract.EndDate == nil {
+		return errors.New("un contrato temporal debe tener fecha de fin")
+	}
+	if contract.WeeklyHours <= 0 || contract.WeeklyHours > 40 {
+		return errors.New("las horas semanales deben estar entre 0 y 40")
+	}
+	if contract.TrialStartDate != nil && *contract.TrialStartDate == "" {
+		contract.TrialStartDate = nil
+	}
+	if contract.TrialStartDate != nil {
+		trialStart, err := time.Parse("2006-01-02", *contract.TrialStartDate)
+		if err != nil {
+			return errors.New("el inicio del periodo de prueba no tiene el formato YYYY-MM-DD")
+		}
+		if trialStart.Before(startDate) || (contract.EndDate != nil && *contract.TrialStartDate > *contract.EndDate) {
+			return errors.New("el periodo de prueba debe empezar dentro del contrato")
+		}
+	}
+	if contract.TrialDays != nil && (*contract.TrialDays < 0 || *contract.TrialDays > 365) {
+		return errors.New("el periodo de prueba debe estar entre 0 y 365 dias")
+	}
+	return nil
+}
+
+// Funcion para validar los campos de una regla de periodo de prueba
+func ValidateTrialRuleFields(rule *models.TrialPeriodRule) error {
+	rule.Category = strings.TrimSpace(rule.Category)
+	switch rule.ContractType {
+	case "", models.ContractTypeIndefinite, models.ContractTypeTemporary, models.ContractTypeDiscontinued:
+	default:
+		return errors.New("el tipo de contrato debe ser indefinido, temporal, fijo-discontinuo o vacio")
+	}
+	if rule.Days < 0 || rule.Days > 365 {
+		return errors.New("el periodo de prueba debe estar entre 0 y 365 dias")
+	}
+	return nil
+}
+
+// Funcion para validar los campos de una asignacion de tienda
+func ValidateAssignmentFields(assignment *models.StoreAssignment) error {
+	if assignment.StoreID == "" {
+		return errors.New("la tienda es obligatoria")
+	}
+	if assignment.Kind != models.AssignmentPrimary && assignment.Kind != models.AssignmentSecondary {
+		return errors.New("la asignacion debe ser principal o secundaria")
+	}
+	startDate, err := time.Parse("2006-01-02", assignment.StartDate)
+	if err != nil {
+		return errors.New("la fecha de inicio no tiene el formato YYYY-MM-DD")
+	}
+	if assignment.EndDate != nil && *assignment.EndDate == "" {
+		assignment.EndDate = nil
+	}
+	if assignment.EndDate != nil {
+		endDate, err := time.Parse("2006-01-02", *assignment.EndDate)
+		if err != nil {
+			return errors.New("la fecha de fin no tiene el formato YYYY-MM-DD")
+		}
+		if endDate.Before(startDate) {
+			return errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
+		}
+	}
 	return nil
 }
 
@@ -38,18 +149,43 @@ func ValidateStoreFields(store *models.Store) error {
 	if store.City == "" {
 		return errors.New("la ciudad de la tienda es obligatoria")
 	}
-	if store.Phone < 100000000 || store.Phone > 999999999 {
-		return errors.New("el telefono de la tienda debe tener 9 dígitos")
+	phone, err := NormalizePhone("phone", store.Phone)
+	if err != nil {
+		return err
 	}
+	store.Phone = phone
 	if store.Status == "" {
 		return errors.New("el estado de la tienda es obligatorio")
 	}
+	if store.ClaimMode != "" && store.ClaimMode != "Directo" && store.ClaimMode != "Aprobacion" {
+		return errors.New("el modo de turnos abiertos debe ser Directo o Aprobacion")
+	}
+	return nil
+}
+
+// Funcion para validar los campos de los tipos de ausencia
+func ValidateLeaveTypeFields(leaveType *models.LeaveType) error {
+	if !leaveCodePattern.MatchString(leaveType.Code) {
+		return errors.New("el codigo del tipo de ausencia solo puede tener minusculas, numeros y guiones bajos")
+	}
+	if leaveType.Name == "" {
+		return errors.New("el nombre del tipo de ausencia es obligatorio")
+	}
+	if leaveType.DayType != models.HolidayDayTypeCalendar && leaveType.DayType != models.HolidayDayTypeWorking {
+		return errors.New("el tipo de dias debe ser naturales o laborables")
+	}
+	if leaveType.MaxDays != nil && *leaveType.MaxDays <= 0 {
+		return errors.New("el maximo de dias debe ser mayor que cero")
+	}
 	return nil
 }
 
 // Funcion para validar los campos de las vacaciones
 func ValidateHolidaysFields(holiday *models.Holiday) error {
 
+	if holiday.LeaveType == "" {
+		holiday.LeaveType = models.LeaveTypeVacation // Sin tipo se consideran vacaciones
+	}
 	if holiday.WorkerID == "" {
 		return errors.New("el id del trabajador es obligatorio")
 	}
@@ -64,8 +200,14 @@ func ValidateHolidaysFields(holiday *models.Holiday) error {
 	if endDate.Before(startDate) {
 		return errors.New("la fecha de fin no puede ser anterior a la fecha de inicio")
 	}
-	if holiday.Status != "Pendientes" && holiday.Status != "Disfrutadas" {
-		return errors.New("el estado de la vacacion es obligatorio")
+	if holiday.Status == models.HolidayStatusLegacyPending {
+		holiday.Status = models.HolidayStatusApproved // Igual que en la migracion de estados
+	}
+	switch holiday.Status {
+	case models.HolidayStatusRequested, models.HolidayStatusPreApproved, models.HolidayStatusApproved,
+		models.HolidayStatusRejected, models.HolidayStatusTaken, models.HolidayStatusCancelled:
+	default:
+		return errors.New("el estado de la vacacion debe ser Solicitadas, Preaprobadas, Aprobadas, Rechazadas, Disfrutadas o Canceladas")
 	}
 
 	return nil
@@ -101,3 +243,109 @@ func ValidateTimelogFields(timelog *models.Timelog) error {
 	}
 	return nil
 }
+
+// Funcion para validar los campos de los turnos
+func ValidateShiftFields(shift *models.WorkShift) error {
+	if shift.WorkerID != nil && *shift.WorkerID == "" {
+		shift.WorkerID = nil // Un trabajador vacio equivale a un turno abierto
+	}
+	if _, err := time.Parse("2006-01-02", shift.WorkDate); err != nil {
+		return errors.New("la fecha del turno no tiene el formato YYYY-MM-DD")
+	}
+	if _, err := ParseClock(shift.StartInterval); err != nil {
+		return errors.New("la hora de entrada no tiene el formato HH:MM")
+	}
+	if _, err := ParseClock(shift.EndInterval); err != nil {
+		return errors.New("la hora de salida no tiene el formato HH:MM")
+	}
+	if shift.StartInterval == shift.EndInterval {
+		return errors.New("la hora de salida debe ser distinta de la de entrada")
+	}
+	if shift.CellColor != "" && !cellColorPattern.MatchString(shift.CellColor) {
+		return errors.New("el color de la celda debe tener el formato #RRGGBB")
+	}
+	return nil
+}
+
+// Funcion para validar las lineas de un pedido
+func ValidateOrderFields(order *models.Order) error {
+	if len(order.Lines) == 0 {
+		return errors.New("el pedido debe tener al menos una linea")
+	}
+	if len(order.Lines) > 200 {
+		return errors.New("el pedido no puede tener mas de 200 lineas")
+	}
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		if line.ProductID == nil || *line.ProductID == "" {
+			return fmt.Errorf("el producto de la linea %d es obligatorio", i+1)
+		}
+		if line.Quantity <= 0 {
+			return fmt.Errorf("la cantidad de la linea %d debe ser mayor que cero", i+1)
+		}
+	}
+	return nil
+}
+
+// Funcion para validar los campos de un producto del catalogo
+func ValidateProductFields(product *models.Product) error {
+	product.SKU = strings.TrimSpace(product.SKU)
+	product.Name = strings.TrimSpace(product.Name)
+	if !skuPattern.MatchString(product.SKU) {
+		return errors.New("el SKU es obligatorio y solo admite letras, numeros y . _ / -")
+	}
+	if product.Name == "" {
+		return errors.New("el nombre del producto es obligatorio")
+	}
+	if strings.TrimSpace(product.Unit) == "" {
+		return errors.New("la unidad de medida del producto es obligatoria")
+	}
+	if product.PackSize == 0 {
+		product.PackSize = 1
+	}
+	if product.PackSize < 0 {
+		return errors.New("el tamaño del bulto debe ser mayor que cero")
+	}
+	if product.Price < 0 {
+		return errors.New("el precio del producto no puede ser negativo")
+	}
+	return nil
+}
+
+// Funcion para validar los campos de un proveedor
+func ValidateSupplierFields(supplier *models.Supplier) error {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	supplier.Email = strings.TrimSpace(supplier.Email)
+	supplier.Phone = strings.TrimSpace(supplier.Phone)
+	if supplier.Name == "" {
+		return errors.New("el nombre del proveedor es obligatorio")
+	}
+	var err error
+	if supplier.Email != "" {
+		if supplier.Email, err = NormalizeEmail("email", supplier.Email); err != nil {
+			return err
+		}
+	}
+	if supplier.Phone != "" {
+		if supplier.Phone, err = NormalizePhone("phone", supplier.Phone); err != nil {
+			return err
+		}
+	}
+	seen := make(map[int]bool)
+	for _, day := range supplier.DeliveryDays {
+		if day < 1 || day > 7 {
+			return errors.New("los dias de reparto van del 1 (lunes) al 7 (domingo)")
+		}
+		if seen[day] {
+			return errors.New("los dias de reparto no pueden repetirse")
+		}
+		seen[day] = true
+	}
+	if supplier.LeadTimeDays < 0 || supplier.LeadTimeDays > 365 {
+		return errors.New("el plazo de entrega debe estar entre 0 y 365 dias")
+	}
+	if supplier.MinOrderAmount < 0 {
+		return errors.New("el importe minimo no puede ser negativo")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"cuatro campos", "0 0 * *"},
+		{"seis campos", "0 0 * * * *"},
+		{"minuto fuera de rango", "60 * * * *"},
+		{"hora fuera de rango", "0 24 * * *"},
+		{"dia del mes cero", "0 0 0 * *"},
+		{"mes fuera de rango", "0 0 1 13 *"},
+		{"dia de la semana fuera de rango", "0 0 * * 8"},
+		{"rango invertido", "0 5-1 * * *"},
+		{"paso cero", "*/0 * * * *"},
+		{"paso no numerico", "*/x * * * *"},
+		{"valor no numerico", "a * * * *"},
+		{"atajo desconocido", "@fortnightly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.expr); err == nil {
+				t.Errorf("ParseCron(%q) deberia fallar", tt.expr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04:05", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	// El 15 de enero de 2025 es miercoles
+	tests := []struct {
+		name  string
+		expr  string
+		after string
+		want  string // Vacio si nunca se cumple
+	}{
+		// Pasos, rangos y listas
+		{"paso de minutos", "*/15 * * * *", "2025-01-15 10:07:00", "2025-01-15 10:15:00"},
+		{"paso que cambia de hora", "*/15 * * * *", "2025-01-15 10:45:30", "2025-01-15 11:00:00"},
+		{"rango con paso", "0 9-17/4 * * *", "2025-01-15 10:00:00", "2025-01-15 13:00:00"},
+		{"rango con paso al dia siguiente", "0 9-17/4 * * *", "2025-01-15 17:00:00", "2025-01-16 09:00:00"},
+		{"valor con paso hasta el final", "10/20 * * * *", "2025-01-15 10:31:00", "2025-01-15 10:50:00"},
+		{"listas", "0,30 8,20 * * *", "2025-01-15 08:30:00", "2025-01-15 20:00:00"},
+		{"siempre posterior a after", "30 3 * * *", "2025-01-15 03:30:00", "2025-01-16 03:30:00"},
+		{"segundos descartados", "30 3 * * *", "2025-01-15 03:29:59", "2025-01-15 03:30:00"},
+
+		// Cambios de mes y de año
+		{"primero de mes", "0 0 1 * *", "2025-01-31 12:00:00", "2025-02-01 00:00:00"},
+		{"dia 31 salta los meses cortos", "0 0 31 * *", "2025-01-31 00:00:00", "2025-03-31 00:00:00"},
+		{"lista de meses", "0 0 1 3,9 *", "2025-04-01 00:00:00", "2025-09-01 00:00:00"},
+		{"cambio de año", "@yearly", "2025-06-01 00:00:00", "2026-01-01 00:00:00"},
+		{"fin de año", "59 23 31 12 *", "2025-12-31 23:59:00", "2026-12-31 23:59:00"},
+		{"29 de febrero", "0 0 29 2 *", "2025-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"30 de febrero nunca llega", "0 0 30 2 *", "2025-01-15 00:00:00", ""},
+
+		// Dia del mes y dia de la semana
+		{"laborables", "0 8 * * 1-5", "2025-01-17 09:00:00", "2025-01-20 08:00:00"},
+		{"domingo como 7", "0 10 * * 7", "2025-01-15 00:00:00", "2025-01-19 10:00:00"},
+		{"domingo como 0", "0 10 * * 0", "2025-01-15 00:00:00", "2025-01-19 10:00:00"},
+		{"solo dia del mes", "0 0 13 * *", "2025-01-15 00:00:00", "2025-02-13 00:00:00"},
+		{"solo dia de la semana", "0 0 * * 5", "2025-01-15 00:00:00", "2025-01-17 00:00:00"},
+		{"dia del mes o de la semana, gana la semana", "0 12 13 * 5", "2025-01-15 00:00:00", "2025-01-17 12:00:00"},
+		{"dia del mes o de la semana, gana el mes", "0 12 13 * 5", "2025-02-08 00:00:00", "2025-02-13 12:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			got := schedule.Next(at(tt.after))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("Next(%q, %s) = %s, want nunca", tt.expr, tt.after, got)
+				}
+				return
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%q, %s) = %s, want %s", tt.expr, tt.after, got, want)
+			}
+		})
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	madrid := time.FixedZone("CET", 3600)
+	schedule, err := ParseCron("30 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := schedule.Next(time.Date(2025, 1, 15, 4, 0, 0, 0, madrid))
+	want := time.Date(2025, 1, 16, 3, 30, 0, 0, madrid)
+	if !got.Equal(want) || got.Location() != madrid {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// Funcion para preparar la lectura de un CSV subido por un usuario
+// ------------------------------------------------------------------
+// Excel guarda los CSV en español separados por ; y a veces con BOM, asi que
+// quitamos el BOM y elegimos el separador que mas aparece en la primera linea.
+func NewCSVReader(data []byte) *csv.Reader {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	return reader
+}
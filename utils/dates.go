@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// Funcion para parsear fechas en formato YYYY-MM-DD
+// ------------------------------------------------------------------
+// Postgres devuelve las columnas date como timestamp completo
+// (2024-01-02T00:00:00Z), asi que solo tenemos en cuenta los 10
+// primeros caracteres.
+func ParseDate(value string) (time.Time, error) {
+	if len(value) < 10 {
+		return time.Time{}, errors.New("la fecha no tiene el formato YYYY-MM-DD")
+	}
+	date, err := time.Parse("2006-01-02", value[:10])
+	if err != nil {
+		return time.Time{}, errors.New("la fecha no tiene el formato YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+// Funcion para parsear horas en formato HH:MM o HH:MM:SS
+// ------------------------------------------------------------------
+func ParseClock(value string) (time.Duration, error) {
+	layout := "15:04:05"
+	if len(value) == 5 {
+		layout = "15:04"
+	}
+	clock, err := time.Parse(layout, value)
+	if err != nil {
+		return 0, errors.New("la hora no tiene el formato HH:MM:SS")
+	}
+	return time.Duration(clock.Hour())*time.Hour +
+		time.Duration(clock.Minute())*time.Minute +
+		time.Duration(clock.Second())*time.Second, nil
+}
+
+// Funcion para parsear marcas de tiempo de los registros horarios
+// ------------------------------------------------------------------
+// Acepta tanto el formato que devuelve Postgres como el que envian los
+// clientes (YYYY-MM-DD HH:MM[:SS]).
+func ParseTimestamp(value string) (time.Time, error) {
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("el registro horario no tiene el formato YYYY-MM-DD HH:MM:SS")
+}
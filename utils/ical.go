@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Evento de un calendario iCalendar (RFC 5545)
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool // Si es true solo se usa la fecha de Start y End (End exclusivo)
+	Cancelled   bool
+}
+
+// Funcion para generar un calendario iCalendar a partir de sus eventos
+// ------------------------------------------------------------------
+// Las horas se escriben en hora local flotante, igual que se guardan los turnos.
+func BuildICalendar(name string, events []ICalEvent) string {
+	var b strings.Builder
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//Worker Hub//Calendario de turnos//ES")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText(name))
+
+	for _, event := range events {
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+event.UID)
+		writeICalLine(&b, "DTSTAMP:"+stamp)
+		if event.AllDay {
+			writeICalLine(&b, "DTSTART;VALUE=DATE:"+event.Start.Format("20060102"))
+			writeICalLine(&b, "DTEND;VALUE=DATE:"+event.End.Format("20060102"))
+		} else {
+			writeICalLine(&b, "DTSTART:"+event.Start.Format("20060102T150405"))
+			writeICalLine(&b, "DTEND:"+event.End.Format("20060102T150405"))
+		}
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(event.Summary))
+		if event.Description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+escapeICalText(event.Description))
+		}
+		if event.Location != "" {
+			writeICalLine(&b, "LOCATION:"+escapeICalText(event.Location))
+		}
+		if event.Cancelled {
+			writeICalLine(&b, "STATUS:CANCELLED")
+		} else {
+			writeICalLine(&b, "STATUS:CONFIRMED")
+		}
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// escapeICalText - Escapa los caracteres especiales de un valor de texto
+func escapeICalText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// writeICalLine - Escribe una linea plegandola a 75 octetos y terminando en CRLF
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // Las lineas de continuacion empiezan por un espacio
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// Funcion para leer los eventos de un calendario iCalendar
+// ------------------------------------------------------------------
+// Solo se interpretan UID, SUMMARY, DESCRIPTION, LOCATION, DTSTART, DTEND
+// y STATUS. Los eventos de dia completo sin DTEND duran un dia.
+func ParseICalEvents(data string) ([]ICalEvent, error) {
+	events := []ICalEvent{}
+	var event *ICalEvent
+	hasEnd := false
+
+	for _, line := range unfoldICalLines(data) {
+		name, params, value := splitICalLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = &ICalEvent{}
+			hasEnd = false
+		case name == "END" && value == "VEVENT":
+			if event == nil || event.Start.IsZero() {
+				return nil, errors.New("el calendario tiene un evento sin fecha de inicio")
+			}
+			if !hasEnd {
+				event.End = event.Start
+				if event.AllDay {
+					event.End = event.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *event)
+			event = nil
+		case event == nil:
+			continue
+		case name == "UID":
+			event.UID = value
+		case name == "SUMMARY":
+			event.Summary = unescapeICalText(value)
+		case name == "DESCRIPTION":
+			event.Description = unescapeICalText(value)
+		case name == "LOCATION":
+			event.Location = unescapeICalText(value)
+		case name == "STATUS":
+			event.Cancelled = value == "CANCELLED"
+		case name == "DTSTART" || name == "DTEND":
+			date, allDay, err := parseICalDate(params, value)
+			if err != nil {
+				return nil, err
+			}
+			if name == "DTSTART" {
+				event.Start, event.AllDay = date, allDay
+			} else {
+				event.End, hasEnd = date, true
+			}
+		}
+	}
+
+	if len(events) == 0 {
+		return nil, errors.New("el calendario no contiene eventos")
+	}
+	return events, nil
+}
+
+// unfoldICalLines - Separa las lineas deshaciendo el plegado de las continuaciones
+func unfoldICalLines(data string) []string {
+	lines := []string{}
+	for _, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += raw[1:]
+			continue
+		}
+		if raw != "" {
+			lines = append(lines, raw)
+		}
+	}
+	return lines
+}
+
+// splitICalLine - Separa el nombre, los parametros y el valor de una linea
+func splitICalLine(line string) (string, string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), "", ""
+	}
+	name, params := line[:colon], ""
+	if semicolon := strings.Index(name, ";"); semicolon >= 0 {
+		name, params = name[:semicolon], name[semicolon+1:]
+	}
+	return strings.ToUpper(name), strings.ToUpper(params), line[colon+1:]
+}
+
+// parseICalDate - Interpreta un DTSTART o DTEND como fecha o fecha y hora
+func parseICalDate(params, value string) (time.Time, bool, error) {
+	if strings.Contains(params, "VALUE=DATE") || len(value) == 8 {
+		date, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, false, errors.New("fecha invalida en el calendario: " + value)
+		}
+		return date, true, nil
+	}
+	date, err := time.Parse("20060102T150405", strings.TrimSuffix(value, "Z"))
+	if err != nil {
+		return time.Time{}, false, errors.New("fecha invalida en el calendario: " + value)
+	}
+	return date, false, nil
+}
+
+// unescapeICalText - Deshace el escapado de un valor de texto
+func unescapeICalText(value string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	).Replace(value)
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Codigos de error de los datos de identidad y contacto
+const (
+	CodeRequired       = "required"
+	CodeInvalidFormat  = "invalid_format"
+	CodeInvalidLetter  = "invalid_check_letter"
+	CodeInvalidDigits  = "invalid_check_digits"
+	CodeInvalidPrefix  = "invalid_prefix"
+	CodeInvalidCountry = "invalid_country_number"
+	CodeInvalidValue   = "invalid_value" // Fuera de los valores admitidos, p. ej. en filtros
+)
+
+// FieldError - Error de validacion de un campo concreto
+// ------------------------------------------------------------------
+// Los handlers devuelven Field y Code para que el formulario marque el campo.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// Letras de control del DNI segun el resto de dividir entre 23
+const documentLetters = "TRWAGMYFPDXBNJZSQVHLCKE"
+
+var documentPattern = regexp.MustCompile(`^([XYZ]|[0-9])([0-9]{7})([A-Z])$`)
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+var spanishPhonePattern = regexp.MustCompile(`^[6789][0-9]{8}$`)
+
+// stripSeparators - Quita espacios, guiones, puntos, barras y parentesis
+func stripSeparators(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '/', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
+
+// NormalizeDocument - Valida un DNI o NIE y lo devuelve en mayusculas y sin separadores
+// ------------------------------------------------------------------
+// El NIE empieza por X, Y o Z, que cuentan como 0, 1 y 2 al calcular la
+// letra. A los DNI con menos de 8 cifras se les añaden los ceros de delante.
+func NormalizeDocument(field, value string) (string, error) {
+	document := strings.ToUpper(stripSeparators(value))
+	if document == "" {
+		return "", &FieldError{field, CodeRequired, "el DNI o NIE es obligatorio"}
+	}
+	if document[0] >= '0' && document[0] <= '9' && len(document) >= 2 && len(document) < 9 {
+		document = strings.Repeat("0", 9-len(document)) + document
+	}
+	if document[0] >= 'A' && document[0] <= 'Z' && !strings.ContainsRune("XYZ", rune(document[0])) {
+		return "", &FieldError{field, CodeInvalidPrefix, "el NIE debe empezar por X, Y o Z"}
+	}
+	parts := documentPattern.FindStringSubmatch(document)
+	if parts == nil {
+		return "", &FieldError{field, CodeInvalidFormat, "el DNI debe tener 8 cifras y una letra y el NIE una letra, 7 cifras y otra letra"}
+	}
+	prefix := strings.NewReplacer("X", "0", "Y", "1", "Z", "2").Replace(parts[1])
+	number, _ := strconv.Atoi(prefix + parts[2])
+	if documentLetters[number%23] != parts[3][0] {
+		return "", &FieldError{field, CodeInvalidLetter, "la letra del DNI o NIE no es correcta"}
+	}
+	return document, nil
+}
+
+// NormalizeEmail - Valida un email y lo devuelve sin espacios y en minusculas
+func NormalizeEmail(field, value string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(value))
+	if email == "" {
+		return "", &FieldError{field, CodeRequired, "el email es obligatorio"}
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return "", &FieldError{field, CodeInvalidFormat, "el email no es valido"}
+	}
+	at := strings.LastIndex(email, "@")
+	if domain := email[at+1:]; !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", &FieldError{field, CodeInvalidFormat, "el dominio del email no es valido"}
+	}
+	return email, nil
+}
+
+// NormalizePhone - Valida un telefono y lo devuelve en formato internacional (+34612345678)
+// ------------------------------------------------------------------
+// Sin prefijo se entiende que es español: 9 cifras empezando por 6, 7, 8 o
+// 9. Con + o 00 se admite cualquier pais con 8 a 15 cifras, y los +34 deben
+// cumplir tambien el formato español.
+func NormalizePhone(field, value string) (string, error) {
+	phone := stripSeparators(value)
+	if phone == "" {
+		return "", &FieldError{field, CodeRequired, "el telefono es obligatorio"}
+	}
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	if !strings.HasPrefix(phone, "+") {
+		if !spanishPhonePattern.MatchString(phone) {
+			return "", &FieldError{field, CodeInvalidFormat, "el telefono debe tener 9 cifras o el prefijo internacional"}
+		}
+		return "+34" + phone, nil
+	}
+	if !phonePattern.MatchString(phone) {
+		return "", &FieldError{field, CodeInvalidFormat, "el telefono internacional debe tener entre 8 y 15 cifras tras el +"}
+	}
+	if strings.HasPrefix(phone, "+34") && !spanishPhonePattern.MatchString(phone[3:]) {
+		return "", &FieldError{field, CodeInvalidCountry, "un telefono español debe tener 9 cifras empezando por 6, 7, 8 o 9"}
+	}
+	return phone, nil
+}
+
+// NormalizeSocialSecurity - Valida un numero de afiliacion a la Seguridad Social y lo deja en 12 cifras
+// ------------------------------------------------------------------
+// 2 cifras de provincia, 8 de numero y 2 de control. El control es el
+// resto entre 97 de provincia y numero juntos, o de numero + provincia *
+// 10^7 si el numero es menor de 10^7.
+func NormalizeSocialSecurity(field, value string) (string, error) {
+	nss := stripSeparators(value)
+	if nss == "" {
+		return "", &FieldError{field, CodeRequired, "el numero de la Seguridad Social es obligatorio"}
+	}
+	if len(nss) != 12 || strings.Trim(nss, "0123456789") != "" {
+		return "", &FieldError{field, CodeInvalidFormat, "el numero de la Seguridad Social debe tener 12 cifras"}
+	}
+	province, _ := strconv.ParseInt(nss[:2], 10, 64)
+	number, _ := strconv.ParseInt(nss[2:10], 10, 64)
+	control, _ := strconv.ParseInt(nss[10:], 10, 64)
+	base := province*100000000 + number
+	if number < 10000000 {
+		base = number + province*10000000
+	}
+	if base%97 != control {
+		return "", &FieldError{field, CodeInvalidDigits, "los digitos de control del numero de la Seguridad Social no son correctos"}
+	}
+	return nss, nil
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+// identityCase - Valor de entrada y resultado esperado de una normalizacion
+type identityCase struct {
+	name  string
+	value string
+	want  string // Valor normalizado si code esta vacio
+	code  string // Codigo del FieldError esperado
+}
+
+func runIdentityCases(t *testing.T, normalize func(field, value string) (string, error), tests []identityCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalize("campo", tt.value)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("%q: error inesperado %v", tt.value, err)
+				}
+				if got != tt.want {
+					t.Errorf("%q = %q, want %q", tt.value, got, tt.want)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("%q: se esperaba un FieldError %s, got %v", tt.value, tt.code, err)
+			}
+			if fieldErr.Code != tt.code || fieldErr.Field != "campo" {
+				t.Errorf("%q: error %s en %s, want %s en campo", tt.value, fieldErr.Code, fieldErr.Field, tt.code)
+			}
+		})
+	}
+}
+
+func TestNormalizeDocument(t *testing.T) {
+	runIdentityCases(t, NormalizeDocument, []identityCase{
+		{"DNI valido", "12345678Z", "12345678Z", ""},
+		{"DNI en minusculas con separadores", " 12.345.678-z ", "12345678Z", ""},
+		{"DNI con ceros de delante omitidos", "1234567L", "01234567L", ""},
+		{"NIE con X", "X1234567L", "X1234567L", ""},
+		{"NIE con Y", "y-1234567-x", "Y1234567X", ""},
+		{"NIE con Z", "Z1234567R", "Z1234567R", ""},
+		{"la X cuenta como 0", "X1234567Z", "", CodeInvalidLetter},
+		{"letra del DNI incorrecta", "12345678A", "", CodeInvalidLetter},
+		{"letra del NIE incorrecta", "Y1234567L", "", CodeInvalidLetter},
+		{"prefijo de NIE no valido", "A1234567L", "", CodeInvalidPrefix},
+		{"sin letra", "12345678", "", CodeInvalidFormat},
+		{"demasiadas cifras", "123456789Z", "", CodeInvalidFormat},
+		{"vacio", "  ", "", CodeRequired},
+	})
+}
+
+func TestNormalizeSocialSecurity(t *testing.T) {
+	runIdentityCases(t, NormalizeSocialSecurity, []identityCase{
+		{"numero de 8 cifras", "281234567840", "281234567840", ""},
+		{"con separadores", "28/12345678/40", "281234567840", ""},
+		{"numero menor de 10^7", "280123456742", "280123456742", ""},
+		{"numero menor de 10^7 con el control concatenado", "280123456785", "", CodeInvalidDigits},
+		{"provincia con cero", "08 99999999 49", "089999999949", ""},
+		{"control incorrecto", "281234567841", "", CodeInvalidDigits},
+		{"cifras de menos", "2812345678", "", CodeInvalidFormat},
+		{"con letras", "28123456784A", "", CodeInvalidFormat},
+		{"vacio", "", "", CodeRequired},
+	})
+}
+
+func TestNormalizePhone(t *testing.T) {
+	runIdentityCases(t, NormalizePhone, []identityCase{
+		{"movil sin prefijo", "612345678", "+34612345678", ""},
+		{"fijo con espacios", "91 123 45 67", "+34911234567", ""},
+		{"prefijo +34", "+34 612-345-678", "+34612345678", ""},
+		{"prefijo 0034", "0034612345678", "+34612345678", ""},
+		{"otro pais", "+44 7911 123456", "+447911123456", ""},
+		{"otro pais con 00", "0033612345678", "+33612345678", ""},
+		{"sin prefijo no español", "512345678", "", CodeInvalidFormat},
+		{"sin prefijo con cifras de menos", "61234567", "", CodeInvalidFormat},
+		{"+34 no español", "+34512345678", "", CodeInvalidCountry},
+		{"+34 con cifras de mas", "+346123456789", "", CodeInvalidCountry},
+		{"internacional demasiado corto", "+1234", "", CodeInvalidFormat},
+		{"vacio", "", "", CodeRequired},
+	})
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	runIdentityCases(t, NormalizeEmail, []identityCase{
+		{"mayusculas y espacios", " Ana.Garcia@Example.COM ", "ana.garcia@example.com", ""},
+		{"sin dominio de primer nivel", "ana@localhost", "", CodeInvalidFormat},
+		{"con nombre", "Ana <ana@example.com>", "", CodeInvalidFormat},
+		{"sin arroba", "ana.example.com", "", CodeInvalidFormat},
+		{"vacio", " ", "", CodeRequired},
+	})
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Medidas de una pagina A4 en puntos
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+)
+
+// Columna de una fila de un PDF; X es la distancia al margen izquierdo
+type PDFColumn struct {
+	X    float64
+	Text string
+}
+
+// PDFDocument - Documento PDF de texto en A4 con las fuentes Helvetica
+// ------------------------------------------------------------------
+// Basta para listados y albaranes: titulos, parrafos, filas en columnas y
+// lineas de separacion. Las paginas se añaden solas al llegar al final.
+type PDFDocument struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+func NewPDFDocument() *PDFDocument {
+	document := &PDFDocument{}
+	document.AddPage()
+	return document
+}
+
+// AddPage - Empieza una pagina nueva
+func (d *PDFDocument) AddPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// space - Baja el cursor y cambia de pagina si no queda sitio
+func (d *PDFDocument) space(height float64) {
+	if d.y-height < pdfMargin {
+		d.AddPage()
+	}
+	d.y -= height
+}
+
+// text - Escribe un texto en una posicion de la pagina actual
+func (d *PDFDocument) text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(text))
+}
+
+// Heading - Escribe un titulo
+func (d *PDFDocument) Heading(text string) {
+	d.space(20)
+	d.text(pdfMargin, d.y, 14, true, text)
+	d.space(4)
+}
+
+// Paragraph - Escribe una linea de texto normal
+func (d *PDFDocument) Paragraph(text string) {
+	d.space(14)
+	d.text(pdfMargin, d.y, 10, false, text)
+}
+
+// Row - Escribe una fila de columnas; en negrita para las cabeceras
+func (d *PDFDocument) Row(columns []PDFColumn, bold bool) {
+	d.space(14)
+	for _, column := range columns {
+		d.text(pdfMargin+column.X, d.y, 9, bold, column.Text)
+	}
+}
+
+// Rule - Dibuja una linea horizontal de margen a margen
+func (d *PDFDocument) Rule() {
+	d.space(6)
+	fmt.Fprintf(d.current, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, d.y+3, pdfPageWidth-pdfMargin, d.y+3)
+}
+
+// Bytes - Genera el fichero PDF
+// ------------------------------------------------------------------
+// Objetos: 1 catalogo, 2 arbol de paginas, 3 y 4 fuentes y despues cada
+// pagina seguida de su contenido.
+func (d *PDFDocument) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// pdfEscape - Pasa un texto a WinAnsi y escapa los caracteres especiales de PDF
+func pdfEscape(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r == '€':
+			out.WriteString("\\200")
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&out, "\\%03o", r)
+		case r >= 0x20 && r < 0x7F:
+			out.WriteRune(r)
+		default:
+			out.WriteByte('?')
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tamaño maximo descomprimido de cada parte del XLSX que se lee
+const maxXLSXPartSize = 32 << 20
+
+// Columnas que se leen de la cabecera de un XLSX
+const maxXLSXColumns = 100
+
+var errXLSXTooLarge = errors.New("el XLSX es demasiado grande")
+
+// Funcion para leer las filas de un CSV o de la primera hoja de un XLSX
+// ------------------------------------------------------------------
+// El XLSX se reconoce por ser un zip, sea cual sea la extension. Las filas
+// vacias intermedias del XLSX se devuelven vacias para no descuadrar los
+// numeros de linea, asi que maxRows limita el numero de fila tras la
+// cabecera y no solo las filas con datos.
+func ReadSpreadsheet(data []byte, maxRows int) ([][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return readXLSX(data, maxRows)
+	}
+	rows, err := NewCSVReader(data).ReadAll()
+	if err != nil {
+		return nil, errors.New("no se pudo leer el CSV")
+	}
+	return rows, nil
+}
+
+// ExcelDate - Pasa una fecha de una celda a YYYY-MM-DD
+// ------------------------------------------------------------------
+// Excel guarda las fechas como dias desde el 30/12/1899 y el XLSX no dice
+// que la celda es una fecha sin leer los estilos, asi que los numeros se
+// convierten aqui. Cualquier otro texto se devuelve tal cual.
+func ExcelDate(value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 1 {
+		return value
+	}
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format("2006-01-02")
+}
+
+// Partes del XLSX que se leen
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var out strings.Builder
+	for _, run := range t.Runs {
+		out.WriteString(run.Text)
+	}
+	return out.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX - Lee la primera hoja de un XLSX
+// ------------------------------------------------------------------
+// Las referencias de fila y columna vienen del fichero, asi que no se
+// rellenan mas alla de maxRows ni del ancho de la cabecera, y cada parte se
+// lee con un limite para que un zip muy comprimido no agote la memoria.
+func readXLSX(data []byte, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("no se pudo abrir el XLSX")
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	decode := func(name string, target interface{}) error {
+		file, ok := files[name]
+		if !ok {
+			return io.ErrUnexpectedEOF
+		}
+		if file.UncompressedSize64 > maxXLSXPartSize {
+			return errXLSXTooLarge
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		limited := &io.LimitedReader{R: reader, N: maxXLSXPartSize}
+		if err := xml.NewDecoder(limited).Decode(target); err != nil {
+			if limited.N == 0 {
+				return errXLSXTooLarge
+			}
+			return err
+		}
+		return nil
+	}
+	failed := func(err error, message string) error {
+		if errors.Is(err, errXLSXTooLarge) {
+			return err
+		}
+		return errors.New(message)
+	}
+
+	// Buscamos el fichero de la primera hoja a traves de las relaciones del libro
+	var workbook xlsxWorkbook
+	var relationships xlsxRelationships
+	if err := decode("xl/workbook.xml", &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return nil, failed(err, "el XLSX no tiene hojas")
+	}
+	if err := decode("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, failed(err, "el XLSX esta dañado")
+	}
+	sheetPath := ""
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID == workbook.Sheets[0].RelID {
+			sheetPath = relationship.Target
+			if strings.HasPrefix(sheetPath, "/") {
+				sheetPath = strings.TrimPrefix(sheetPath, "/")
+			} else {
+				sheetPath = path.Join("xl", sheetPath)
+			}
+		}
+	}
+
+	// Los textos suelen ir en la tabla de textos compartidos
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decode("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, failed(err, "el XLSX esta dañado")
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decode(sheetPath, &sheet); err != nil {
+		return nil, failed(err, "no se pudo leer la primera hoja del XLSX")
+	}
+	var rows [][]string
+	width := maxXLSXColumns
+	for _, row := range sheet.Rows {
+		if row.Number == 0 {
+			row.Number = len(rows) + 1
+		}
+		if row.Number > maxRows+1 {
+			return nil, fmt.Errorf("el fichero no puede tener mas de %d filas", maxRows)
+		}
+		if row.Number <= len(rows) {
+			return nil, errors.New("el XLSX esta dañado")
+		}
+		// Rellenamos las filas que Excel omite por estar vacias
+		for row.Number > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+		var record []string
+		for i, c := range row.Cells {
+			column := xlsxColumn(c.Ref)
+			if column < 0 {
+				column = i
+			}
+			if column >= width {
+				continue // Fuera de la cabecera no hay campo que leer
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+			switch c.Type {
+			case "s":
+				index, err := strconv.Atoi(c.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, errors.New("el XLSX esta dañado")
+				}
+				record[column] = shared.Items[index].String()
+			case "inlineStr":
+				record[column] = c.Inline.String()
+			default:
+				record[column] = c.Value
+			}
+		}
+		if len(rows) == 0 {
+			width = len(record)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// xlsxColumn - Indice de la columna de una referencia de celda, A1 -> 0
+func xlsxColumn(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+	return column - 1
+}